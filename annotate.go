@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// annotateEntries marks each entry's title with a footnote number, e.g.
+// "Add login page [1]", numbered in the order of the report, and returns
+// the footnotes saying where the entries came from, e.g.
+// "[1] https://github.com/octocat/app/pull/12, events 3861, 3864"
+func annotateEntries(meetings, entries []reportEntry, grouping entryGrouping) ([]reportEntry, reportSection) {
+	section := reportSection{title: "Sources"}
+	numbers := make(map[string]int)
+	for _, block := range buildBlocks(meetings, entries, grouping) {
+		for _, entry := range block.entries {
+			key := entry.repo + "\x00" + entry.title
+			if _, numbered := numbers[key]; numbered || isMeeting(meetings, entry) {
+				continue
+			}
+			numbers[key] = len(numbers) + 1
+
+			var source []string
+			if u := entry.url(); u != "" {
+				source = append(source, u)
+			}
+			switch {
+			case len(entry.events) > 0:
+				source = append(source, "events "+strings.Join(entry.events, ", "))
+			case entry.eventType == "":
+				source = append(source, "added by hand")
+			}
+			section.lines = append(section.lines, reportLine{
+				text: fmt.Sprintf("[%d] %s", numbers[key], strings.Join(source, ", ")),
+				repo: entry.repo,
+			})
+		}
+	}
+
+	annotated := make([]reportEntry, len(entries))
+	for i, entry := range entries {
+		annotated[i] = entry
+		if n, ok := numbers[entry.repo+"\x00"+entry.title]; ok {
+			annotated[i].title = fmt.Sprintf("%s [%d]", entry.title, n)
+		}
+	}
+	return annotated, section
+}
+
+// isMeeting tells whether the entry is one of the meetings, which come from
+// the calendar rather than events
+func isMeeting(meetings []reportEntry, entry reportEntry) bool {
+	for _, meeting := range meetings {
+		if meeting.title == entry.title && meeting.status == entry.status {
+			return true
+		}
+	}
+	return false
+}
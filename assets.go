@@ -0,0 +1,68 @@
+package main
+
+import (
+	"embed"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// Default templates, locale catalogs and web pages, built into the binary
+//
+//go:embed assets
+var embeddedAssets embed.FS
+
+// assetDirs returns the directories whose files shadow the built-in assets,
+// searched in order: ASSETS_DIR, then daily-reporting in the user config
+// directory, e.g. ~/.config/daily-reporting/locales/ja.json replaces the
+// built-in locales/ja.json
+func assetDirs() []string {
+	var dirs []string
+	if dir := os.Getenv("ASSETS_DIR"); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	if dir, err := os.UserConfigDir(); err == nil {
+		dirs = append(dirs, filepath.Join(dir, "daily-reporting"))
+	}
+	return dirs
+}
+
+// readAsset reads an asset such as "templates/retro.tmpl" from the first
+// override directory that has it, the built-in one otherwise
+func readAsset(name string) ([]byte, error) {
+	for _, dir := range assetDirs() {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err == nil {
+			return data, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return embeddedAssets.ReadFile(path.Join("assets", name))
+}
+
+// assetNames lists the assets of a directory such as "locales", built-in
+// and overriding ones alike, by name
+func assetNames(dir string) []string {
+	seen := make(map[string]bool)
+	if entries, err := embeddedAssets.ReadDir(path.Join("assets", dir)); err == nil {
+		for _, entry := range entries {
+			seen[entry.Name()] = true
+		}
+	}
+	for _, override := range assetDirs() {
+		entries, err := os.ReadDir(filepath.Join(override, filepath.FromSlash(dir)))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				seen[entry.Name()] = true
+			}
+		}
+	}
+	return sortedKeys(seen)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// An entry of an issue's timeline of events
+type issueEvent struct {
+	Event     string `json:"event"`
+	CreatedAt string `json:"created_at"`
+	Assignee  struct {
+		Login string `json:"login"`
+	} `json:"assignee"`
+	RequestedReviewer struct {
+		Login string `json:"login"`
+	} `json:"requested_reviewer"`
+	Actor struct {
+		Login string `json:"login"`
+	} `json:"actor"`
+}
+
+// getNewlyAssigned lists the issues and pull requests assigned to me on the
+// given date, whether or not there was any other activity on them
+func getNewlyAssigned(ctx context.Context, date, username, token string) ([]reportLine, error) {
+	issues, err := searchIssues(ctx, fmt.Sprintf("assignee:%s updated:>=%s", username, date), token)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []reportLine
+	for _, issue := range issues {
+		var events []issueEvent
+		u := fmt.Sprintf("%s/issues/%d/events?per_page=100", issue.RepositoryURL, issue.Number)
+		if err := githubGet(ctx, u, token, &events); err != nil {
+			return nil, err
+		}
+
+		for _, e := range events {
+			if e.Event == "assigned" && e.Assignee.Login == username && onDate(e.CreatedAt, date) {
+				lines = append(lines, reportLine{text: fmt.Sprintf("%s (%s#%d)", issue.Title, issue.repoName(), issue.Number), repo: issue.repoName()})
+				break
+			}
+		}
+	}
+	return lines, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Characters left out of the field names of status groups
+var fieldSeparators = regexp.MustCompile(`[^a-z0-9]+`)
+
+// statusField returns the field name of a status group, e.g. "in_review"
+func statusField(group string) string {
+	return strings.Trim(fieldSeparators.ReplaceAllString(strings.ToLower(group), "_"), "_")
+}
+
+// automationFields lays the report out as the flat fields of webhook
+// targets: text and numbers only, no nesting, so Zapier and Make map them
+// without parsing. Multi-line fields have one item per line.
+//
+//	date         2024-05-10
+//	revision     1, 2, ... for the revisions of the day's report
+//	updated      true when the report replaces one delivered before
+//	header       "May 10, 2024"
+//	report       the report as delivered to text targets
+//	entry_count  number of entries, meetings included
+//	entries      "Status | Title" lines
+//	urls         links of the entries, in the order of entries
+//	done, in_review, wip, committed, reviewed
+//	             titles of the entries of each status group, always present
+//	incidents    "[SEV1] Title" lines of the incident fixes
+//	next         tomorrow's plan
+//	sections     the extra sections, as in the report
+func automationFields(rep *generatedReport, report string, orgs []string) map[string]interface{} {
+	fields := map[string]interface{}{
+		"date":     rep.date,
+		"revision": rep.revision,
+		"updated":  rep.replaces,
+		"header":   rep.header,
+		"report":   report,
+	}
+
+	byGroup := make(map[string][]string)
+	for _, group := range defaultStatusOrder {
+		byGroup[statusField(group)] = nil
+	}
+	var entries, urls, incidents []string
+	for _, entry := range append(append([]reportEntry{}, rep.meetings...), filterEntries(rep.entries, orgs)...) {
+		entries = append(entries, fmt.Sprintf("%s | %s", entry.status, entry.title))
+		urls = append(urls, entry.url())
+		if entry.severity != "" {
+			incidents = append(incidents, fmt.Sprintf("[%s] %s", entry.severity, entry.title))
+		}
+
+		group, ok := statusGroups[entry.status]
+		if !ok {
+			group = entry.status
+		}
+		byGroup[statusField(group)] = append(byGroup[statusField(group)], entry.title)
+	}
+	fields["entry_count"] = len(entries)
+	fields["entries"] = strings.Join(entries, "\n")
+	fields["urls"] = strings.Join(urls, "\n")
+	fields["incidents"] = strings.Join(incidents, "\n")
+
+	next := []string{rep.messages.text(defaultNext)}
+	if lines := filterLines(rep.next, orgs); len(lines) > 0 {
+		next = nil
+		for _, line := range lines {
+			next = append(next, line.text)
+		}
+	}
+	fields["next"] = strings.Join(next, "\n")
+
+	var sections []string
+	for _, section := range filterSections(rep.sections, orgs) {
+		if len(section.lines) == 0 {
+			continue
+		}
+		sections = append(sections, rep.messages.text(section.title)+":")
+		for _, line := range section.lines {
+			sections = append(sections, line.text)
+		}
+	}
+	fields["sections"] = strings.Join(sections, "\n")
+
+	// A status named like a field above doesn't replace it
+	for field, titles := range byGroup {
+		if _, taken := fields[field]; !taken {
+			fields[field] = strings.Join(titles, "\n")
+		}
+	}
+	return fields
+}
+
+// postToAutomation sends the report's fields to a Zapier or Make webhook
+func postToAutomation(ctx context.Context, t target, rep *generatedReport, report string) error {
+	return sendJSON(ctx, "POST", t.URL, nil, automationFields(rep, report, t.Orgs), nil)
+}
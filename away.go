@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Workdays without a report from which I count as having been away, rather
+// than having skipped a report
+const minAwayDays = 2
+
+// Longest absence looked back over
+const maxAwayDays = 60
+
+// parseDays parses a day, "2024-08-12", or an inclusive range of days,
+// "2024-08-12..2024-08-16"
+func parseDays(days string) (from, to time.Time, err error) {
+	first, last, isRange := strings.Cut(days, "..")
+	if from, err = time.ParseInLocation(dateFormat, first, time.Local); err != nil {
+		return from, to, fmt.Errorf("invalid day %q, expected YYYY-MM-DD or YYYY-MM-DD..YYYY-MM-DD", days)
+	}
+	to = from
+	if isRange {
+		if to, err = time.ParseInLocation(dateFormat, last, time.Local); err != nil || to.Before(from) {
+			return from, to, fmt.Errorf("invalid range %q, expected YYYY-MM-DD..YYYY-MM-DD", days)
+		}
+	}
+	return from, to, nil
+}
+
+// awayOn reports whether the day is one of the away days of the config
+func (cfg *config) awayOn(day time.Time) bool {
+	for _, days := range cfg.Away {
+		from, to, err := parseDays(days)
+		if err == nil && !day.Before(from) && !day.After(to) {
+			return true
+		}
+	}
+	return false
+}
+
+// lastAbsence returns the days I was away up to yesterday: the registered
+// away days and the workdays without a stored report, back to the last day
+// with a report. Weekends in between are part of it. ok is false when fewer
+// than minAwayDays days were missed.
+func lastAbsence(h *historyStore, cfg *config, today time.Time) (from, to time.Time, ok bool, err error) {
+	records, err := h.list()
+	if err != nil || len(records) == 0 {
+		return from, to, false, err
+	}
+	reported := make(map[string]bool)
+	for _, r := range records {
+		reported[r.date] = true
+	}
+	earliest := records[len(records)-1].date
+
+	var missed int
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.Local)
+	for day := today.AddDate(0, 0, -1); day.Format(dateFormat) > earliest && today.Sub(day) <= maxAwayDays*24*time.Hour; day = day.AddDate(0, 0, -1) {
+		date := day.Format(dateFormat)
+		weekend := day.Weekday() == time.Saturday || day.Weekday() == time.Sunday
+		if reported[date] {
+			break
+		}
+		if !cfg.awayOn(day) && weekend {
+			continue
+		}
+		if to.IsZero() {
+			to = day
+		}
+		from = day
+		missed++
+	}
+	return from, to, missed >= minAwayDays, nil
+}
+
+// awaySummary lists what happened on GitHub while I was away, for my own
+// catch-up: my pull requests merged, the reviews and issues waiting for me,
+// the mentions and the activity on my open pull requests
+func awaySummary(ctx context.Context, cfg *config, token string, from, to time.Time) (string, error) {
+	username := cfg.GitHub.Username
+	period := from.Format(dateFormat) + ".." + to.Format(dateFormat)
+	queries := []struct {
+		title, query string
+	}{
+		{"Merged while you were away", "type:pr author:%s is:merged merged:" + period},
+		{"Review requests", "type:pr is:open review-requested:%s"},
+		{"Assigned to you", "type:issue is:open assignee:%s updated:" + period},
+		{"Mentions", "mentions:%s updated:" + period},
+		{"Activity on your pull requests", "type:pr is:open author:%s updated:" + period},
+	}
+
+	messages := cfg.messages()
+	report := messages.textf("Since you were away, %s to %s", cfg.formatHeaderDate(from), cfg.formatHeaderDate(to)) + ":\n"
+	for _, q := range queries {
+		issues, err := searchIssues(ctx, fmt.Sprintf(q.query, username), token)
+		if err != nil {
+			return "", err
+		}
+		if len(issues) == 0 {
+			continue
+		}
+		report += "\n" + messages.text(q.title) + ":\n"
+		for _, issue := range issues {
+			report += fmt.Sprintf("%s (%s#%d)\n", issue.Title, issue.repoName(), issue.Number)
+		}
+	}
+	return report, nil
+}
+
+// offerAwaySummary points to "catchup" after an absence, on the first
+// report since
+func offerAwaySummary(cfg *config, today time.Time) {
+	h, err := openHistory(historyPath())
+	if err != nil {
+		return
+	}
+	defer h.Close()
+	if from, to, ok, err := lastAbsence(h, cfg, today); err == nil && ok {
+		slog.Info("Welcome back, run catchup for what happened while you were away",
+			"from", from.Format(dateFormat), "to", to.Format(dateFormat))
+	}
+}
+
+// catchup implements "catchup [--since YYYY-MM-DD]", printing what happened
+// while I was away, from the last absence or since the given day
+func catchup(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("catchup", flag.ExitOnError)
+	since := fs.String("since", "", "first day away, the start of the last absence by default")
+	fs.Parse(args)
+
+	cfg, err := loadConfig(configPath())
+	if err != nil {
+		return err
+	}
+	token, err := githubToken(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var from, to time.Time
+	if *since != "" {
+		if from, err = time.ParseInLocation(dateFormat, *since, time.Local); err != nil {
+			return fmt.Errorf("invalid date %q, expected YYYY-MM-DD", *since)
+		}
+		to = time.Date(now.Year(), now.Month(), now.Day()-1, 0, 0, 0, 0, time.Local)
+	} else {
+		h, err := openHistory(historyPath())
+		if err != nil {
+			return err
+		}
+		var ok bool
+		from, to, ok, err = lastAbsence(h, cfg, now)
+		h.Close()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("No absence found since the last report, give --since")
+			return nil
+		}
+	}
+
+	summary, err := awaySummary(ctx, cfg, token, from, to)
+	if err != nil {
+		return err
+	}
+	fmt.Print(summary)
+	return nil
+}
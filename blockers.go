@@ -0,0 +1,350 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const slackAPI = "https://slack.com/api"
+
+// blockersConfig asks for blockers in a Slack DM, see "blockers ask"
+type blockersConfig struct {
+	// Member ID of the Slack user asked, e.g. "U024BE7LH"
+	SlackUser string `json:"slack_user"`
+	// Bot token with the im:write, chat:write and im:history scopes,
+	// SLACK_BOT_TOKEN when not set
+	SlackToken string `json:"slack_token,omitempty"`
+}
+
+func (c *blockersConfig) token() string {
+	if c.SlackToken != "" {
+		return c.SlackToken
+	}
+	return os.Getenv("SLACK_BOT_TOKEN")
+}
+
+// A blocker, open from the day it was raised until the day it was resolved
+type blocker struct {
+	id       int
+	text     string
+	raised   string
+	resolved string
+}
+
+// addBlocker stores a blocker raised on the day, unless the same one is
+// still open or was resolved that day
+func (h *historyStore) addBlocker(date, text string) error {
+	_, err := h.db.Exec(`INSERT INTO blockers (text, raised) SELECT ?, ?
+		WHERE NOT EXISTS (SELECT 1 FROM blockers WHERE text = ? AND (resolved = '' OR resolved = ?))`, text, date, text, date)
+	return err
+}
+
+// resolveBlocker marks the blocker resolved on the day, if still open.
+// Resolving it again on the same day, as answers read again do, is a no-op.
+func (h *historyStore) resolveBlocker(id int, date string) error {
+	res, err := h.db.Exec(`UPDATE blockers SET resolved = ? WHERE id = ? AND (resolved = '' OR resolved = ?)`, date, id, date)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("no open blocker #%d", id)
+	}
+	return nil
+}
+
+// openBlockers returns the blockers open on the day: raised by then and not
+// resolved before, oldest first
+func (h *historyStore) openBlockers(date string) ([]blocker, error) {
+	rows, err := h.db.Query(`SELECT id, text, raised, resolved FROM blockers
+		WHERE raised <= ? AND (resolved = '' OR resolved >= ?) ORDER BY id`, date, date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var blockers []blocker
+	for rows.Next() {
+		var b blocker
+		if err := rows.Scan(&b.id, &b.text, &b.raised, &b.resolved); err != nil {
+			return nil, err
+		}
+		blockers = append(blockers, b)
+	}
+	return blockers, rows.Err()
+}
+
+// Load the blockers open on the day
+func loadOpenBlockers(date string) ([]blocker, error) {
+	h, err := openHistory(historyPath())
+	if err != nil {
+		return nil, err
+	}
+	defer h.Close()
+
+	return h.openBlockers(date)
+}
+
+// blockersSection lists the blockers open on the day, those carried over
+// with the day they were raised, and those resolved that day as such
+func blockersSection(blockers []blocker, date string, c catalog) reportSection {
+	section := reportSection{title: "Blockers"}
+	for _, b := range blockers {
+		text := b.text
+		switch {
+		case b.resolved == date:
+			text = c.textf("%s (resolved)", text)
+		case b.raised < date:
+			text = c.textf("%s (since %s)", text, b.raised)
+		}
+		section.lines = append(section.lines, reportLine{text: text})
+	}
+	return section
+}
+
+// Answers meaning there is no blocker
+var noBlockers = regexp.MustCompile(`(?i)^(no|none|nope|nothing|n/a|-)\.?$`)
+
+// Answers resolving blockers by number, e.g. "resolved 3" or "done #3, #5"
+var resolvedBlockers = regexp.MustCompile(`(?i)^(resolved|done|fixed)\s+(#?\d+(\s*,\s*#?\d+)*)$`)
+
+// parseBlockerAnswer reads an answer to "Any blockers?": a blocker per line,
+// "resolved N" lines resolving open blockers, "no" or "none" for nothing new
+func parseBlockerAnswer(answer string) (raised []string, resolved []int) {
+	for _, line := range strings.Split(answer, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "-*•"))
+		if line == "" || noBlockers.MatchString(line) {
+			continue
+		}
+		if m := resolvedBlockers.FindStringSubmatch(line); m != nil {
+			for _, number := range strings.Split(m[2], ",") {
+				id, _ := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(number), "#"))
+				resolved = append(resolved, id)
+			}
+			continue
+		}
+		raised = append(raised, line)
+	}
+	return raised, resolved
+}
+
+// applyBlockerAnswer stores the blockers raised and resolved by an answer
+func (h *historyStore) applyBlockerAnswer(date, answer string) error {
+	raised, resolved := parseBlockerAnswer(answer)
+	for _, text := range raised {
+		if err := h.addBlocker(date, text); err != nil {
+			return err
+		}
+	}
+	var errs []error
+	for _, id := range resolved {
+		errs = append(errs, h.resolveBlocker(id, date))
+	}
+	return errors.Join(errs...)
+}
+
+// blockerQuestion asks "Any blockers?", listing the open ones by number
+func blockerQuestion(open []blocker) string {
+	var b strings.Builder
+	b.WriteString("Any blockers? One per line, or \"no\".\n")
+	if slices.ContainsFunc(open, func(o blocker) bool { return o.resolved == "" }) {
+		b.WriteString("Still open, answer \"resolved N\" for those that aren't anymore:\n")
+		for _, o := range open {
+			if o.resolved != "" {
+				continue
+			}
+			fmt.Fprintf(&b, "#%d %s (since %s)\n", o.id, o.text, o.raised)
+		}
+	}
+	return b.String()
+}
+
+// askBlockers asks for blockers on the terminal before the report, reading
+// the answer up to an empty line
+func askBlockers(date string, in io.Reader) error {
+	h, err := openHistory(historyPath())
+	if err != nil {
+		return err
+	}
+	defer h.Close()
+
+	open, err := h.openBlockers(date)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(os.Stderr, blockerQuestion(open))
+
+	var lines []string
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() && strings.TrimSpace(scanner.Text()) != "" {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return h.applyBlockerAnswer(date, strings.Join(lines, "\n"))
+}
+
+// slackCall calls a method of the Slack Web API, which tells errors apart
+// by "ok" rather than the status code
+func slackCall(ctx context.Context, token, method string, params url.Values, body, out interface{}) error {
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	headers := map[string]string{"Authorization": "Bearer " + token}
+	u := slackAPI + "/" + method
+	httpMethod := "POST"
+	if params != nil {
+		u, httpMethod = u+"?"+params.Encode(), "GET"
+	}
+	var raw json.RawMessage
+	if err := sendJSON(ctx, httpMethod, u, headers, body, &raw); err != nil {
+		return err
+	}
+	if err := parseJSON(raw, &result); err != nil {
+		return err
+	}
+	if !result.OK {
+		return fmt.Errorf("slack %s: %s", method, result.Error)
+	}
+	if out == nil {
+		return nil
+	}
+	return parseJSON(raw, out)
+}
+
+// Slack item of the day's blocker question: the DM and the question's ts
+const slackBlockersItem = "blockers"
+
+// askBlockersOnSlack sends "Any blockers?" to the user in a DM, remembering
+// the message so the day's report reads the replies
+func askBlockersOnSlack(ctx context.Context, c *blockersConfig, date string) error {
+	open, err := loadOpenBlockers(date)
+	if err != nil {
+		return err
+	}
+	var dm struct {
+		Channel struct {
+			ID string `json:"id"`
+		} `json:"channel"`
+	}
+	if err := slackCall(ctx, c.token(), "conversations.open", nil, map[string]string{"users": c.SlackUser}, &dm); err != nil {
+		return err
+	}
+	var message struct {
+		TS string `json:"ts"`
+	}
+	err = slackCall(ctx, c.token(), "chat.postMessage", nil, map[string]string{"channel": dm.Channel.ID, "text": blockerQuestion(open)}, &message)
+	if err != nil {
+		return err
+	}
+	return recordDelivery(date, "slack:"+c.SlackUser, slackBlockersItem, dm.Channel.ID+" "+message.TS)
+}
+
+// collectSlackBlockers stores the blockers the user replied with to the
+// day's question, if it was asked. Replies are read again on every run:
+// blockers still open aren't added twice, and those resolved that day are
+// resolved again as a no-op.
+func collectSlackBlockers(ctx context.Context, c *blockersConfig, date string) error {
+	if offline {
+		return errOffline
+	}
+	ref, err := deliveredRef(date, "slack:"+c.SlackUser, slackBlockersItem)
+	if err != nil || ref == "" {
+		return err
+	}
+	channel, ts, _ := strings.Cut(ref, " ")
+
+	var history struct {
+		Messages []struct {
+			User  string `json:"user"`
+			BotID string `json:"bot_id"`
+			Text  string `json:"text"`
+		} `json:"messages"`
+	}
+	if err := slackCall(ctx, c.token(), "conversations.history", url.Values{"channel": {channel}, "oldest": {ts}}, nil, &history); err != nil {
+		return err
+	}
+
+	h, err := openHistory(historyPath())
+	if err != nil {
+		return err
+	}
+	defer h.Close()
+	// Newest first, answer in the order written
+	var errs []error
+	for i := len(history.Messages) - 1; i >= 0; i-- {
+		m := history.Messages[i]
+		if m.BotID == "" && m.User == c.SlackUser {
+			errs = append(errs, h.applyBlockerAnswer(date, m.Text))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// blockersCommand implements "blockers list [--date]", "blockers add TEXT",
+// "blockers resolve N" and "blockers ask", which asks in a Slack DM
+func blockersCommand(ctx context.Context, args []string) error {
+	usage := errors.New("usage: blockers list|add TEXT|resolve N|ask [--date YYYY-MM-DD]")
+	if len(args) == 0 {
+		return usage
+	}
+	fs := flag.NewFlagSet("blockers "+args[0], flag.ExitOnError)
+	date := fs.String("date", time.Now().Format(dateFormat), "day the blockers are listed, raised or resolved on")
+	fs.Parse(args[1:])
+	if _, err := time.Parse(dateFormat, *date); err != nil {
+		return fmt.Errorf("invalid date %q, expected YYYY-MM-DD", *date)
+	}
+
+	if args[0] == "ask" {
+		cfg, err := loadConfig(configPath())
+		if err != nil {
+			return err
+		}
+		if cfg.Blockers == nil {
+			return errors.New("blockers: slack_user is required")
+		}
+		return askBlockersOnSlack(ctx, cfg.Blockers, *date)
+	}
+
+	h, err := openHistory(historyPath())
+	if err != nil {
+		return err
+	}
+	defer h.Close()
+
+	switch {
+	case args[0] == "list":
+		open, err := h.openBlockers(*date)
+		if err != nil {
+			return err
+		}
+		for _, b := range open {
+			if b.resolved != *date {
+				fmt.Printf("#%d %s (since %s)\n", b.id, b.text, b.raised)
+			}
+		}
+		return nil
+	case args[0] == "add" && fs.NArg() > 0:
+		return h.addBlocker(*date, strings.Join(fs.Args(), " "))
+	case args[0] == "resolve" && fs.NArg() == 1:
+		id, err := strconv.Atoi(strings.TrimPrefix(fs.Arg(0), "#"))
+		if err != nil {
+			return fmt.Errorf("invalid blocker %q, expected its number", fs.Arg(0))
+		}
+		return h.resolveBlocker(id, *date)
+	}
+	return usage
+}
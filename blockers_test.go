@@ -0,0 +1,72 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func openTestHistory(t *testing.T) *historyStore {
+	t.Helper()
+	h, err := openHistory(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { h.Close() })
+	return h
+}
+
+// Slack replies are read again on every run of the day, so applying the
+// same answers again must change nothing
+func TestApplyBlockerAnswerTwice(t *testing.T) {
+	type state struct{ text, resolved string }
+	tests := []struct {
+		name    string
+		answers []string
+		want    []state
+	}{
+		{"raised", []string{"Waiting for the staging API keys"}, []state{{"Waiting for the staging API keys", ""}}},
+		{"raised then resolved", []string{"Waiting for the staging API keys", "resolved 1"}, []state{{"Waiting for the staging API keys", "2024-05-10"}}},
+		{"raised and resolved in one answer", []string{"Flaky CI\nresolved #1"}, []state{{"Flaky CI", "2024-05-10"}}},
+		{"nothing", []string{"no"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := openTestHistory(t)
+			for run := range 2 {
+				for _, answer := range tt.answers {
+					if err := h.applyBlockerAnswer("2024-05-10", answer); err != nil {
+						t.Fatalf("run %d: applyBlockerAnswer(%q) = %v", run+1, answer, err)
+					}
+				}
+			}
+			blockers, err := h.openBlockers("2024-05-10")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []state
+			for _, b := range blockers {
+				got = append(got, state{b.text, b.resolved})
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("blockers after answering twice = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveBlockerNotOpen(t *testing.T) {
+	h := openTestHistory(t)
+	if err := h.addBlocker("2024-05-09", "Flaky CI"); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.resolveBlocker(1, "2024-05-09"); err != nil {
+		t.Fatal(err)
+	}
+	// Resolved on an earlier day, or never raised
+	for _, id := range []int{1, 2} {
+		if err := h.resolveBlocker(id, "2024-05-10"); err == nil {
+			t.Errorf("resolveBlocker(%d) on a later day = nil, want an error", id)
+		}
+	}
+}
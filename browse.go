@@ -0,0 +1,197 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// browseModel pages through the stored reports, newest first
+type browseModel struct {
+	cfg     *config
+	records []historyRecord
+	// Index of the day shown in records
+	day     int
+	entries []reportEntry
+	cursor  int
+	// Only entries whose repository or status contains filter are listed
+	filter    string
+	filtering bool
+	// Outcome of the last action, shown under the entries
+	message string
+}
+
+func (m browseModel) Init() tea.Cmd {
+	return nil
+}
+
+// load reads the entries of the shown day
+func (m *browseModel) load() {
+	m.entries, m.cursor, m.message = nil, 0, ""
+	rep, err := regenerateReport(m.cfg, reportOptions{}, m.records[m.day].date)
+	if err != nil {
+		m.message = err.Error()
+		return
+	}
+	m.entries = filterEntries(rep.entries, nil)
+}
+
+// shown returns the entries of the day matching the filter
+func (m browseModel) shown() []reportEntry {
+	if m.filter == "" {
+		return m.entries
+	}
+	var shown []reportEntry
+	for _, entry := range m.entries {
+		if strings.Contains(strings.ToLower(entry.repo+" "+entry.status), strings.ToLower(m.filter)) {
+			shown = append(shown, entry)
+		}
+	}
+	return shown
+}
+
+func (m browseModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	// Typing a filter
+	if m.filtering {
+		switch key.Type {
+		case tea.KeyEnter:
+			m.filtering = false
+		case tea.KeyEsc:
+			m.filtering, m.filter = false, ""
+		case tea.KeyBackspace:
+			if m.filter != "" {
+				m.filter = m.filter[:len(m.filter)-1]
+			}
+		case tea.KeyRunes, tea.KeySpace:
+			m.filter += string(key.Runes)
+		}
+		m.cursor = 0
+		return m, nil
+	}
+
+	switch key.String() {
+	case "ctrl+c", "q", "esc":
+		return m, tea.Quit
+	case "left", "h", "pgdown":
+		if m.day < len(m.records)-1 {
+			m.day++
+			m.load()
+		}
+	case "right", "l", "pgup":
+		if m.day > 0 {
+			m.day--
+			m.load()
+		}
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.shown())-1 {
+			m.cursor++
+		}
+	case "/":
+		m.filtering = true
+	case "o":
+		shown := m.shown()
+		if len(shown) == 0 || shown[m.cursor].number == 0 {
+			m.message = "No pull request to open"
+			break
+		}
+		entry := shown[m.cursor]
+		url := fmt.Sprintf("https://github.com/%s/pull/%d", entry.repo, entry.number)
+		if err := openURL(url); err != nil {
+			m.message = err.Error()
+		} else {
+			m.message = "Opened " + url
+		}
+	case "c":
+		if err := copyToClipboard(m.records[m.day].report); err != nil {
+			m.message = err.Error()
+		} else {
+			m.message = "Copied the report of " + m.records[m.day].date
+		}
+	}
+	return m, nil
+}
+
+func (m browseModel) View() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Report for %s (%d/%d)\n", m.records[m.day].date, m.day+1, len(m.records))
+	if m.filter != "" || m.filtering {
+		fmt.Fprintf(&b, "Filter: %s\n", m.filter)
+	}
+	b.WriteString("\n")
+
+	shown := m.shown()
+	if len(shown) == 0 {
+		b.WriteString("  No entries\n")
+	}
+	for i, entry := range shown {
+		cursor := " "
+		if i == m.cursor {
+			cursor = ">"
+		}
+		fmt.Fprintf(&b, "%s %-20s %s", cursor, entry.status, entry.title)
+		if entry.repo != "" {
+			fmt.Fprintf(&b, "  (%s)", entry.repo)
+		}
+		b.WriteString("\n")
+	}
+
+	if m.message != "" {
+		fmt.Fprintf(&b, "\n%s\n", m.message)
+	}
+	b.WriteString("\n←/→ day  ↑/↓ move  / filter  o open PR  c copy report  q quit\n")
+	return b.String()
+}
+
+// browse implements the "browse" command
+func browse() error {
+	cfg, err := loadConfig(configPath())
+	if err != nil {
+		return err
+	}
+
+	h, err := openHistory(historyPath())
+	if err != nil {
+		return err
+	}
+	records, err := h.list()
+	h.Close()
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return errors.New("no reports stored yet")
+	}
+
+	m := browseModel{cfg: cfg, records: records}
+	m.load()
+	_, err = tea.NewProgram(m).Run()
+	return err
+}
+
+// copyToClipboard puts text on the system clipboard with the first of the
+// platform's clipboard tools that is installed
+func copyToClipboard(text string) error {
+	var tried []string
+	for _, args := range clipboardCommands {
+		if _, err := exec.LookPath(args[0]); err != nil {
+			tried = append(tried, args[0])
+			continue
+		}
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		return cmd.Run()
+	}
+	return fmt.Errorf("no clipboard tool found, install one of %s", strings.Join(tried, ", "))
+}
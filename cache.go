@@ -0,0 +1,89 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Directory GitHub API responses are cached in with their ETags, "" when
+// caching is off. See setupCache.
+var cacheDir string
+
+// A cached GitHub API response, and when it was last known to be current
+type cachedResponse struct {
+	ETag      string    `json:"etag"`
+	Body      []byte    `json:"body"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// setupCache caches responses in CACHE_DIR, by default in the user cache
+// directory, unless noCache
+func setupCache(noCache bool) {
+	if noCache {
+		return
+	}
+	cacheDir = os.Getenv("CACHE_DIR")
+	if cacheDir == "" {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return
+		}
+		cacheDir = filepath.Join(dir, "daily-reporting")
+	}
+}
+
+// cachePath is where the response to url is cached. The token is part of
+// the key so users sharing a cache don't see each other's responses.
+func cachePath(url, token string) string {
+	sum := sha256.Sum256([]byte(token + "\x00" + url))
+	return filepath.Join(cacheDir, hex.EncodeToString(sum[:])+".json")
+}
+
+// cachedGet returns the cached response to url, if any
+func cachedGet(url, token string) (cachedResponse, bool) {
+	var cached cachedResponse
+	if cacheDir == "" {
+		return cached, false
+	}
+	data, err := os.ReadFile(cachePath(url, token))
+	if err != nil || json.Unmarshal(data, &cached) != nil || cached.ETag == "" {
+		return cached, false
+	}
+	return cached, true
+}
+
+// cachePut caches the response to url. Failing to is only logged, the
+// response is still used.
+func cachePut(url, token, etag string, body []byte) {
+	if cacheDir == "" || etag == "" {
+		return
+	}
+	data, err := json.Marshal(cachedResponse{ETag: etag, Body: body, FetchedAt: time.Now()})
+	if err == nil {
+		err = os.MkdirAll(cacheDir, 0700)
+	}
+	if err == nil {
+		// Written aside and renamed, as concurrent requests may cache the same URL
+		var tmp *os.File
+		if tmp, err = os.CreateTemp(cacheDir, "*.tmp"); err == nil {
+			_, err = tmp.Write(data)
+			if closeErr := tmp.Close(); err == nil {
+				err = closeErr
+			}
+			if err == nil {
+				err = os.Rename(tmp.Name(), cachePath(url, token))
+			}
+			if err != nil {
+				os.Remove(tmp.Name())
+			}
+		}
+	}
+	if err != nil {
+		slog.Warn("Caching the response failed", "url", url, "err", err)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	googleTokenURL = "https://oauth2.googleapis.com/token"
+	calendarAPI    = "https://www.googleapis.com/calendar/v3"
+)
+
+// calendarConfig reads today's meetings from Google Calendar
+type calendarConfig struct {
+	// OAuth client; the secret and refresh token can also come from
+	// GOOGLE_CLIENT_SECRET and GOOGLE_REFRESH_TOKEN
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	// Calendars to read, "primary" by default
+	CalendarIDs []string `json:"calendar_ids,omitempty"`
+	// Events whose title contains any of these are left out, e.g. "Lunch"
+	Ignore []string `json:"ignore,omitempty"`
+}
+
+// googleAccessToken exchanges the refresh token for an access token
+func (c *calendarConfig) googleAccessToken(ctx context.Context) (string, error) {
+	secret, refresh := c.ClientSecret, c.RefreshToken
+	if secret == "" {
+		secret = os.Getenv("GOOGLE_CLIENT_SECRET")
+	}
+	if refresh == "" {
+		refresh = os.Getenv("GOOGLE_REFRESH_TOKEN")
+	}
+	if refresh == "" {
+		return "", errors.New("calendar: refresh_token or GOOGLE_REFRESH_TOKEN is required")
+	}
+
+	form := url.Values{
+		"client_id":     {c.ClientID},
+		"client_secret": {secret},
+		"refresh_token": {refresh},
+		"grant_type":    {"refresh_token"},
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", googleTokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("calendar: refreshing the access token: %s", resp.Status)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(boundedBody(resp.Body)).Decode(&token); err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
+
+// A calendar event as returned by the Google Calendar API
+type calendarEvent struct {
+	Summary string `json:"summary"`
+	Status  string `json:"status"`
+	Start   struct {
+		DateTime string `json:"dateTime"`
+	} `json:"start"`
+	Attendees []struct {
+		Self           bool   `json:"self"`
+		ResponseStatus string `json:"responseStatus"`
+	} `json:"attendees"`
+}
+
+// accepted reports whether I attend the event: I accepted the invitation,
+// or it is my own event without other attendees
+func (e calendarEvent) accepted() bool {
+	for _, a := range e.Attendees {
+		if a.Self {
+			return a.ResponseStatus == "accepted"
+		}
+	}
+	return len(e.Attendees) == 0
+}
+
+func (c *calendarConfig) ignored(title string) bool {
+	for _, pattern := range c.Ignore {
+		if strings.Contains(strings.ToLower(title), strings.ToLower(pattern)) {
+			return true
+		}
+	}
+	return false
+}
+
+// getMeetings returns "Done | Attended <event title>" entries for the
+// accepted, timed events of the day in the configured calendars
+func getMeetings(ctx context.Context, c *calendarConfig, day time.Time) ([]reportEntry, error) {
+	if offline {
+		return nil, errOffline
+	}
+	token, err := c.googleAccessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	ids := c.CalendarIDs
+	if len(ids) == 0 {
+		ids = []string{"primary"}
+	}
+
+	var entries []reportEntry
+	for _, id := range ids {
+		query := url.Values{
+			"timeMin":      {start.Format(time.RFC3339)},
+			"timeMax":      {start.AddDate(0, 0, 1).Format(time.RFC3339)},
+			"singleEvents": {"true"},
+			"orderBy":      {"startTime"},
+		}
+		var result struct {
+			Items []calendarEvent `json:"items"`
+		}
+		u := fmt.Sprintf("%s/calendars/%s/events?%s", calendarAPI, url.PathEscape(id), query.Encode())
+		if err := sendJSON(ctx, "GET", u, map[string]string{"Authorization": "Bearer " + token}, nil, &result); err != nil {
+			return nil, err
+		}
+
+		for _, e := range result.Items {
+			// All-day events such as holidays aren't meetings
+			if e.Status == "cancelled" || e.Start.DateTime == "" || !e.accepted() || c.ignored(e.Summary) {
+				continue
+			}
+			entries = append(entries, reportEntry{status: "Done", title: "Attended " + e.Summary})
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// A commit of a pull request as returned by the pull request commits API
+type prCommit struct {
+	Commit struct {
+		Message   string `json:"message"`
+		Committer struct {
+			Date string `json:"date"`
+		} `json:"committer"`
+	} `json:"commit"`
+}
+
+// addDayCommits stores the subject lines of the commits each pull request
+// of the events got during the day in the event's payload as "day_commits",
+// so they are listed under the entry, also when the day is regenerated later
+func addDayCommits(ctx context.Context, date string, events []map[string]interface{}, token string) error {
+	// Each pull request is fetched once, however many events it has
+	var keys []string
+	prs := make(map[string][]map[string]interface{})
+	for _, event := range events {
+		repo, _ := event["repo"].(map[string]interface{})
+		name, _ := repo["name"].(string)
+		payload, _ := event["payload"].(map[string]interface{})
+		pr, ok := payload["pull_request"].(map[string]interface{})
+		if !ok || pr["day_commits"] != nil {
+			continue
+		}
+
+		number, _ := pr["number"].(float64)
+		key := fmt.Sprintf("%s/pulls/%d", name, int(number))
+		if _, seen := prs[key]; !seen {
+			keys = append(keys, key)
+		}
+		prs[key] = append(prs[key], pr)
+	}
+
+	return runPool(ctx, fetchConcurrency, len(keys), func(ctx context.Context, i int) error {
+		var commits []prCommit
+		if err := githubGet(ctx, fmt.Sprintf("%s/repos/%s/commits?per_page=100", githubAPI, keys[i]), token, &commits); err != nil {
+			return err
+		}
+
+		messages := []interface{}{}
+		for _, c := range commits {
+			subject, _, _ := strings.Cut(c.Commit.Message, "\n")
+			if onDate(c.Commit.Committer.Date, date) && !strings.HasPrefix(subject, "Merge ") {
+				messages = append(messages, subject)
+			}
+		}
+		for _, pr := range prs[keys[i]] {
+			pr["day_commits"] = messages
+		}
+		return nil
+	})
+}
+
+// eventCommits returns the commits of the day stored in the pull request
+// of the event, if any
+func eventCommits(payload map[string]interface{}) []string {
+	pr, _ := payload["pull_request"].(map[string]interface{})
+	commits, _ := pr["day_commits"].([]interface{})
+
+	var messages []string
+	for _, c := range commits {
+		if message, ok := c.(string); ok {
+			messages = append(messages, message)
+		}
+	}
+	return messages
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A file changed by a pull request, as returned by the pulls files API
+type prFile struct {
+	Filename string `json:"filename"`
+	// Unified diff of the file, missing for binary and large files
+	Patch string `json:"patch"`
+}
+
+// addChangedFiles stores the files changed by each pull request of the
+// repositories wanted in the event's payload as "files", so entries can be
+// tagged with their components, also when the day is regenerated later
+func addChangedFiles(ctx context.Context, want func(repo string) bool, events []map[string]interface{}, token string) error {
+	// Each pull request is fetched once, however many events it has
+	var keys []string
+	prs := make(map[string][]map[string]interface{})
+	for _, event := range events {
+		repo, _ := event["repo"].(map[string]interface{})
+		name, _ := repo["name"].(string)
+		payload, _ := event["payload"].(map[string]interface{})
+		pr, ok := payload["pull_request"].(map[string]interface{})
+		if !want(name) || !ok || pr["files"] != nil {
+			continue
+		}
+
+		number, _ := pr["number"].(float64)
+		key := fmt.Sprintf("%s/pulls/%d", name, int(number))
+		if _, seen := prs[key]; !seen {
+			keys = append(keys, key)
+		}
+		prs[key] = append(prs[key], pr)
+	}
+
+	return runPool(ctx, fetchConcurrency, len(keys), func(ctx context.Context, i int) error {
+		var changed []prFile
+		if err := githubGet(ctx, fmt.Sprintf("%s/repos/%s/files?per_page=100", githubAPI, keys[i]), token, &changed); err != nil {
+			return err
+		}
+
+		files := []interface{}{}
+		for _, f := range changed {
+			files = append(files, f.Filename)
+		}
+		for _, pr := range prs[keys[i]] {
+			pr["files"] = files
+		}
+		return nil
+	})
+}
+
+// eventFiles returns the files changed by the pull request or local commit
+// of the event, when known
+func eventFiles(payload map[string]interface{}) []string {
+	files, _ := payload["files"].([]interface{})
+	if pr, ok := payload["pull_request"].(map[string]interface{}); ok {
+		files, _ = pr["files"].([]interface{})
+	}
+
+	var names []string
+	for _, f := range files {
+		if name, ok := f.(string); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// componentsOf returns the components owning the files, sorted. Each file
+// belongs to the component of the longest matching path prefix.
+func componentsOf(prefixes map[string]string, files []string) []string {
+	seen := make(map[string]bool)
+	var components []string
+	for _, file := range files {
+		best := ""
+		for prefix := range prefixes {
+			if strings.HasPrefix(file, prefix) && len(prefix) > len(best) {
+				best = prefix
+			}
+		}
+		if c, ok := prefixes[best]; ok && best != "" && !seen[c] {
+			seen[c] = true
+			components = append(components, c)
+		}
+	}
+	sort.Strings(components)
+	return components
+}
+
+// tagComponents prefixes the titles of monorepo entries with the components
+// they touched, e.g. "[payments] Fix rounding bug"
+func tagComponents(components map[string]map[string]string, entries []reportEntry) {
+	for i, entry := range entries {
+		prefixes, ok := components[entry.repo]
+		if !ok {
+			continue
+		}
+		if tags := componentsOf(prefixes, entry.files); len(tags) > 0 {
+			entries[i].title = fmt.Sprintf("[%s] %s", strings.Join(tags, ", "), entry.title)
+		}
+	}
+}
@@ -0,0 +1,585 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Default location of the config file, overridable with CONFIG_FILE
+const defaultConfigFile = "config.json"
+
+// Report modes a schedule can run in
+const (
+	modeFinal  = "final"
+	modeDraft  = "draft"
+	modeWeekly = "weekly"
+	modeDigest = "digest"
+	// Add what the last report missed, delivering an addendum
+	modeReconcile = "reconcile"
+)
+
+// config is the optional JSON config file, see config.example.json
+type config struct {
+	// GitHub account the report is about
+	GitHub githubConfig `json:"github,omitempty"`
+	// File the report is written to when run from the command line, and
+	// the targets it is also delivered to
+	ReportFile string   `json:"report_file,omitempty"`
+	Deliver    []string `json:"deliver,omitempty"`
+
+	// Go time layout of the report date header, e.g. "2006年1月2日(Mon)"
+	DateFormat string `json:"date_format,omitempty"`
+	// Language of the statuses, headings and dates of the report, e.g.
+	// "ja"; locale is its older name
+	Language string `json:"language,omitempty"`
+	Locale   string `json:"locale,omitempty"`
+	// Add a "Feedback received" section for reviews and comments on my PRs
+	Feedback bool `json:"feedback,omitempty"`
+	// Add a "Newly assigned" section for issues and PRs assigned to me that day
+	NewlyAssigned bool `json:"newly_assigned,omitempty"`
+	// Read the day's meetings from Google Calendar instead of the default lines
+	Calendar *calendarConfig `json:"calendar,omitempty"`
+	// Read the day's time entries from Toggl or Clockify, adding them to the
+	// hours of the entries they are about
+	TimeTracking *timeTrackingConfig `json:"time_tracking,omitempty"`
+	// Plan my Notion tasks due or in progress under Next, and check off
+	// those of the pull requests merged
+	NotionTasks *notionTasksConfig `json:"notion_tasks,omitempty"`
+	// Add the day's coding time and top languages and projects from
+	// WakaTime under "Coding activity"
+	WakaTime *wakaTimeConfig `json:"wakatime,omitempty"`
+	// Ask for blockers in a Slack DM with "blockers ask", reading the
+	// replies into the Blockers section
+	Blockers *blockersConfig `json:"blockers,omitempty"`
+	// Path prefixes of monorepos mapped to their component, by repository,
+	// e.g. {"octocat/monorepo": {"services/payments/": "payments"}}
+	Components map[string]map[string]string `json:"components,omitempty"`
+	// Add a footer with the languages of the files changed today
+	Languages bool `json:"languages,omitempty"`
+	// Columns of csv and tsv reports, all of them by default, and the
+	// layout and theme of pdf and html reports
+	Columns []string  `json:"columns,omitempty"`
+	PDF     pdfConfig `json:"pdf,omitempty"`
+	// Add the reviews I asked for and gave this week to the weekly report
+	ReviewBalance bool `json:"review_balance,omitempty"`
+	// End the weekly report of Fridays with a retrospective scaffold,
+	// rendered with the text/template at retro_template if set
+	Retro         bool   `json:"retro,omitempty"`
+	RetroTemplate string `json:"retro_template,omitempty"`
+	// List the commits of the day of each pull request under its entry
+	PRCommits bool `json:"pr_commits,omitempty"`
+	// Add TODO and FIXME comments added by my merged PRs to Next
+	TodoNext bool `json:"todo_next,omitempty"`
+	// Warn about my commits of the day in local clones that aren't pushed
+	CheckUnpushed bool `json:"check_unpushed,omitempty"`
+	// Privacy of the entries matching each rule, the first that matches,
+	// and of the others, "team" by default
+	PrivacyRules   []privacyRule `json:"privacy_rules,omitempty"`
+	DefaultPrivacy string        `json:"default_privacy,omitempty"`
+	// LLM writing the summary of --summary and of schedules with summary
+	Summary *summaryConfig `json:"summary,omitempty"`
+	// text/template file laying out the daily report instead of the
+	// default layout, parsed when the config is validated
+	ReportTemplate string `json:"report_template,omitempty"`
+	reportTemplate *template.Template
+	// List report entries under their organization
+	GroupByOrg bool `json:"group_by_org,omitempty"`
+	// List report entries under their status instead, e.g. Done, In Review,
+	// with the groups in status_order if set
+	GroupByStatus bool     `json:"group_by_status,omitempty"`
+	StatusOrder   []string `json:"status_order,omitempty"`
+	// Categories of pull requests by label, e.g. "bug" in "🐛 Fix",
+	// prefixing their titles or, with group_by_category, as headings
+	LabelCategories []labelRule `json:"label_categories,omitempty"`
+	GroupByCategory bool        `json:"group_by_category,omitempty"`
+	// Bullet and status emoji of the entries by output: "text", "csv",
+	// "tsv", "slack", "google_chat", "github" or "notion"
+	Styles map[string]entryStyle `json:"styles,omitempty"`
+	// Fill Next from review requests, my PRs needing changes or fixes and
+	// my assigned issues, oldest first, at most next_limit items (5 by
+	// default), after the next_items always planned. Items waiting for
+	// next_age_days (7 by default) or more say since when.
+	AutoNext    bool     `json:"auto_next,omitempty"`
+	NextLimit   int      `json:"next_limit,omitempty"`
+	NextItems   []string `json:"next_items,omitempty"`
+	NextAgeDays int      `json:"next_age_days,omitempty"`
+	// List the reviews requested from me and by me today, and the pull
+	// requests still waiting for my review under Pending
+	ReviewRequests bool `json:"review_requests,omitempty"`
+	// Write the hours spent on each entry after its title, estimated from
+	// its first to last event of the day unless set with "hours"
+	Hours bool `json:"hours,omitempty"`
+	// Summarize how I triaged my GitHub notifications today
+	Notifications bool `json:"notifications,omitempty"`
+	// Bots, accounts and titles of automated activity left out of the
+	// reports
+	Ignore ignoreConfig `json:"ignore,omitempty"`
+	// Machine users, such as CI accounts, whose events are never counted
+	// as mine, e.g. approvals by CI with my token
+	MachineUsers []machineUser `json:"machine_users,omitempty"`
+	// Days I'm away, e.g. "2024-08-12..2024-08-16" or "2024-12-25", counted
+	// as absence by catchup even when a report was due
+	Away []string `json:"away,omitempty"`
+
+	// Only report on these repositories, e.g. ["acme/web", "acme-labs/*"];
+	// events elsewhere are left out
+	Repos []string `json:"repos,omitempty"`
+	// Named profiles replacing some of the settings above, e.g. one per
+	// client, see --profile
+	Profiles map[string]profile `json:"profiles,omitempty"`
+
+	// Client and author of the Japanese monthly report, see monthly-report
+	MonthlyReport monthlyReportConfig `json:"monthly_report,omitempty"`
+
+	// GitHub logins of the teammates in the digest, and how their events
+	// are fetched
+	Team  []string    `json:"team,omitempty"`
+	Batch batchConfig `json:"batch,omitempty"`
+
+	// What to do when a report is already stored for the day: "overwrite"
+	// (default), "append-revision" or "abort"
+	OnDuplicate string `json:"on_duplicate,omitempty"`
+
+	// Commands run before and after each delivery
+	Hooks hookConfig `json:"hooks,omitempty"`
+
+	Targets   map[string]target `json:"targets"`
+	Schedules []schedule        `json:"schedules"`
+}
+
+// target is a destination a report is delivered to
+type target struct {
+	// "file", "stdout", "notion", "jira", "google_chat", "webhook", "slack",
+	// "email", "git" or "github"
+	Type string `json:"type"`
+	Path string `json:"path,omitempty"`
+
+	// API token of the integration, when not taken from the environment
+	Token string `json:"token,omitempty"`
+
+	// Notion database the report pages are created in, and the names of
+	// its title and date properties ("Name" and "Date" by default)
+	DatabaseID    string `json:"database_id,omitempty"`
+	TitleProperty string `json:"title_property,omitempty"`
+	DateProperty  string `json:"date_property,omitempty"`
+
+	// Jira Cloud site, or the Google Chat or Slack incoming webhook URL,
+	// or the Zapier or Make webhook URL
+	URL string `json:"url,omitempty"`
+	// Account email the Jira token belongs to
+	Email string `json:"email,omitempty"`
+	// Regular expression matching issue keys in titles and branch names
+	KeyPattern string `json:"key_pattern,omitempty"`
+	// "comment" (default) or "worklog", and the time logged per worklog
+	PostAs      string `json:"post_as,omitempty"`
+	WorklogTime string `json:"worklog_time,omitempty"`
+
+	// SMTP server of email targets, e.g. "smtp.example.com:587", and the
+	// sender and recipients
+	SMTP string   `json:"smtp,omitempty"`
+	From string   `json:"from,omitempty"`
+	To   []string `json:"to,omitempty"`
+
+	// Local clone git targets commit the report file to, at path inside
+	// the clone, and the commit message expanded like paths, e.g.
+	// "Daily report of {{.Username}} for {{.Date}}". For github targets,
+	// the repository the reports are posted to, e.g. "octocat/reports".
+	Repo    string `json:"repo,omitempty"`
+	Message string `json:"message,omitempty"`
+
+	// Tracking issue github targets comment on, or else the title of the
+	// monthly issue, or discussion of the category, they comment on, e.g.
+	// "Daily reports of {{.Username}}, {{.Year}}-{{.Month}}"
+	Issue    int    `json:"issue,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Category string `json:"category,omitempty"`
+
+	// Append each day's report to the file of a file target, after a
+	// "--- 2024-05-10 ---" separator, instead of overwriting it, e.g. to
+	// keep a monthly log in "reports/{{.Year}}-{{.Month}}.txt"
+	Append bool `json:"append,omitempty"`
+
+	// Format of file, stdout, slack and email targets: "text" (default),
+	// "csv", "tsv", "pdf" or "html" (files and emails only), the columns of
+	// csv and tsv, all of them by default, and the layout and theme of pdf
+	// and html. Emails have the text report as their body and the others
+	// attached.
+	Format  string    `json:"format,omitempty"`
+	Columns []string  `json:"columns,omitempty"`
+	PDF     pdfConfig `json:"pdf,omitempty"`
+
+	// Only deliver the parts of the report about these organizations
+	Orgs []string `json:"orgs,omitempty"`
+	// Most private entries delivered: "public", "team" or "private", by
+	// default private for files and stdout and team otherwise
+	Privacy string `json:"privacy,omitempty"`
+
+	// Style of the target's output, see styles
+	style *entryStyle
+}
+
+// schedule runs a report in the given mode and delivers it to targets
+type schedule struct {
+	Name string `json:"name"`
+	// minute hour day-of-month month day-of-week, e.g. "0 18 * * 1-5"
+	Cron string `json:"cron"`
+	// "final" (default), "draft", "weekly", "digest" or "reconcile"
+	Mode    string   `json:"mode,omitempty"`
+	Targets []string `json:"targets"`
+	// Deliver immediately even outside of the delivery window
+	IgnoreQuietHours bool `json:"ignore_quiet_hours,omitempty"`
+	// Add a summary of the day, see summary
+	Summary bool `json:"summary,omitempty"`
+
+	spec cronSpec
+}
+
+func configPath() string {
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		return path
+	}
+	return defaultConfigFile
+}
+
+// loadConfig reads and validates the config file. A missing file gives the
+// env-based defaults: one final report at REPORT_TIME written to REPORT_FILE.
+// Settings left out are taken from the deprecated variables they replace.
+func loadConfig(path string) (*config, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && activeProfile == "" {
+		return defaultConfig()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &config{}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if activeProfile != "" {
+		if err := cfg.applyProfile(activeProfile); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	cfg.applyLegacyEnv()
+	cfg.applyFlags()
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+func defaultConfig() (*config, error) {
+	cfg := &config{}
+	cfg.applyLegacyEnv()
+	cfg.applyFlags()
+	if err := cfg.addDefaultSchedule(); err != nil {
+		return nil, err
+	}
+	return cfg, cfg.validate()
+}
+
+// addDefaultSchedule adds the target writing the report file and its daily
+// schedule at REPORT_TIME, used when there is no config file
+func (cfg *config) addDefaultSchedule() error {
+	if cfg.ReportFile == "" {
+		return fmt.Errorf("no report file: %s does not exist, and neither REPORT_FILE nor --report-file is set", configPath())
+	}
+
+	reportAt := clockFromEnv("REPORT_TIME", defaultReportTime)
+	cfg.Targets = map[string]target{
+		"report-file": {Type: "file", Path: cfg.ReportFile},
+	}
+	cfg.Schedules = []schedule{{
+		Name:    "daily",
+		Cron:    fmt.Sprintf("%d %d * * *", reportAt.minute, reportAt.hour),
+		Targets: []string{"report-file"},
+	}}
+	return nil
+}
+
+func (cfg *config) validate() error {
+	for _, group := range cfg.StatusOrder {
+		if !slices.Contains(defaultStatusOrder, group) {
+			return fmt.Errorf("status_order: unknown status group %q, expected one of %s", group, strings.Join(defaultStatusOrder, ", "))
+		}
+	}
+	if err := validFormat("", cfg.Columns); err != nil {
+		return fmt.Errorf("columns: %w", err)
+	}
+	if _, err := cfg.PDF.theme(); err != nil {
+		return fmt.Errorf("pdf: %w", err)
+	}
+	if err := validRepos(cfg.Repos); err != nil {
+		return fmt.Errorf("repos: %w", err)
+	}
+	for name, p := range cfg.Profiles {
+		if err := validRepos(p.Repos); err != nil {
+			return fmt.Errorf("profile %q: repos: %w", name, err)
+		}
+	}
+	if cfg.DefaultPrivacy != "" {
+		if err := validPrivacy(cfg.DefaultPrivacy); err != nil {
+			return fmt.Errorf("default_privacy: %w", err)
+		}
+	}
+	for i, rule := range cfg.PrivacyRules {
+		if err := validPrivacy(rule.Level); err != nil {
+			return fmt.Errorf("privacy_rules[%d]: %w", i, err)
+		}
+		if _, err := path.Match(rule.Repo, ""); err != nil {
+			return fmt.Errorf("privacy_rules[%d]: repo: %w", i, err)
+		}
+	}
+	if _, err := reportPath(cfg.ReportFile, &generatedReport{}); err != nil {
+		return fmt.Errorf("report_file: %w", err)
+	}
+	if err := cfg.Ignore.compile(); err != nil {
+		return fmt.Errorf("ignore: %w", err)
+	}
+	if err := validStyles(cfg.Styles); err != nil {
+		return fmt.Errorf("styles: %w", err)
+	}
+	for i, rule := range cfg.LabelCategories {
+		if rule.Label == "" || rule.Category == "" {
+			return fmt.Errorf("label_categories[%d]: set label and category", i)
+		}
+	}
+	for i, m := range cfg.MachineUsers {
+		if m.Login == "" && m.Body == "" {
+			return fmt.Errorf("machine_users[%d]: set login or body", i)
+		}
+	}
+	for _, days := range cfg.Away {
+		if _, _, err := parseDays(days); err != nil {
+			return fmt.Errorf("away: %w", err)
+		}
+	}
+	for _, name := range cfg.Deliver {
+		if _, ok := cfg.Targets[name]; !ok {
+			return fmt.Errorf("deliver: unknown target %q", name)
+		}
+	}
+	for name, t := range cfg.Targets {
+		if err := validFormat(t.Format, t.Columns); err != nil {
+			return fmt.Errorf("target %q: %w", name, err)
+		}
+		if t.Privacy != "" {
+			if err := validPrivacy(t.Privacy); err != nil {
+				return fmt.Errorf("target %q: %w", name, err)
+			}
+		}
+		if (t.Format == formatPDF || t.Format == formatHTML) && t.Type != "file" && t.Type != "git" && t.Type != "email" {
+			return fmt.Errorf("target %q: only file, git and email targets can be pdf or html", name)
+		}
+		if _, err := t.PDF.theme(); err != nil {
+			return fmt.Errorf("target %q: pdf: %w", name, err)
+		}
+		switch t.Type {
+		case "file", "git":
+			if t.Path == "" {
+				return fmt.Errorf("target %q: path is required", name)
+			}
+			if t.Type == "git" && t.Repo == "" {
+				return fmt.Errorf("target %q: repo is required", name)
+			}
+			if _, err := t.commitMessage(&generatedReport{}); err != nil {
+				return fmt.Errorf("target %q: message: %w", name, err)
+			}
+			if _, err := reportPath(t.Path, &generatedReport{}); err != nil {
+				return fmt.Errorf("target %q: path: %w", name, err)
+			}
+			if t.Append && t.Format != "" && t.Format != formatText {
+				return fmt.Errorf("target %q: only text reports can be appended", name)
+			}
+		case "stdout":
+		case "notion":
+			if t.DatabaseID == "" {
+				return fmt.Errorf("target %q: database_id is required", name)
+			}
+			if t.Token == "" && os.Getenv("NOTION_TOKEN") == "" {
+				return fmt.Errorf("target %q: token or NOTION_TOKEN is required", name)
+			}
+		case "jira":
+			if t.URL == "" || t.Email == "" {
+				return fmt.Errorf("target %q: url and email are required", name)
+			}
+			if t.Token == "" && os.Getenv("JIRA_TOKEN") == "" {
+				return fmt.Errorf("target %q: token or JIRA_TOKEN is required", name)
+			}
+			if _, err := regexp.Compile(t.jiraKeyPattern()); err != nil {
+				return fmt.Errorf("target %q: key_pattern: %w", name, err)
+			}
+			if t.PostAs != "" && t.PostAs != "comment" && t.PostAs != "worklog" {
+				return fmt.Errorf("target %q: post_as must be comment or worklog", name)
+			}
+		case "google_chat", "webhook", "slack":
+			if t.URL == "" {
+				return fmt.Errorf("target %q: url is required", name)
+			}
+		case "github":
+			if owner, repo, _ := strings.Cut(t.Repo, "/"); owner == "" || repo == "" {
+				return fmt.Errorf("target %q: repo must be owner/name", name)
+			}
+			if t.Issue != 0 && t.Category != "" {
+				return fmt.Errorf("target %q: issue and category can't both be set", name)
+			}
+			if _, err := t.threadTitle(&generatedReport{date: "2000-01-01"}); err != nil {
+				return fmt.Errorf("target %q: title: %w", name, err)
+			}
+		case "email":
+			if t.SMTP == "" || t.From == "" || len(t.To) == 0 {
+				return fmt.Errorf("target %q: smtp, from and to are required", name)
+			}
+		default:
+			return fmt.Errorf("target %q: unknown type %q", name, t.Type)
+		}
+	}
+
+	if cfg.Calendar != nil && cfg.Calendar.ClientID == "" {
+		return errors.New("calendar: client_id is required")
+	}
+	if cfg.TimeTracking != nil {
+		if err := cfg.TimeTracking.validate(); err != nil {
+			return err
+		}
+	}
+	if cfg.NotionTasks != nil {
+		if err := cfg.NotionTasks.validate(); err != nil {
+			return err
+		}
+	}
+	if cfg.Blockers != nil && cfg.Blockers.SlackUser == "" {
+		return errors.New("blockers: slack_user is required")
+	}
+	if cfg.WakaTime != nil && cfg.WakaTime.Top < 0 {
+		return errors.New("wakatime: top must not be negative")
+	}
+
+	switch cfg.OnDuplicate {
+	case "", policyOverwrite, policyAppendRevision, policyAbort:
+	default:
+		return fmt.Errorf("on_duplicate must be %s, %s or %s", policyOverwrite, policyAppendRevision, policyAbort)
+	}
+
+	if cfg.NextLimit < 0 {
+		return errors.New("next_limit must not be negative")
+	}
+	if cfg.NextAgeDays < 0 {
+		return errors.New("next_age_days must not be negative")
+	}
+
+	if cfg.Batch.Interval != "" {
+		if _, err := time.ParseDuration(cfg.Batch.Interval); err != nil {
+			return fmt.Errorf("batch: interval: %w", err)
+		}
+	}
+
+	if cfg.Summary != nil {
+		if _, ok := summaryProviders[cfg.Summary.Provider]; !ok {
+			return fmt.Errorf("summary: unknown provider %q, expected openai or anthropic", cfg.Summary.Provider)
+		}
+	}
+
+	if cfg.ReportTemplate != "" {
+		tmpl, err := loadReportTemplate(cfg.ReportTemplate)
+		if err != nil {
+			return fmt.Errorf("report_template: %w", err)
+		}
+		cfg.reportTemplate = tmpl
+	}
+
+	if lang := cfg.language(); lang != "" && lang != "en" {
+		if _, ok := locales()[lang]; !ok {
+			return fmt.Errorf("unknown language %q", lang)
+		}
+	}
+
+	for i := range cfg.Schedules {
+		s := &cfg.Schedules[i]
+		if s.Name == "" {
+			s.Name = fmt.Sprintf("schedule-%d", i+1)
+		}
+
+		spec, err := parseCron(s.Cron)
+		if err != nil {
+			return fmt.Errorf("schedule %q: %w", s.Name, err)
+		}
+		s.spec = spec
+
+		switch s.Mode {
+		case "":
+			s.Mode = modeFinal
+		case modeFinal, modeDraft, modeWeekly, modeReconcile:
+		case modeDigest:
+			if len(cfg.Team) == 0 {
+				return fmt.Errorf("schedule %q: digest mode needs a team", s.Name)
+			}
+		default:
+			return fmt.Errorf("schedule %q: unknown mode %q", s.Name, s.Mode)
+		}
+
+		if len(s.Targets) == 0 {
+			return fmt.Errorf("schedule %q: no targets", s.Name)
+		}
+		for _, name := range s.Targets {
+			if _, ok := cfg.Targets[name]; !ok {
+				return fmt.Errorf("schedule %q: unknown target %q", s.Name, name)
+			}
+		}
+	}
+	return nil
+}
+
+// entryGrouping returns how the entries of daily reports are grouped
+func (cfg *config) entryGrouping() entryGrouping {
+	grouping := entryGrouping{byOrg: cfg.GroupByOrg}
+	if cfg.GroupByStatus {
+		grouping.byStatus = defaultStatusOrder
+		if len(cfg.StatusOrder) > 0 {
+			grouping.byStatus = cfg.StatusOrder
+		}
+	}
+	if cfg.GroupByCategory {
+		grouping.byCategory = categoryOrder(cfg.LabelCategories)
+	}
+	return grouping
+}
+
+// finalTargets returns the targets of the schedules in final mode, once each
+func (cfg *config) finalTargets() []target {
+	var targets []target
+	seen := make(map[string]bool)
+	for _, s := range cfg.Schedules {
+		if s.Mode != modeFinal {
+			continue
+		}
+		for _, name := range s.Targets {
+			if !seen[name] {
+				seen[name] = true
+				targets = append(targets, cfg.withStyle(cfg.Targets[name]))
+			}
+		}
+	}
+	return targets
+}
+
+// resolveTargets looks up targets by name, skipping unknown names
+func (cfg *config) resolveTargets(names []string) []target {
+	var targets []target
+	for _, name := range names {
+		if t, ok := cfg.Targets[name]; ok {
+			targets = append(targets, cfg.withStyle(t))
+		}
+	}
+	return targets
+}
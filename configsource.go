@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// setting is a setting whose default changes how reports are generated or
+// delivered, listed by "config defaults" and "config show --resolved"
+type setting struct {
+	Name string `json:"name"`
+	// Where it can be set: the key of config.json, e.g. "batch.interval",
+	// the environment variable and the flag
+	File    string `json:"file,omitempty"`
+	Env     string `json:"env,omitempty"`
+	Flag    string `json:"flag,omitempty"`
+	Default string `json:"default"`
+	Doc     string `json:"description"`
+	// Only shown as set or not
+	secret bool
+}
+
+// settings lists the settings, in order of config.json then environment
+func settings() []setting {
+	cacheDir := "daily-reporting in the user cache directory"
+	if dir, err := os.UserCacheDir(); err == nil {
+		cacheDir = filepath.Join(dir, "daily-reporting")
+	}
+	return []setting{
+		{Name: "github.username", File: "github.username", Env: "GITHUB_USERNAME", Flag: "user", Doc: "login whose events are reported"},
+		{Name: "github.token", File: "github.token", Env: "GITHUB_TOKEN", Doc: "API token, the OS keychain's when not set", secret: true},
+		{Name: "report_file", File: "report_file", Env: "REPORT_FILE", Flag: "report-file", Doc: "file the report is written to"},
+		{Name: "language", File: "language", Default: "en", Doc: "language of the statuses, headings and dates"},
+		{Name: "date_format", File: "date_format", Default: defaultHeaderFormat, Doc: "layout of the date header, the language's by default"},
+		{Name: "default_privacy", File: "default_privacy", Default: privacyTeam, Doc: "privacy of entries matching no privacy rule"},
+		{Name: "next_limit", File: "next_limit", Default: fmt.Sprint(defaultNextLimit), Doc: "most items auto_next adds to Next"},
+		{Name: "next_age_days", File: "next_age_days", Default: fmt.Sprint(defaultNextAgeDays), Doc: "days waiting before a Next item says since when"},
+		{Name: "repos", File: "repos", Doc: "repositories reported on, all by default"},
+		{Name: "on_duplicate", File: "on_duplicate", Default: policyOverwrite, Doc: "what to do when the day's report is already stored"},
+		{Name: "batch.concurrency", File: "batch.concurrency", Default: fmt.Sprint(defaultBatchConcurrency), Doc: "members of batch runs fetched at a time"},
+		{Name: "batch.interval", File: "batch.interval", Default: defaultBatchInterval.String(), Doc: "minimum time between starting to fetch two members in batch runs"},
+		{Name: "profile", Env: "REPORT_PROFILE", Flag: "profile", Doc: "profile of the config used, with its own history"},
+		{Name: "config_file", Env: "CONFIG_FILE", Default: defaultConfigFile, Doc: "config file"},
+		{Name: "history_db", Env: "HISTORY_DB", Default: defaultHistoryDB, Doc: "SQLite database of the stored reports"},
+		{Name: "cache_dir", Env: "CACHE_DIR", Default: cacheDir, Doc: "cache of GitHub API responses"},
+		{Name: "assets_dir", Env: "ASSETS_DIR", Doc: "directory whose files shadow the built-in assets"},
+		{Name: "local_repos", Env: "LOCAL_REPOS", Flag: "local", Doc: "directory of local clones scanned for commits"},
+		{Name: "report_server", Env: "REPORT_SERVER", Flag: "server", Doc: "report server commands run against"},
+		{Name: "format", Flag: "format", Default: formatText, Doc: "format of the report file"},
+		{Name: "report_time", Env: "REPORT_TIME", Default: defaultReportTime, Doc: "time of the daily report without a config file"},
+		{Name: "delivery_window_start", Env: "DELIVERY_WINDOW_START", Default: defaultWindowStart, Doc: "earliest time serve delivers"},
+		{Name: "delivery_window_end", Env: "DELIVERY_WINDOW_END", Default: defaultWindowEnd, Doc: "latest time serve delivers"},
+		{Name: "http_timeout", Env: "HTTP_TIMEOUT", Default: defaultHTTPTimeout.String(), Doc: "limit of a single API request"},
+		{Name: "run_timeout", Env: "RUN_TIMEOUT", Default: defaultRunTimeout.String(), Doc: "limit of generating or delivering a report"},
+		{Name: "webhook_addr", Env: "WEBHOOK_ADDR", Default: defaultWebhookAddr, Doc: "address webhooks are received on, with WEBHOOK_SECRET"},
+		{Name: "server_addr", Env: "SERVER_ADDR", Doc: "address of the report server of serve"},
+		{Name: "metrics_addr", Env: "METRICS_ADDR", Doc: "address of the metrics of serve"},
+		{Name: "log_level", Env: "LOG_LEVEL", Default: "info", Doc: "lowest level logged, debug with --verbose"},
+		{Name: "log_format", Env: "LOG_FORMAT", Default: "text", Doc: "text or json log lines"},
+	}
+}
+
+// Settings given on the command line, overriding the config file and the
+// environment
+var flagSettings struct {
+	username   string
+	reportFile string
+}
+
+// The username is the one setting a report can't do without
+var errNoUsername = errors.New("no GitHub username: set github.username in the config, GITHUB_USERNAME in the environment or .env, or --user")
+
+// applyFlags replaces the settings of the config with those given on the
+// command line, the environment and the file having been applied
+func (cfg *config) applyFlags() {
+	if flagSettings.username != "" {
+		cfg.GitHub.Username = flagSettings.username
+	}
+	if flagSettings.reportFile != "" {
+		cfg.ReportFile = flagSettings.reportFile
+	}
+}
+
+// Sources of a resolved setting, the first one set wins
+const (
+	sourceFlag    = "flag"
+	sourceFile    = "file"
+	sourceEnv     = "env"
+	sourceDefault = "default"
+)
+
+// resolvedSetting is the value a setting has in this run, and where it comes
+// from
+type resolvedSetting struct {
+	setting
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// fileValue returns the value of a dotted key of the config file, e.g.
+// "batch.interval"
+func fileValue(file map[string]interface{}, key string) (string, bool) {
+	var value interface{} = file
+	for _, name := range strings.Split(key, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		if value, ok = object[name]; !ok {
+			return "", false
+		}
+	}
+	return fmt.Sprint(value), true
+}
+
+// resolve returns the value of each setting: from the global flags set on
+// the command line, then the config file, then the environment, then the
+// default
+func resolve(file map[string]interface{}) []resolvedSetting {
+	flags := make(map[string]string)
+	flag.Visit(func(f *flag.Flag) { flags[f.Name] = f.Value.String() })
+
+	var resolved []resolvedSetting
+	for _, s := range settings() {
+		r := resolvedSetting{setting: s, Value: s.Default, Source: sourceDefault}
+		if value, ok := flags[s.Flag]; ok && s.Flag != "" {
+			r.Value, r.Source = value, sourceFlag
+		} else if value, ok := fileValue(file, s.File); ok && s.File != "" {
+			r.Value, r.Source = value, sourceFile
+		} else if value := os.Getenv(s.Env); value != "" && s.Env != "" {
+			r.Value, r.Source = value, sourceEnv
+		}
+		if s.secret && r.Source != sourceDefault {
+			r.Value = "(set)"
+		}
+		resolved = append(resolved, r)
+	}
+	return resolved
+}
+
+// configDefaults implements "config defaults [--json]", listing the settings
+// and their defaults, e.g. to diff them between two versions
+func configDefaults(args []string) error {
+	fs := flag.NewFlagSet("config defaults", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print a JSON array instead of a table")
+	fs.Parse(args)
+
+	all := settings()
+	if *asJSON {
+		return printJSON(all)
+	}
+	for _, s := range all {
+		fmt.Printf("%-22s %-24s %s\n", s.Name, s.Default, s.Doc)
+	}
+	return nil
+}
+
+// configShow implements "config show [--resolved] [--json]", printing the
+// config as loaded, with the token left out, or with --resolved the value
+// of each setting in this run and where it comes from: flag, file, env or
+// default
+func configShow(args []string) error {
+	fs := flag.NewFlagSet("config show", flag.ExitOnError)
+	resolved := fs.Bool("resolved", false, "print every setting with its value and source")
+	asJSON := fs.Bool("json", false, "print a JSON array of the resolved settings instead of a table")
+	fs.Parse(args)
+
+	if !*resolved {
+		cfg, err := loadConfig(configPath())
+		if err != nil {
+			return err
+		}
+		cfg.GitHub.Token = ""
+		return printJSON(cfg)
+	}
+
+	file := map[string]interface{}{}
+	data, err := os.ReadFile(configPath())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &file); err != nil {
+			return fmt.Errorf("%s: %w", configPath(), err)
+		}
+	}
+
+	all := resolve(file)
+	if *asJSON {
+		return printJSON(all)
+	}
+	for _, s := range all {
+		fmt.Printf("%-22s %-8s %s\n", s.Name, s.Source, s.Value)
+	}
+	return nil
+}
+
+// printJSON prints v as indented JSON
+func printJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSpec is a parsed five-field cron expression:
+// minute hour day-of-month month day-of-week
+type cronSpec struct {
+	minute, hour, dom, month, dow uint64
+	// Whether day-of-month and day-of-week start with "*", as "*" and "*/2"
+	// do, which like in cron leaves them unrestricted for matchesDay
+	domAny, dowAny bool
+}
+
+func parseCron(expr string) (cronSpec, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return cronSpec{}, fmt.Errorf("cron %q: expected 5 fields, got %d", expr, len(fields))
+	}
+
+	var spec cronSpec
+	var err error
+	if spec.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return spec, fmt.Errorf("cron %q: minute: %w", expr, err)
+	}
+	if spec.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return spec, fmt.Errorf("cron %q: hour: %w", expr, err)
+	}
+	if spec.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return spec, fmt.Errorf("cron %q: day of month: %w", expr, err)
+	}
+	if spec.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return spec, fmt.Errorf("cron %q: month: %w", expr, err)
+	}
+	if spec.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return spec, fmt.Errorf("cron %q: day of week: %w", expr, err)
+	}
+	// Sunday can be written as 0 or 7
+	if spec.dow&(1<<7) != 0 {
+		spec.dow |= 1
+	}
+	spec.domAny = strings.HasPrefix(fields[2], "*")
+	spec.dowAny = strings.HasPrefix(fields[4], "*")
+
+	return spec, nil
+}
+
+// parseCronField parses lists of values, ranges and steps ("1,15", "1-5", "*/10")
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepPart)
+			}
+			step = n
+		}
+
+		lo, hi := min, max
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = strconv.Atoi(from); err != nil {
+				return 0, fmt.Errorf("invalid value %q", from)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(to); err != nil {
+					return 0, fmt.Errorf("invalid value %q", to)
+				}
+			} else if hasStep {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("value out of range %q", part)
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (c cronSpec) matchesDay(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	// Like cron, when both day-of-month and day-of-week are restricted the
+	// day matches if either does, and otherwise if both do, e.g. "*/2" and
+	// "1-5" for every other day of the month that is a weekday
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// next returns the first time strictly after t matching the expression
+func (c cronSpec) next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	// Give up after looking five years ahead (e.g. "0 0 30 2 *")
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 || !c.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
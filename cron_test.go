@@ -0,0 +1,133 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// cronBits sets the bits of the given values, as parseCronField does
+func cronBits(values ...int) uint64 {
+	var bits uint64
+	for _, v := range values {
+		bits |= 1 << uint(v)
+	}
+	return bits
+}
+
+func TestParseCronField(t *testing.T) {
+	tests := []struct {
+		field    string
+		min, max int
+		want     uint64
+		wantErr  bool
+	}{
+		{"5", 0, 59, cronBits(5), false},
+		{"1,15", 1, 31, cronBits(1, 15), false},
+		{"1-5", 0, 7, cronBits(1, 2, 3, 4, 5), false},
+		{"1-5,10-12", 1, 31, cronBits(1, 2, 3, 4, 5, 10, 11, 12), false},
+		{"*/15", 0, 59, cronBits(0, 15, 30, 45), false},
+		{"0-30/10", 0, 59, cronBits(0, 10, 20, 30), false},
+		{"5/20", 0, 59, cronBits(5, 25, 45), false},
+		{"*/2", 0, 7, cronBits(0, 2, 4, 6), false},
+		{"*/10", 1, 31, cronBits(1, 11, 21, 31), false},
+		{"*", 1, 12, cronBits(1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12), false},
+		{"60", 0, 59, 0, true},
+		{"0", 1, 31, 0, true},
+		{"5-1", 0, 59, 0, true},
+		{"*/0", 0, 59, 0, true},
+		{"*/x", 0, 59, 0, true},
+		{"a", 0, 59, 0, true},
+		{"1-b", 0, 59, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			got, err := parseCronField(tt.field, tt.min, tt.max)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("parseCronField(%q, %d, %d) = %b, %v, want %b, error %v", tt.field, tt.min, tt.max, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{"0 9 * *", "0 9 * * * *", "0 24 * * *", "0 9 0 * *", "0 9 * 13 *", "0 9 * * 8", "60 9 * * *"} {
+		t.Run(expr, func(t *testing.T) {
+			if _, err := parseCron(expr); err == nil {
+				t.Errorf("parseCron(%q) succeeded, want an error", expr)
+			}
+		})
+	}
+}
+
+// May 1 2024 is a Wednesday
+func TestCronMatchesDay(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		day  int
+		want bool
+	}{
+		{"every day", "0 9 * * *", 4, true},
+		{"day of month", "0 9 15 * *", 15, true},
+		{"other day of month", "0 9 15 * *", 16, false},
+		{"weekday range", "0 9 * * 1-5", 3, true},
+		{"weekday range on a Saturday", "0 9 * * 1-5", 4, false},
+		{"Sunday as 0", "0 9 * * 0", 5, true},
+		{"Sunday as 7", "0 9 * * 7", 5, true},
+		{"odd day of month", "0 9 */2 * *", 3, true},
+		{"even day of month", "0 9 */2 * *", 4, false},
+		{"even weekday", "0 9 * * */2", 5, true},
+		{"odd weekday", "0 9 * * */2", 6, false},
+		{"odd day of month that is a weekday", "0 9 */2 * 1-5", 3, true},
+		{"odd day of month on a Sunday", "0 9 */2 * 1-5", 5, false},
+		{"even day of month that is a weekday", "0 9 */2 * 1-5", 2, false},
+		{"day of month or weekday, the day of month", "0 9 1 * 1", 1, true},
+		{"day of month or weekday, the weekday", "0 9 1 * 1", 6, true},
+		{"day of month or weekday, neither", "0 9 1 * 1", 7, false},
+		{"day of month range or weekday, both", "0 9 1-5 * 6", 4, true},
+		{"day of month range or weekday, the weekday", "0 9 1-5 * 6", 11, true},
+		{"day of month range or weekday, neither", "0 9 1-5 * 6", 10, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := parseCron(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			day := time.Date(2024, time.May, tt.day, 0, 0, 0, 0, time.UTC)
+			if got := spec.matchesDay(day); got != tt.want {
+				t.Errorf("%q matchesDay(%s) = %v, want %v", tt.expr, day.Format("Mon Jan 2"), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, time.May, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"0 9 * * *", at(3, 8, 0), at(3, 9, 0)},
+		{"0 9 * * *", at(3, 9, 0), at(4, 9, 0)},
+		{"*/15 * * * *", at(3, 9, 7), at(3, 9, 15)},
+		{"0 9 * * 1-5", at(3, 10, 0), at(6, 9, 0)},
+		{"30 9 */2 * 1-5", at(3, 10, 0), at(7, 9, 30)},
+		{"0 17 1 * 1", at(1, 18, 0), at(6, 17, 0)},
+		{"0 0 30 2 *", at(3, 0, 0), time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			spec, err := parseCron(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := spec.next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("%q next(%s) = %s, want %s", tt.expr, tt.from, got, tt.want)
+			}
+		})
+	}
+}
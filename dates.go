@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default date header format, e.g. "Jan 02, 2006"
+const defaultHeaderFormat = "Jan 02, 2006"
+
+// Japanese eras, newest first, with the day each started
+var japaneseEras = []struct {
+	name  string
+	start time.Time
+}{
+	{"令和", time.Date(2019, time.May, 1, 0, 0, 0, 0, time.UTC)},
+	{"平成", time.Date(1989, time.January, 8, 0, 0, 0, 0, time.UTC)},
+	{"昭和", time.Date(1926, time.December, 25, 0, 0, 0, 0, time.UTC)},
+	{"大正", time.Date(1912, time.July, 30, 0, 0, 0, 0, time.UTC)},
+	{"明治", time.Date(1868, time.January, 25, 0, 0, 0, 0, time.UTC)},
+}
+
+// japaneseEra returns the era of the day and its year in the era, "元" for
+// the first
+func japaneseEra(t time.Time) (era, year string) {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	for _, e := range japaneseEras {
+		if !day.Before(e.start) {
+			if n := t.Year() - e.start.Year() + 1; n > 1 {
+				return e.name, strconv.Itoa(n)
+			}
+			return e.name, "元"
+		}
+	}
+	return "", strconv.Itoa(t.Year())
+}
+
+// formatDate formats t with a Go time layout and translates the month and
+// weekday names for the locale, e.g. "2006年1月2日(Mon)" with "ja" gives
+// "2024年5月10日(金)". Unknown locales keep the English names. {era} and
+// {era_year} in the layout are the Japanese era and year in it, e.g.
+// "{era}{era_year}年1月2日" gives "令和6年5月10日".
+func formatDate(t time.Time, layout, locale string) string {
+	if layout == "" {
+		layout = defaultHeaderFormat
+	}
+	// The era is filled in after formatting, so its year isn't read as a layout
+	era, eraYear := japaneseEra(t)
+	formatted := strings.NewReplacer("{era}", era, "{era_year}", eraYear).Replace(t.Format(layout))
+
+	names, ok := locales()[locale]
+	if !ok {
+		return formatted
+	}
+
+	// Full names first so "Monday" isn't replaced as "Mon" + "day". Names
+	// a locale leaves out stay English.
+	var pairs []string
+	for _, pair := range [][2]string{
+		{t.Weekday().String(), names.Weekdays[t.Weekday()]},
+		{t.Month().String(), names.Months[t.Month()-1]},
+		{t.Weekday().String()[:3], names.ShortWeekdays[t.Weekday()]},
+		{t.Month().String()[:3], names.ShortMonths[t.Month()-1]},
+	} {
+		if pair[1] != "" {
+			pairs = append(pairs, pair[0], pair[1])
+		}
+	}
+	return strings.NewReplacer(pairs...).Replace(formatted)
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Save the report to the report file, in its format
+func deliverReport(ctx context.Context, cfg *config, rep *generatedReport, output target, report string) error {
+	return deliverToTargets(ctx, cfg.Hooks, rep, report, []target{output})
+}
+
+// reportFileTarget is the target of the report file in the format and with
+// the columns of --format and --columns, styled as text
+func (cfg *config) reportFileTarget(format, columns string) target {
+	output := target{Type: "file", Path: cfg.ReportFile, Format: format, Columns: cfg.Columns, PDF: cfg.PDF}
+	if columns != "" {
+		output.Columns = strings.Split(columns, ",")
+	}
+	return cfg.withStyle(output)
+}
+
+// Deliver the report to each of the targets. A target that fails doesn't
+// keep the others from getting the report; the failures are returned
+// together. Each target gets the report prepared for it by prepareTarget.
+func deliverToTargets(ctx context.Context, hooks hookConfig, rep *generatedReport, report string, targets []target) error {
+	if err := runHooks(ctx, "pre_delivery", hooks.PreDelivery, rep, report); err != nil {
+		return err
+	}
+	var errs []error
+	for _, t := range targets {
+		if err := writeTarget(ctx, t, rep, report); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", t.describe(), err))
+		}
+	}
+	if err := runHooks(ctx, "post_delivery", hooks.PostDelivery, rep, report); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// prepareTarget returns the report a target gets and its text: the part of
+// its organizations, the reconciled report for files, without the entries
+// more private than it receives, in its style, and as a table for csv and
+// tsv. Emails keep the text, they attach the tables themselves.
+func prepareTarget(t target, rep *generatedReport, report string) (*generatedReport, string, error) {
+	if len(t.Orgs) > 0 {
+		report = rep.render(t.Orgs)
+	}
+	// Files keep whole reports, so they get the reconciled report instead
+	// of its addendum
+	if rep.reconciled != nil && (t.Type == "file" || t.Type == "git") {
+		rep = rep.reconciled
+		report = rep.render(t.Orgs)
+	}
+	// Entries more private than the target receives are left out
+	if restricted, withheld := rep.restrictedTo(t.privacyLevel()); withheld {
+		rep, report = restricted, restricted.render(t.Orgs)
+	}
+	// Outputs with a style get the entries written in it, unless the text
+	// was edited
+	if t.style != nil && !rep.edited {
+		rep = rep.styled(*t.style)
+		report = rep.render(t.Orgs)
+	}
+	if t.Type != "email" && (t.Format == formatCSV || t.Format == formatTSV) {
+		table, err := rep.renderTable(t.Format, t.Columns, t.Orgs)
+		if err != nil {
+			return nil, "", err
+		}
+		report = table
+	}
+	return rep, report, nil
+}
+
+// writeTarget delivers the rendered report to a single target
+func writeTarget(ctx context.Context, t target, rep *generatedReport, report string) error {
+	rep, report, err := prepareTarget(t, rep, report)
+	if err != nil {
+		return err
+	}
+
+	switch t.Type {
+	case "email":
+		return sendEmail(ctx, t, rep, report)
+	case "file":
+		_, err := writeReportFile(t, rep, report, "")
+		return err
+	case "git":
+		return commitToRepo(ctx, t, rep, report)
+	case "github":
+		return postToGitHub(ctx, t, rep, report)
+	case "stdout":
+		fmt.Println(report)
+	case "notion":
+		return postToNotion(ctx, t, rep, report)
+	case "jira":
+		return postToJira(ctx, t, rep)
+	case "google_chat":
+		return postToGoogleChat(ctx, t, rep)
+	case "webhook":
+		return postToAutomation(ctx, t, rep, report)
+	case "slack":
+		return postToSlack(ctx, t, report)
+	}
+	return nil
+}
+
+// writeReportFile writes the report to the file of a file or git target,
+// under dir if not empty, and returns its path
+func writeReportFile(t target, rep *generatedReport, report, dir string) (string, error) {
+	data := []byte(report)
+	if t.Format == formatPDF {
+		pdf, err := rep.renderPDF(t.PDF, t.Orgs)
+		if err != nil {
+			return "", err
+		}
+		data = pdf
+	}
+	if t.Format == formatHTML {
+		page, err := rep.renderHTML(t.PDF, t.Orgs)
+		if err != nil {
+			return "", err
+		}
+		data = page
+	}
+	path, err := reportPath(t.Path, rep)
+	if t.Append {
+		path, err = expandPath(t.Path, rep)
+	}
+	if err != nil {
+		return "", err
+	}
+	path = filepath.Join(dir, path)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	if t.Append {
+		return path, appendReport(path, rep, report)
+	}
+	return path, os.WriteFile(path, data, 0644)
+}
+
+// sendJSON sends body, if not nil, as JSON and decodes the JSON response
+// into out, if not nil
+func sendJSON(ctx context.Context, method, url string, headers map[string]string, body, out interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		respBody, err := io.ReadAll(boundedBody(resp.Body))
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("%s %s: %s: %s", method, url, resp.Status, bytes.TrimSpace(respBody))
+	}
+
+	if out == nil {
+		return nil
+	}
+	// Decoded as it is read; an empty response leaves out as is
+	err = json.NewDecoder(boundedBody(resp.Body)).Decode(out)
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
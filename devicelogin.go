@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	deviceCodeURL  = "https://github.com/login/device/code"
+	oauthTokenURL  = "https://github.com/login/oauth/access_token"
+	deviceGrant    = "urn:ietf:params:oauth:grant-type:device_code"
+	defaultScopes  = "repo read:user"
+	slowDownOffset = 5 * time.Second
+)
+
+// A device code returned by GitHub to start the device flow
+type deviceCode struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+// pollDeviceToken waits until I authorize the device code in the browser
+// and returns the access token, polling as often as GitHub allows
+func pollDeviceToken(ctx context.Context, clientID string, code deviceCode) (string, error) {
+	interval := time.Duration(code.Interval) * time.Second
+	if interval == 0 {
+		interval = 5 * time.Second
+	}
+	deadline := time.Now().Add(time.Duration(code.ExpiresIn) * time.Second)
+	headers := map[string]string{"Accept": "application/json"}
+
+	for time.Now().Before(deadline) {
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+
+		var result struct {
+			AccessToken string `json:"access_token"`
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		body := map[string]string{"client_id": clientID, "device_code": code.DeviceCode, "grant_type": deviceGrant}
+		if err := sendJSON(ctx, "POST", oauthTokenURL, headers, body, &result); err != nil {
+			return "", err
+		}
+
+		switch result.Error {
+		case "":
+			return result.AccessToken, nil
+		case "authorization_pending":
+		case "slow_down":
+			interval += slowDownOffset
+		default:
+			return "", fmt.Errorf("login failed: %s", result.Description)
+		}
+	}
+	return "", errors.New("login failed: the code expired, run login again")
+}
+
+// login implements "login [--scopes ...]": sign in with GitHub's OAuth device
+// flow and keep the token in the OS keychain, like "auth login". The OAuth
+// App is the one of GITHUB_CLIENT_ID, with device flow enabled.
+func login(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("login", flag.ExitOnError)
+	scopes := fs.String("scopes", defaultScopes, "OAuth scopes to request")
+	fs.Parse(args)
+
+	clientID := os.Getenv("GITHUB_CLIENT_ID")
+	if clientID == "" {
+		return errors.New("GITHUB_CLIENT_ID is not set")
+	}
+
+	var code deviceCode
+	headers := map[string]string{"Accept": "application/json"}
+	if err := sendJSON(ctx, "POST", deviceCodeURL, headers, map[string]string{"client_id": clientID, "scope": *scopes}, &code); err != nil {
+		return err
+	}
+
+	fmt.Printf("Open %s and enter the code %s\n", code.VerificationURI, code.UserCode)
+	// Best effort, the URL is printed anyway
+	openURL(code.VerificationURI)
+
+	token, err := pollDeviceToken(ctx, clientID, code)
+	if err != nil {
+		return err
+	}
+
+	var user struct {
+		Login string `json:"login"`
+	}
+	if err := githubGet(ctx, githubAPI+"/user", token, &user); err != nil {
+		return err
+	}
+	if err := keychainSet(keyringService, keyringUser, token); err != nil {
+		return fmt.Errorf("storing the token in the keychain: %w", err)
+	}
+	fmt.Printf("Logged in as %s\n", user.Login)
+	return nil
+}
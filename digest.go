@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Defaults for batch runs: two members fetched at a time, one started a second
+const (
+	defaultBatchConcurrency = 2
+	defaultBatchInterval    = time.Second
+)
+
+// batchConfig spreads the API calls of batch runs such as the team digest
+type batchConfig struct {
+	// Members fetched at the same time
+	Concurrency int `json:"concurrency,omitempty"`
+	// Minimum time between starting to fetch two members, e.g. "2s"; each
+	// takes up to maxEventPages API calls
+	Interval string `json:"interval,omitempty"`
+}
+
+func (b batchConfig) concurrency() int {
+	if b.Concurrency > 0 {
+		return b.Concurrency
+	}
+	return defaultBatchConcurrency
+}
+
+func (b batchConfig) interval() time.Duration {
+	if d, err := time.ParseDuration(b.Interval); err == nil && d > 0 {
+		return d
+	}
+	return defaultBatchInterval
+}
+
+// checkpoint returns the events of the member already fetched for the day's
+// digest, if any
+func (h *historyStore) checkpoint(date, login string) ([]map[string]interface{}, bool, error) {
+	var events string
+	err := h.db.QueryRow(`SELECT events FROM digest_checkpoints WHERE date = ? AND login = ?`, date, login).Scan(&events)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var parsed []map[string]interface{}
+	return parsed, true, parseJSON([]byte(events), &parsed)
+}
+
+// saveCheckpoint stores the events fetched for a member, so a digest that
+// is interrupted resumes where it stopped
+func (h *historyStore) saveCheckpoint(date, login string, events []map[string]interface{}) error {
+	if events == nil {
+		events = []map[string]interface{}{}
+	}
+	data, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	_, err = h.db.Exec(`INSERT INTO digest_checkpoints (date, login, events, fetched_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (date, login) DO UPDATE SET events = excluded.events, fetched_at = excluded.fetched_at`,
+		date, login, string(data), time.Now().Format(time.RFC3339))
+	return err
+}
+
+// clearCheckpoints drops the checkpoints of the day's digest once it is
+// complete
+func (h *historyStore) clearCheckpoints(date string) error {
+	_, err := h.db.Exec(`DELETE FROM digest_checkpoints WHERE date = ?`, date)
+	return err
+}
+
+// fetchTeamEvents fetches the day's events of every member, at most
+// concurrency at a time and one member started per interval across all of
+// them, each taking up to maxEventPages API calls. Members with a checkpoint
+// of an interrupted run are not fetched again. A member that fails is logged
+// and the others carry on; the first error is returned at the end. Once all
+// are fetched the day's checkpoints are cleared, so a later digest of the
+// day fetches afresh.
+func fetchTeamEvents(ctx context.Context, h *historyStore, members []string, date, token string, batch batchConfig) (map[string][]map[string]interface{}, error) {
+	events := make(map[string][]map[string]interface{})
+	var pending []string
+	for _, login := range members {
+		memberEvents, ok, err := h.checkpoint(date, login)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			events[login] = memberEvents
+		} else {
+			pending = append(pending, login)
+		}
+	}
+
+	ticker := time.NewTicker(batch.interval())
+	defer ticker.Stop()
+
+	var mu sync.Mutex
+	var firstErr error
+	var wg sync.WaitGroup
+	queue := make(chan string)
+	for i := 0; i < batch.concurrency(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for login := range queue {
+				select {
+				case <-ticker.C:
+				case <-ctx.Done():
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = ctx.Err()
+					}
+					mu.Unlock()
+					continue
+				}
+				memberEvents, err := getDailyEvents(ctx, login, token, date)
+				if err == nil {
+					err = h.saveCheckpoint(date, login, memberEvents)
+				}
+
+				mu.Lock()
+				if err != nil {
+					slog.Warn("Fetching member events failed", "member", login, "err", err)
+					if firstErr == nil {
+						firstErr = err
+					}
+				} else {
+					events[login] = memberEvents
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, login := range pending {
+		queue <- login
+	}
+	close(queue)
+	wg.Wait()
+
+	if firstErr != nil {
+		return events, firstErr
+	}
+	return events, h.clearCheckpoints(date)
+}
+
+// storedTeamEvents returns the day's events of every member as received
+// by webhook
+func storedTeamEvents(members []string, date string) (map[string][]map[string]interface{}, error) {
+	events := make(map[string][]map[string]interface{})
+	for _, login := range members {
+		memberEvents, err := storedWebhookEvents(date, login)
+		if err != nil {
+			return nil, err
+		}
+		events[login] = memberEvents
+	}
+	return events, nil
+}
+
+// generateDigest builds the team digest of the day: the entries of each
+// member of the team, in the order of the config. With webhook the events
+// are the ones received by webhook instead of fetched from the events API.
+func generateDigest(ctx context.Context, cfg *config, date string, webhook bool) (*generatedReport, error) {
+	if len(cfg.Team) == 0 {
+		return nil, errors.New("no team configured")
+	}
+
+	var events map[string][]map[string]interface{}
+	if webhook {
+		var err error
+		if events, err = storedTeamEvents(cfg.Team, date); err != nil {
+			return nil, err
+		}
+	} else {
+		token, err := githubToken(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		h, err := openHistory(historyPath())
+		if err != nil {
+			return nil, err
+		}
+		defer h.Close()
+		if events, err = fetchTeamEvents(ctx, h, cfg.Team, date, token, cfg.Batch); err != nil {
+			return nil, fmt.Errorf("%w (run again to resume)", err)
+		}
+	}
+
+	day, _ := time.ParseInLocation(dateFormat, date, time.Local)
+	messages := cfg.messages()
+	header := messages.textf("Team digest, %s", cfg.formatHeaderDate(day))
+	byMember := make(map[string][]reportEntry)
+	var entries []reportEntry
+	for _, login := range cfg.Team {
+		byMember[login] = buildEntries(cfg.withoutIgnored(events[login]), login)
+		entries = append(entries, byMember[login]...)
+	}
+
+	return &generatedReport{
+		date:     date,
+		username: cfg.GitHub.Username,
+		header:   header,
+		entries:  entries,
+		messages: messages,
+		format: func(_ *generatedReport, _ []reportEntry, orgs []string) string {
+			report := fmt.Sprintf("%s:\n", header)
+			for _, login := range cfg.Team {
+				report += fmt.Sprintf("\n%s:\n", login)
+				memberEntries := filterEntries(byMember[login], orgs)
+				if len(memberEntries) == 0 {
+					report += messages.text("No activity") + "\n"
+				}
+				for _, entry := range memberEntries {
+					report += fmt.Sprintf("%s | %s\n", messages.text(entry.status), entry.title)
+				}
+			}
+			return report
+		},
+	}, nil
+}
+
+// digest implements "digest [--date YYYY-MM-DD] [--webhook]"
+func digest(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("digest", flag.ExitOnError)
+	date := fs.String("date", time.Now().Format(dateFormat), "day of the digest")
+	webhook := fs.Bool("webhook", false, "build the digest from the events received by serve --webhook")
+	fs.Parse(args)
+	if _, err := time.Parse(dateFormat, *date); err != nil {
+		return fmt.Errorf("invalid date %q, expected YYYY-MM-DD", *date)
+	}
+
+	cfg, err := loadConfig(configPath())
+	if err != nil {
+		return err
+	}
+	rep, err := generateDigest(ctx, cfg, *date, *webhook)
+	if err != nil {
+		return err
+	}
+	fmt.Println(rep.render(nil))
+	return nil
+}
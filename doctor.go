@@ -0,0 +1,473 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Clock skew from GitHub's clock from which doctor warns, as dates and
+// signed requests start to go wrong
+const maxClockSkew = 30 * time.Second
+
+// Remaining GitHub requests under which doctor warns
+const lowRateLimit = 100
+
+// A doctor run, counting the failed checks
+type doctor struct {
+	failed int
+}
+
+func (d *doctor) ok(check, detail string, args ...interface{}) {
+	fmt.Printf("ok    %s: %s\n", check, fmt.Sprintf(detail, args...))
+}
+
+func (d *doctor) warn(check, detail string, args ...interface{}) {
+	fmt.Printf("warn  %s: %s\n", check, fmt.Sprintf(detail, args...))
+}
+
+func (d *doctor) fail(check string, err error) {
+	d.failed++
+	fmt.Printf("FAIL  %s: %v\n", check, err)
+}
+
+// failFix fails the check, saying what to do about it on the next line
+func (d *doctor) failFix(check string, err error, fix string, args ...interface{}) {
+	d.fail(check, err)
+	fmt.Printf("      fix: %s\n", fmt.Sprintf(fix, args...))
+}
+
+// writableDir checks a file can be created in dir, or in its closest
+// existing parent for directories created on delivery
+func writableDir(dir string) error {
+	for {
+		if _, err := os.Stat(dir); !errors.Is(err, os.ErrNotExist) || filepath.Dir(dir) == dir {
+			break
+		}
+		dir = filepath.Dir(dir)
+	}
+	f, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// checkGitHub checks the token signs in as the configured user with the
+// scopes the enabled features need, the remaining rate limits, and the
+// clock against GitHub's
+func (d *doctor) checkGitHub(ctx context.Context, cfg *config) {
+	token, err := githubToken(ctx, cfg)
+	if err != nil {
+		d.failFix("GitHub token", err, "run auth login again, or set GITHUB_TOKEN")
+		return
+	}
+	if token == "" {
+		d.failFix("GitHub token", errors.New("no token configured"), "set GITHUB_TOKEN or run auth login")
+		return
+	}
+
+	// Installation tokens act as the app, so /user is left out
+	kind := kindOfToken(token)
+	path := "/user"
+	if !kind.user {
+		path = "/rate_limit"
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", githubAPI+path, nil)
+	if err != nil {
+		d.fail("GitHub", err)
+		return
+	}
+	req.Header.Set("Authorization", githubAuthorization(token))
+	req.Header.Set("Accept", "application/vnd.github+json")
+	sent := time.Now()
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		d.fail("GitHub", err)
+		return
+	}
+	body, err := io.ReadAll(boundedBody(resp.Body))
+	resp.Body.Close()
+	if err != nil {
+		d.fail("GitHub", err)
+		return
+	}
+
+	if date, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		// GitHub's clock at the middle of the request
+		skew := sent.Add(time.Since(sent) / 2).Sub(date)
+		if skew.Abs() > maxClockSkew {
+			d.warn("Clock", "%s off from GitHub's clock, check the system time", skew.Round(time.Second))
+		} else {
+			d.ok("Clock", "within %s of GitHub's clock", maxClockSkew)
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("GET %s: %s: %s", path, resp.Status, strings.TrimSpace(string(body)))
+		if resp.StatusCode == http.StatusUnauthorized {
+			d.failFix("GitHub token", err, "the token is invalid, expired or revoked; create a new one and set GITHUB_TOKEN, or run auth login")
+		} else {
+			d.fail("GitHub token", err)
+		}
+		return
+	}
+	var user struct {
+		Login string `json:"login"`
+	}
+	if err := json.Unmarshal(body, &user); err != nil {
+		d.fail("GitHub token", err)
+		return
+	}
+	switch {
+	case !kind.user:
+		d.ok("GitHub token", "%s, acting as the app", kind.name)
+	case cfg.GitHub.Username != "" && !strings.EqualFold(user.Login, cfg.GitHub.Username):
+		d.warn("GitHub token", "%s signing in as %s, but the report is about %s", kind.name, user.Login, cfg.GitHub.Username)
+	default:
+		d.ok("GitHub token", "%s signing in as %s", kind.name, user.Login)
+	}
+	if cfg.Notifications {
+		if err := needsNotifications(token); err != nil {
+			d.warn("GitHub notifications", "%s", err)
+		}
+	}
+
+	// Fine-grained and app tokens don't list scopes
+	if header, listed := resp.Header["X-Oauth-Scopes"]; listed {
+		scopes := strings.Split(strings.ReplaceAll(strings.Join(header, ","), " ", ""), ",")
+		needed := []string{"repo"}
+		if cfg.Notifications {
+			needed = append(needed, "notifications")
+		}
+		var missing []string
+		for _, scope := range needed {
+			if !slices.Contains(scopes, scope) {
+				missing = append(missing, scope)
+			}
+		}
+		if len(missing) > 0 {
+			d.warn("GitHub scopes", "missing %s, private repositories or notifications may be left out", strings.Join(missing, ", "))
+		} else {
+			d.ok("GitHub scopes", "%s", strings.Join(header, ","))
+		}
+	}
+
+	var limits struct {
+		Resources map[string]struct {
+			Limit     int   `json:"limit"`
+			Remaining int   `json:"remaining"`
+			Reset     int64 `json:"reset"`
+		} `json:"resources"`
+	}
+	if err := sendJSON(ctx, "GET", githubAPI+"/rate_limit", map[string]string{"Authorization": githubAuthorization(token)}, nil, &limits); err != nil {
+		d.fail("GitHub rate limit", err)
+		return
+	}
+	for _, resource := range []string{"core", "search"} {
+		limit := limits.Resources[resource]
+		reset := time.Unix(limit.Reset, 0).Local().Format(time.Kitchen)
+		if limit.Remaining < min(lowRateLimit, limit.Limit) {
+			d.warn("GitHub rate limit", "%s: %d of %d requests left until %s", resource, limit.Remaining, limit.Limit, reset)
+		} else {
+			d.ok("GitHub rate limit", "%s: %d of %d requests left", resource, limit.Remaining, limit.Limit)
+		}
+	}
+}
+
+// reachable checks a TCP connection can be made to the host of a URL
+func reachable(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	port := u.Port()
+	if port == "" {
+		port = map[string]string{"http": "80"}[u.Scheme]
+		if port == "" {
+			port = "443"
+		}
+	}
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// checkTarget checks a target can be delivered to without delivering
+// anything: files can be written, today's for templated paths, APIs accept
+// the credentials, and webhook hosts can be reached, as posting to them
+// would deliver a message
+func (d *doctor) checkTarget(ctx context.Context, cfg *config, name string, t target) {
+	check := "Target " + name
+	var err error
+	switch t.Type {
+	case "file":
+		err = writableReportDir(cfg, t.Path)
+	case "git":
+		err = checkRepo(cfg, t)
+	case "github":
+		err = checkGitHubTarget(ctx, t)
+	case "notion":
+		err = sendJSON(ctx, "GET", notionAPI+"/databases/"+t.DatabaseID, notionHeaders(t), nil, nil)
+	case "jira":
+		err = sendJSON(ctx, "GET", strings.TrimSuffix(t.URL, "/")+"/rest/api/3/myself", jiraHeaders(t), nil, nil)
+	case "google_chat", "webhook":
+		err = reachable(ctx, t.URL)
+	case "slack":
+		err = checkSlackWebhook(ctx, t.URL)
+	case "email":
+		err = checkEmail(ctx, t)
+	}
+	if err != nil {
+		d.failFix(check, err, "%s", targetFix(t, err))
+		return
+	}
+	d.ok(check, "%s", t.describe())
+}
+
+// targetFix says what to do about a target failing its check
+func targetFix(t target, err error) string {
+	switch t.Type {
+	case "file", "git":
+		if errors.Is(err, os.ErrPermission) {
+			return "make the directory writable by this user, or change the target's path"
+		}
+		return "check the target's path, and for git targets that repo is a local clone"
+	case "github":
+		return "check the target's token or GITHUB_TOKEN can read and write " + t.Repo
+	case "notion":
+		return "check NOTION_TOKEN, or the target's token, and share the database with the integration"
+	case "jira":
+		return "check the target's url and email, and JIRA_TOKEN or the target's token"
+	case "slack":
+		return "the webhook may be revoked or mistyped; create an incoming webhook in Slack and update the target's url"
+	case "email":
+		return "check the target's smtp, from and to, and SMTP_USERNAME and SMTP_PASSWORD or the target's token"
+	}
+	return "check the target's url can be reached from this machine"
+}
+
+// Errors Slack incoming webhooks answer an empty message with: the webhook
+// is valid, and nothing is posted
+var slackEmptyMessage = []string{"no_text", "missing_text_or_fallback_or_attachments"}
+
+// checkSlackWebhook checks Slack knows the incoming webhook, by posting an
+// empty message it turns down
+func checkSlackWebhook(ctx context.Context, webhook string) error {
+	err := sendJSON(ctx, "POST", webhook, nil, map[string]string{}, nil)
+	if err == nil {
+		return nil
+	}
+	if slices.ContainsFunc(slackEmptyMessage, func(answer string) bool { return strings.HasSuffix(err.Error(), ": "+answer) }) {
+		return nil
+	}
+	return err
+}
+
+// checkEmail checks the addresses of an email target and that its SMTP
+// server accepts the sign-in
+func checkEmail(ctx context.Context, t target) error {
+	if len(t.To) == 0 {
+		return errors.New("no recipients in to")
+	}
+	for _, address := range append([]string{t.From}, t.To...) {
+		if _, err := mail.ParseAddress(address); err != nil {
+			return fmt.Errorf("address %q: %w", address, err)
+		}
+	}
+	return checkSMTP(ctx, t)
+}
+
+// writableReportDir checks today's report can be written to path
+func writableReportDir(cfg *config, path string) error {
+	path, err := reportPath(path, &generatedReport{date: time.Now().Format(dateFormat), username: cfg.GitHub.Username})
+	if err != nil {
+		return err
+	}
+	return writableDir(filepath.Dir(path))
+}
+
+// checkSMTP connects and signs in to the SMTP server of an email target
+func checkSMTP(ctx context.Context, t target) error {
+	host, _, err := net.SplitHostPort(t.SMTP)
+	if err != nil {
+		return err
+	}
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", t.SMTP)
+	if err != nil {
+		return err
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if err := startTLSAndAuth(c, t, host); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// checkSources checks the other sources of the report and the local state
+func (d *doctor) checkSources(ctx context.Context, cfg *config) {
+	if cfg.Calendar != nil {
+		if _, err := cfg.Calendar.googleAccessToken(ctx); err != nil {
+			d.fail("Google Calendar", err)
+		} else {
+			d.ok("Google Calendar", "signed in")
+		}
+	}
+
+	if cfg.Summary != nil {
+		env := map[string]string{"openai": "OPENAI_API_KEY", "anthropic": "ANTHROPIC_API_KEY"}[cfg.Summary.Provider]
+		if pick(cfg.Summary.APIKey, env, "") == "" {
+			d.fail("Summary", fmt.Errorf("no API key, set api_key or %s", env))
+		} else if err := reachable(ctx, pick(cfg.Summary.URL, "", "https://api."+cfg.Summary.Provider+".com")); err != nil {
+			d.fail("Summary", err)
+		} else {
+			d.ok("Summary", "%s API key set", cfg.Summary.Provider)
+		}
+	}
+
+	if cfg.Blockers != nil {
+		var auth struct {
+			User string `json:"user"`
+			Team string `json:"team"`
+		}
+		if cfg.Blockers.token() == "" {
+			d.failFix("Slack blockers", errors.New("no bot token"), "set SLACK_BOT_TOKEN or blockers.slack_token")
+		} else if err := slackCall(ctx, cfg.Blockers.token(), "auth.test", nil, nil, &auth); err != nil {
+			d.failFix("Slack blockers", err, "check the bot token in SLACK_BOT_TOKEN or blockers.slack_token is still installed")
+		} else {
+			d.ok("Slack blockers", "signed in as %s on %s", auth.User, auth.Team)
+		}
+	}
+
+	if root := os.Getenv("LOCAL_REPOS"); root != "" {
+		if info, err := os.Stat(root); err != nil {
+			d.fail("Local clones", err)
+		} else if !info.IsDir() {
+			d.fail("Local clones", fmt.Errorf("%s is not a directory", root))
+		} else {
+			d.ok("Local clones", "%s", root)
+		}
+	}
+
+	if h, err := openHistory(historyPath()); err != nil {
+		d.fail("History", err)
+	} else {
+		d.ok("History", "%s", historyPath())
+		if githubAppConfigured() {
+			if repos, err := h.subscribedRepos(); err != nil {
+				d.fail("GitHub App", err)
+			} else if len(repos) == 0 {
+				d.warn("GitHub App", "no repositories reported by webhook yet")
+			} else {
+				d.ok("GitHub App", "installed on %d repositories: %s", len(repos), strings.Join(repos, ", "))
+			}
+		}
+		h.Close()
+	}
+
+	if cfg.ReportFile != "" {
+		if err := writableReportDir(cfg, cfg.ReportFile); err != nil {
+			d.failFix("Report file", err, "make the directory of report_file or REPORT_FILE writable, or change it")
+		} else {
+			d.ok("Report file", "%s", cfg.ReportFile)
+		}
+	}
+
+	// The cache directory is created on first use
+	if _, err := os.Stat(cacheDir); cacheDir != "" && err == nil {
+		if err := writableDir(cacheDir); err != nil {
+			d.failFix("Cache", err, "make CACHE_DIR writable, or run with --no-cache")
+		} else {
+			d.ok("Cache", "%s", cacheDir)
+		}
+	}
+}
+
+// checkTemplates checks the templates of the config: the report's, the
+// profiles' included, render the sample report of "template dev", which
+// their syntax alone doesn't tell, and the retro's parses
+func (d *doctor) checkTemplates(cfg *config) {
+	if cfg.reportTemplate != nil {
+		if _, err := executeTemplate(cfg.reportTemplate, sampleReportData(cfg)); err != nil {
+			d.failFix("Report template", err, "correct %s, previewing it with template dev", cfg.ReportTemplate)
+		} else {
+			d.ok("Report template", "%s renders the sample report", cfg.ReportTemplate)
+		}
+	}
+	for _, name := range sortedKeys(cfg.Profiles) {
+		path := cfg.Profiles[name].ReportTemplate
+		if path == "" || path == cfg.ReportTemplate {
+			continue
+		}
+		tmpl, err := loadReportTemplate(path)
+		if err == nil {
+			_, err = executeTemplate(tmpl, sampleReportData(cfg))
+		}
+		if err != nil {
+			d.failFix("Report template", fmt.Errorf("profile %s: %w", name, err), "correct %s, previewing it with template dev %s", path, path)
+		} else {
+			d.ok("Report template", "profile %s: %s renders the sample report", name, path)
+		}
+	}
+
+	if cfg.RetroTemplate != "" {
+		data, err := os.ReadFile(cfg.RetroTemplate)
+		if err == nil {
+			_, err = template.New("retro").Parse(string(data))
+		}
+		if err != nil {
+			d.failFix("Retro template", err, "correct retro_template, or remove it to use the built-in one")
+		} else {
+			d.ok("Retro template", "%s", cfg.RetroTemplate)
+		}
+	}
+}
+
+// doctorCommand implements "doctor", checking the config, every source and
+// target, the GitHub token and rate limits, the clock, the local paths and
+// the templates, and printing what it found with how to fix what failed. It
+// fails when a check does.
+func doctorCommand() error {
+	d := &doctor{}
+	cfg, err := loadConfig(configPath())
+	if err != nil {
+		d.failFix("Config", err, "correct %s, config.example.json has an example of the settings", configPath())
+		return errors.New("config is invalid, nothing else checked")
+	}
+	d.ok("Config", "%s", configPath())
+
+	ctx, cancel := runContext(context.Background())
+	defer cancel()
+	d.checkGitHub(ctx, cfg)
+	d.checkSources(ctx, cfg)
+	d.checkTemplates(cfg)
+	for _, name := range sortedKeys(cfg.Targets) {
+		d.checkTarget(ctx, cfg, name, cfg.Targets[name])
+	}
+
+	if d.failed > 0 {
+		return fmt.Errorf("%d checks failed", d.failed)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// describe names where a target delivers to, e.g. "file daily_report.txt"
+func (t target) describe() string {
+	switch t.Type {
+	case "file":
+		return "file " + t.Path
+	case "git":
+		return "git " + filepath.Join(t.Repo, t.Path)
+	case "github":
+		if t.Issue != 0 {
+			return fmt.Sprintf("github issue %s#%d", t.Repo, t.Issue)
+		}
+		if t.Category != "" {
+			return fmt.Sprintf("github discussions of %s in %s", t.Repo, t.Category)
+		}
+		return "github issues of " + t.Repo
+	case "notion":
+		return "notion database " + t.DatabaseID
+	case "jira", "google_chat", "webhook", "slack":
+		return t.Type + " " + t.URL
+	case "email":
+		return "email to " + strings.Join(t.To, ", ")
+	}
+	return t.Type
+}
+
+// dryRun prints what would be delivered to each target, as prepared by
+// prepareTarget, and how the report differs from the one already stored for
+// the day, without writing anything
+func dryRun(rep *generatedReport, report string, targets []target) error {
+	for _, t := range targets {
+		fmt.Printf("Would deliver to %s:\n", t.describe())
+		prepared, text, err := prepareTarget(t, rep, report)
+		if err != nil {
+			return err
+		}
+		switch {
+		case t.Type != "email" && (t.Format == formatCSV || t.Format == formatTSV):
+			fmt.Print(text)
+		case t.Format == formatPDF:
+			if _, err := prepared.renderPDF(t.PDF, t.Orgs); err != nil {
+				return err
+			}
+			fmt.Println("(PDF of the report)")
+		case t.Format == formatHTML:
+			if _, err := prepared.renderHTML(t.PDF, t.Orgs); err != nil {
+				return err
+			}
+			fmt.Println("(HTML page of the report)")
+		default:
+			fmt.Println(text)
+		}
+	}
+
+	h, err := openHistory(historyPath())
+	if err != nil {
+		return err
+	}
+	defer h.Close()
+
+	previous, err := h.get(rep.date)
+	if err != nil {
+		fmt.Printf("No report stored for %s yet\n", rep.date)
+		return nil
+	}
+	if previous.report == report {
+		fmt.Printf("Same as the report stored for %s\n", rep.date)
+		return nil
+	}
+	fmt.Printf("Changes from the report stored for %s:\n%s", rep.date, lineDiff(previous.report, report))
+	return nil
+}
+
+// lineDiff compares two texts line by line, marking removed lines "-",
+// added lines "+" and unchanged ones " "
+func lineDiff(before, after string) string {
+	a := strings.Split(strings.TrimSuffix(before, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(after, "\n"), "\n")
+
+	// Longest common subsequence lengths of the suffixes
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var diff strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			diff.WriteString("  " + a[i] + "\n")
+			i, j = i+1, j+1
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			diff.WriteString("+ " + b[j] + "\n")
+			j++
+		default:
+			diff.WriteString("- " + a[i] + "\n")
+			i++
+		}
+	}
+	return diff.String()
+}
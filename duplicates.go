@@ -0,0 +1,223 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// What to do when a report is already stored for the day
+const (
+	// Replace the stored report, and update what was delivered where the
+	// target allows it
+	policyOverwrite = "overwrite"
+	// Keep the stored report as an earlier revision and deliver the new one
+	// as the next revision
+	policyAppendRevision = "append-revision"
+	// Stop without saving or delivering anything
+	policyAbort = "abort"
+)
+
+// A stored report superseded by a later revision of the same day
+type reportRevision struct {
+	revision  int
+	report    string
+	createdAt time.Time
+}
+
+// storedRevision returns the revision number of the report stored for the
+// day, 0 when there is none
+func (h *historyStore) storedRevision(date string) (int, error) {
+	var stored, archived int
+	err := h.db.QueryRow(`SELECT (SELECT COUNT(*) FROM reports WHERE date = ?), (SELECT COUNT(*) FROM report_revisions WHERE date = ?)`, date, date).
+		Scan(&stored, &archived)
+	if err != nil || stored == 0 {
+		return 0, err
+	}
+	return archived + 1, nil
+}
+
+// archive keeps the report stored for the day as the given revision, before
+// it is replaced by the next one
+func (h *historyStore) archive(date string, revision int) error {
+	_, err := h.db.Exec(`INSERT INTO report_revisions (date, revision, events, report, created_at)
+		SELECT date, ?, events, report, created_at FROM reports WHERE date = ?`, revision, date)
+	return err
+}
+
+// revisions returns the earlier revisions of the day's report, oldest first
+func (h *historyStore) revisions(date string) ([]reportRevision, error) {
+	rows, err := h.db.Query(`SELECT revision, report, created_at FROM report_revisions WHERE date = ? ORDER BY revision`, date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []reportRevision
+	for rows.Next() {
+		var r reportRevision
+		var createdAt string
+		if err := rows.Scan(&r.revision, &r.report, &createdAt); err != nil {
+			return nil, err
+		}
+		r.createdAt, _ = time.Parse(time.RFC3339, createdAt)
+		revisions = append(revisions, r)
+	}
+	return revisions, rows.Err()
+}
+
+// applyDuplicatePolicy works out the revision of the report from what is
+// already stored for its day, failing with the abort policy
+func applyDuplicatePolicy(rep *generatedReport, policy string) error {
+	h, err := openHistory(historyPath())
+	if err != nil {
+		return err
+	}
+	defer h.Close()
+
+	stored, err := h.storedRevision(rep.date)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case stored == 0:
+		rep.revision = 1
+	case policy == policyAbort:
+		return fmt.Errorf("a report is already stored for %s, run again with --overwrite or --append-revision", rep.date)
+	case policy == policyAppendRevision:
+		rep.revision = stored + 1
+	default:
+		rep.revision, rep.replaces = stored, true
+	}
+	return nil
+}
+
+// duplicatePolicy picks the policy from the --overwrite, --append-revision
+// and --abort flags, falling back to the configured one
+func duplicatePolicy(cfg *config, overwrite, appendRevision, abort bool) (string, error) {
+	var chosen []string
+	if overwrite {
+		chosen = append(chosen, policyOverwrite)
+	}
+	if appendRevision {
+		chosen = append(chosen, policyAppendRevision)
+	}
+	if abort {
+		chosen = append(chosen, policyAbort)
+	}
+
+	switch len(chosen) {
+	case 0:
+		return cfg.duplicatePolicy(), nil
+	case 1:
+		return chosen[0], nil
+	default:
+		return "", fmt.Errorf("only one of --%s can be given", strings.Join(chosen, ", --"))
+	}
+}
+
+// The configured duplicate-day policy, overwrite by default
+func (cfg *config) duplicatePolicy() string {
+	if cfg.OnDuplicate == "" {
+		return policyOverwrite
+	}
+	return cfg.OnDuplicate
+}
+
+// reportPath is the file a revision of the report is written to, see
+// expandPath and revisionPath
+func reportPath(path string, rep *generatedReport) (string, error) {
+	path, err := expandPath(path, rep)
+	if err != nil {
+		return "", err
+	}
+	return revisionPath(path, rep.revision), nil
+}
+
+// expandPath expands a file path as a text/template with reportPathData
+// when it has actions, e.g. "reports/{{.Date}}/daily-{{.Username}}.md"
+func expandPath(path string, rep *generatedReport) (string, error) {
+	if strings.Contains(path, "{{") {
+		tmpl, err := template.New("path").Option("missingkey=error").Parse(path)
+		if err != nil {
+			return "", err
+		}
+		day, _ := time.ParseInLocation(dateFormat, rep.date, time.Local)
+		data := reportPathData{
+			Date:     rep.date,
+			Username: rep.username,
+			Year:     day.Format("2006"),
+			Month:    day.Format("01"),
+			Day:      day.Format("02"),
+		}
+		var b strings.Builder
+		if err := tmpl.Execute(&b, data); err != nil {
+			return "", err
+		}
+		path = b.String()
+	}
+	return path, nil
+}
+
+// Fields of report path templates
+type reportPathData struct {
+	// Day of the report, e.g. "2024-05-10", and its parts
+	Date, Year, Month, Day string
+	// GitHub login the report is about
+	Username string
+}
+
+// revisionPath is the file a revision of the report is written to:
+// "daily_report.txt" for the first one, "daily_report.r2.txt" for the second
+func revisionPath(path string, revision int) string {
+	if revision <= 1 {
+		return path
+	}
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s.r%d%s", strings.TrimSuffix(path, ext), revision, ext)
+}
+
+// revisionTitle marks later revisions in titles of delivered reports, e.g.
+// "Oct 14 (revision 2)"
+func revisionTitle(rep *generatedReport, title string) string {
+	if rep.revision > 1 && !rep.replaces {
+		return fmt.Sprintf("%s (revision %d)", title, rep.revision)
+	}
+	return title
+}
+
+// deliveredRef returns what was created for the item of the target when the
+// day's report was delivered, e.g. the Notion page ID, or "" if nothing was
+func deliveredRef(date, target, item string) (string, error) {
+	h, err := openHistory(historyPath())
+	if err != nil {
+		return "", err
+	}
+	defer h.Close()
+
+	var ref string
+	err = h.db.QueryRow(`SELECT ref FROM deliveries WHERE date = ? AND target = ? AND item = ?`, date, target, item).Scan(&ref)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return ref, err
+}
+
+// recordDelivery remembers what was created for the item of the target, so
+// an overwriting run updates it instead of posting again
+func recordDelivery(date, target, item, ref string) error {
+	h, err := openHistory(historyPath())
+	if err != nil {
+		return err
+	}
+	defer h.Close()
+
+	_, err = h.db.Exec(`INSERT INTO deliveries (date, target, item, ref) VALUES (?, ?, ?, ?)
+		ON CONFLICT (date, target, item) DO UPDATE SET ref = excluded.ref`, date, target, item, ref)
+	return err
+}
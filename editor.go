@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// editReport opens the report in $VISUAL or $EDITOR (vi by default) so
+// manual items can be added and noise removed, and returns the edited text
+func editReport(report string) (string, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	f, err := os.CreateTemp("", "daily-report-*.txt")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.WriteString(report); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	// The editor may come with arguments, e.g. "code --wait"
+	args := append(strings.Fields(editor), f.Name())
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+
+	edited, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(string(edited)) == "" {
+		return "", errors.New("report is empty after editing, nothing delivered")
+	}
+	return string(edited), nil
+}
+
+// checkEditable returns an error naming the targets the edits of
+// --interactive would be lost on, as they render the report again from its
+// entries: those restricted to organizations or withholding private
+// entries, tables, PDFs and HTML pages, Jira, Google Chat and webhooks, and the files
+// of reconciled days
+func checkEditable(rep *generatedReport, targets []target) error {
+	var lost []string
+	for _, t := range targets {
+		_, withheld := rep.restrictedTo(t.privacyLevel())
+		switch {
+		case len(t.Orgs) > 0 || withheld:
+		case t.Format != "" && t.Format != formatText:
+		case t.Type == "jira" || t.Type == "google_chat" || t.Type == "webhook":
+		case rep.reconciled != nil && (t.Type == "file" || t.Type == "git"):
+		default:
+			continue
+		}
+		lost = append(lost, t.describe())
+	}
+	if len(lost) > 0 {
+		return fmt.Errorf("--interactive edits would be lost on %s, which render the report from its entries; deliver to them without --interactive", strings.Join(lost, ", "))
+	}
+	return nil
+}
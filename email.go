@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strings"
+)
+
+// base64Lines encodes data as base64 in lines of 76 characters, as MIME
+// bodies may not have longer lines
+func base64Lines(data []byte) string {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	var b strings.Builder
+	for len(encoded) > 76 {
+		b.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	b.WriteString(encoded)
+	return b.String()
+}
+
+// emailAttachment returns the csv, tsv, pdf or html of an email target, with its
+// file name and type, nothing for text
+func emailAttachment(t target, rep *generatedReport) (name, contentType string, data []byte, err error) {
+	switch t.Format {
+	case formatCSV, formatTSV:
+		table, err := rep.renderTable(t.Format, t.Columns, t.Orgs)
+		return fmt.Sprintf("daily_report_%s.%s", rep.date, t.Format), "text/" + map[string]string{formatCSV: "csv", formatTSV: "tab-separated-values"}[t.Format], []byte(table), err
+	case formatPDF:
+		pdf, err := rep.renderPDF(t.PDF, t.Orgs)
+		return fmt.Sprintf("daily_report_%s.pdf", rep.date), "application/pdf", pdf, err
+	case formatHTML:
+		page, err := rep.renderHTML(t.PDF, t.Orgs)
+		return fmt.Sprintf("daily_report_%s.html", rep.date), "text/html; charset=utf-8", page, err
+	}
+	return "", "", nil, nil
+}
+
+// emailMessage builds the report email: the text report as the body, and
+// the csv, tsv, pdf or html attached for those formats
+func emailMessage(t target, rep *generatedReport, report string) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\nTo: %s\r\n", t.From, strings.Join(t.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\nMIME-Version: 1.0\r\n", mime.QEncoding.Encode("utf-8", revisionTitle(rep, "Daily report "+rep.header)))
+
+	name, contentType, data, err := emailAttachment(t, rep)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		fmt.Fprintf(&b, "Content-Type: text/plain; charset=utf-8\r\nContent-Transfer-Encoding: base64\r\n\r\n%s\r\n", base64Lines([]byte(report)))
+		return b.Bytes(), nil
+	}
+
+	w := multipart.NewWriter(&b)
+	fmt.Fprintf(&b, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", w.Boundary())
+	parts := []struct {
+		header textproto.MIMEHeader
+		data   []byte
+	}{
+		{textproto.MIMEHeader{"Content-Type": {"text/plain; charset=utf-8"}}, []byte(report)},
+		{textproto.MIMEHeader{"Content-Type": {contentType}, "Content-Disposition": {fmt.Sprintf("attachment; filename=%q", name)}}, data},
+	}
+	for _, p := range parts {
+		p.header.Set("Content-Transfer-Encoding", "base64")
+		part, err := w.CreatePart(p.header)
+		if err != nil {
+			return nil, err
+		}
+		part.Write([]byte(base64Lines(p.data)))
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// startTLSAndAuth upgrades the SMTP connection to TLS when the server offers
+// it, and signs in as SMTP_USERNAME (the sender by default) with the
+// target's token or SMTP_PASSWORD when set
+func startTLSAndAuth(c *smtp.Client, t target, host string) error {
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if password := pick(t.Token, "SMTP_PASSWORD", ""); password != "" {
+		return c.Auth(smtp.PlainAuth("", pick(os.Getenv("SMTP_USERNAME"), "", t.From), password, host))
+	}
+	return nil
+}
+
+// sendEmail mails the report through the target's SMTP server
+func sendEmail(ctx context.Context, t target, rep *generatedReport, report string) error {
+	message, err := emailMessage(t, rep, report)
+	if err != nil {
+		return err
+	}
+
+	host, _, err := net.SplitHostPort(t.SMTP)
+	if err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", t.SMTP)
+	if err != nil {
+		return err
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if err := startTLSAndAuth(c, t, host); err != nil {
+		return err
+	}
+	if err := c.Mail(t.From); err != nil {
+		return err
+	}
+	for _, to := range t.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// A review or comment left on a pull request
+type prFeedback struct {
+	State       string `json:"state"`
+	SubmittedAt string `json:"submitted_at"`
+	CreatedAt   string `json:"created_at"`
+	User        struct {
+		Login string `json:"login"`
+	} `json:"user"`
+}
+
+// Whether an RFC3339 timestamp falls on the given local date
+func onDate(timestamp, date string) bool {
+	t, err := time.Parse(time.RFC3339, timestamp)
+	return err == nil && t.Local().Format(dateFormat) == date
+}
+
+// getFeedback lists my pull requests that others reviewed or commented on
+// during the day, e.g. "Add login page | 2 comments, changes requested by alice"
+func getFeedback(ctx context.Context, date, username, token string) ([]reportLine, error) {
+	prs, err := searchIssues(ctx, fmt.Sprintf("type:pr author:%s updated:>=%s", username, date), token)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []reportLine
+	for _, pr := range prs {
+		var reviews, issueComments, reviewComments []prFeedback
+		base := fmt.Sprintf("%s/pulls/%d", pr.RepositoryURL, pr.Number)
+		if err := githubGet(ctx, base+"/reviews?per_page=100", token, &reviews); err != nil {
+			return nil, err
+		}
+		if err := githubGet(ctx, fmt.Sprintf("%s/issues/%d/comments?per_page=100&since=%sT00:00:00Z", pr.RepositoryURL, pr.Number, date), token, &issueComments); err != nil {
+			return nil, err
+		}
+		if err := githubGet(ctx, fmt.Sprintf("%s/comments?per_page=100&since=%sT00:00:00Z", base, date), token, &reviewComments); err != nil {
+			return nil, err
+		}
+
+		comments := 0
+		for _, c := range append(issueComments, reviewComments...) {
+			if c.User.Login != username && onDate(c.CreatedAt, date) {
+				comments++
+			}
+		}
+
+		var changesRequested, approved []string
+		for _, r := range reviews {
+			if r.User.Login == username || !onDate(r.SubmittedAt, date) {
+				continue
+			}
+			switch r.State {
+			case "CHANGES_REQUESTED":
+				changesRequested = append(changesRequested, r.User.Login)
+			case "APPROVED":
+				approved = append(approved, r.User.Login)
+			}
+		}
+
+		var parts []string
+		if comments == 1 {
+			parts = append(parts, "1 comment")
+		} else if comments > 1 {
+			parts = append(parts, fmt.Sprintf("%d comments", comments))
+		}
+		if len(changesRequested) > 0 {
+			parts = append(parts, "changes requested by "+strings.Join(changesRequested, ", "))
+		}
+		if len(approved) > 0 {
+			parts = append(parts, "approved by "+strings.Join(approved, ", "))
+		}
+
+		if len(parts) > 0 {
+			lines = append(lines, reportLine{text: fmt.Sprintf("%s | %s", pr.Title, strings.Join(parts, ", ")), repo: pr.repoName()})
+		}
+	}
+	return lines, nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const githubAPI = "https://api.github.com"
+
+// Returned, wrapped, by githubGet for 404 responses
+var errNotFound = errors.New("404 Not Found")
+
+// Retries of a request hitting a secondary rate limit before giving up
+const maxRateLimitRetries = 3
+
+// githubGet fetches a GitHub API URL and decodes the JSON response into v.
+// Responses are cached with their ETag, so an unchanged response is reused
+// from a 304 Not Modified, which doesn't count against the rate limit.
+func githubGet(ctx context.Context, url, token string, v interface{}) error {
+	cached, isCached := cachedGet(url, token)
+	if offline {
+		if !isCached {
+			return fmt.Errorf("GET %s: %w", url, errOffline)
+		}
+		noteCacheAge(ctx, cached.FetchedAt)
+		return parseJSON(cached.Body, v)
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return err
+		}
+
+		if auth := githubAuthorization(token); auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		req.Header.Set("Accept", "application/vnd.github+json")
+		if isCached {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			metrics.apiError()
+			return err
+		}
+
+		slog.Debug("GitHub request", "url", url, "status", resp.StatusCode,
+			"rate_limit_remaining", resp.Header.Get("X-RateLimit-Remaining"),
+			"rate_limit_reset", resp.Header.Get("X-RateLimit-Reset"))
+
+		if resp.StatusCode == http.StatusOK {
+			return decodeGitHubResponse(resp, url, token, v)
+		}
+		body, err := io.ReadAll(boundedBody(resp.Body))
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		// Wait out secondary rate limits and try again
+		if wait, limited := secondaryRateLimit(resp, body, attempt); limited && attempt < maxRateLimitRetries {
+			slog.Warn("GitHub secondary rate limit hit", "url", url, "retry_in", wait.Round(time.Second))
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			continue
+		}
+
+		if resp.StatusCode == http.StatusNotModified && isCached {
+			cachePut(url, token, cached.ETag, cached.Body)
+			return parseJSON(cached.Body, v)
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("GET %s: %w", url, errNotFound)
+		}
+		metrics.apiError()
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+}
+
+// decodeGitHubResponse decodes the response into v as it is read, keeping a
+// copy for the cache only when it can be revalidated
+func decodeGitHubResponse(resp *http.Response, url, token string, v interface{}) error {
+	defer resp.Body.Close()
+	etag := resp.Header.Get("ETag")
+	var body io.Reader = boundedBody(resp.Body)
+	var raw bytes.Buffer
+	if cacheDir != "" && etag != "" {
+		body = io.TeeReader(body, &raw)
+	}
+	if err := json.NewDecoder(body).Decode(v); err != nil {
+		return fmt.Errorf("GET %s: %w", url, err)
+	}
+	if raw.Len() > 0 {
+		cachePut(url, token, etag, raw.Bytes())
+	}
+	return nil
+}
+
+// secondaryRateLimit reports whether the response is a secondary rate limit
+// (abuse detection) error and how long to wait before retrying: Retry-After
+// when given, the rate limit reset when no requests remain, and otherwise a
+// minute doubling with each attempt, as GitHub asks. Up to a second of jitter
+// is added so concurrent requests don't all retry at the same time.
+func secondaryRateLimit(resp *http.Response, body []byte, attempt int) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	retryAfter := resp.Header.Get("Retry-After")
+	message := strings.ToLower(string(body))
+	abuse := strings.Contains(message, "secondary rate limit") || strings.Contains(message, "abuse")
+	if retryAfter == "" && !abuse {
+		return 0, false
+	}
+
+	wait := time.Minute << attempt
+	if seconds, err := strconv.Atoi(retryAfter); err == nil {
+		wait = time.Duration(seconds) * time.Second
+	} else if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			wait = time.Until(time.Unix(reset, 0))
+		}
+	}
+	return wait + time.Duration(rand.Int63n(int64(time.Second))), true
+}
+
+// An issue or pull request returned by the search API
+type searchIssue struct {
+	Number        int    `json:"number"`
+	Title         string `json:"title"`
+	HTMLURL       string `json:"html_url"`
+	RepositoryURL string `json:"repository_url"`
+	CreatedAt     string `json:"created_at"`
+	User          struct {
+		Login string `json:"login"`
+	} `json:"user"`
+}
+
+// searchIssues runs an issue search query, e.g. "type:pr author:octocat"
+func searchIssues(ctx context.Context, query, token string) ([]searchIssue, error) {
+	var result struct {
+		Items []searchIssue `json:"items"`
+	}
+	u := fmt.Sprintf("%s/search/issues?per_page=100&q=%s", githubAPI, url.QueryEscape(query))
+	if err := githubGet(ctx, u, token, &result); err != nil {
+		return nil, err
+	}
+	return result.Items, nil
+}
+
+// Repository of the issue, e.g. "octocat/hello-world"
+func (i searchIssue) repoName() string {
+	return strings.TrimPrefix(i.RepositoryURL, githubAPI+"/repos/")
+}
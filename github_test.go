@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeGitHub serves the events of users like api.github.com, per_page at a
+// time, and counts the requests
+type fakeGitHub struct {
+	events   map[string][]map[string]interface{}
+	requests atomic.Int32
+}
+
+func (f *fakeGitHub) serveEvents(w http.ResponseWriter, r *http.Request) {
+	f.requests.Add(1)
+	events, ok := f.events[r.PathValue("user")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+	page, perPage = max(page, 1), max(perPage, 30)
+	start := min((page-1)*perPage, len(events))
+	body, err := json.Marshal(events[start:min(start+perPage, len(events))])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	etag := fmt.Sprintf(`"%x"`, sha256.Sum256(body))
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+// redirectTransport sends the requests of the API client to the fake
+type redirectTransport struct {
+	to   *url.URL
+	next http.RoundTripper
+}
+
+func (t redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host = t.to.Scheme, t.to.Host
+	return t.next.RoundTrip(req)
+}
+
+// startFakeGitHub points the API client at a fake GitHub serving the
+// events, without the response cache, until the test ends
+func startFakeGitHub(t *testing.T, events map[string][]map[string]interface{}) *fakeGitHub {
+	t.Helper()
+	fake := &fakeGitHub{events: events}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{user}/events", fake.serveEvents)
+	srv := httptest.NewServer(mux)
+	to, _ := url.Parse(srv.URL)
+
+	transport, dir := httpClient.Transport, cacheDir
+	httpClient.Transport, cacheDir = redirectTransport{to: to, next: srv.Client().Transport}, ""
+	t.Cleanup(func() {
+		httpClient.Transport, cacheDir = transport, dir
+		srv.Close()
+	})
+	return fake
+}
+
+// recordedEvents reads the events of testdata/github, newest first as the
+// API lists them
+func recordedEvents(t *testing.T, file string) []map[string]interface{} {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "github", file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []map[string]interface{}
+	if err := json.Unmarshal(data, &events); err != nil {
+		t.Fatal(err)
+	}
+	return events
+}
+
+func eventIDs(events []map[string]interface{}) []string {
+	var ids []string
+	for _, event := range events {
+		ids = append(ids, fmt.Sprint(event["id"]))
+	}
+	return ids
+}
+
+func TestGetDailyEvents(t *testing.T) {
+	startFakeGitHub(t, map[string][]map[string]interface{}{"octocat": recordedEvents(t, "events_octocat.json")})
+
+	tests := []struct {
+		name  string
+		dates []string
+		want  []string
+	}{
+		{"one day", []string{"2024-05-10"}, []string{"2009", "2008", "2007", "2006", "2005", "2004", "2003"}},
+		{"the day before", []string{"2024-05-09"}, []string{"2002", "2001"}},
+		{"both days", []string{"2024-05-09", "2024-05-10"}, []string{"2009", "2008", "2007", "2006", "2005", "2004", "2003", "2002", "2001"}},
+		{"no events", []string{"2024-05-11"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := getDailyEvents(context.Background(), "octocat", "", tt.dates...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := eventIDs(events); !slices.Equal(got, tt.want) {
+				t.Errorf("getDailyEvents(%v) = %v, want %v", tt.dates, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetDailyEventsPages(t *testing.T) {
+	// An event every 10 minutes going back from the end of the day, 144 of
+	// them that day: the second page reaches the day before, the third
+	// isn't fetched
+	end := time.Date(2024, 5, 10, 23, 50, 0, 0, time.UTC)
+	var events []map[string]interface{}
+	for i := range 250 {
+		events = append(events, map[string]interface{}{
+			"id":         strconv.Itoa(i),
+			"type":       "PushEvent",
+			"created_at": end.Add(-10 * time.Minute * time.Duration(i)).Format(time.RFC3339),
+		})
+	}
+	fake := startFakeGitHub(t, map[string][]map[string]interface{}{"octocat": events})
+
+	got, err := getDailyEvents(context.Background(), "octocat", "", "2024-05-10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 144 {
+		t.Errorf("got %d events, want 144", len(got))
+	}
+	if n := fake.requests.Load(); n != 2 {
+		t.Errorf("fetched %d pages, want 2", n)
+	}
+}
+
+func TestGetDailyEventsUnknownUser(t *testing.T) {
+	startFakeGitHub(t, map[string][]map[string]interface{}{})
+
+	if _, err := getDailyEvents(context.Background(), "nobody", "", "2024-05-10"); !errors.Is(err, errNotFound) {
+		t.Errorf("getDailyEvents of an unknown user = %v, want %v", err, errNotFound)
+	}
+}
+
+func TestGitHubGetRevalidatesCache(t *testing.T) {
+	fake := startFakeGitHub(t, map[string][]map[string]interface{}{"octocat": recordedEvents(t, "events_octocat.json")})
+	cacheDir = t.TempDir()
+
+	u := githubAPI + "/users/octocat/events?per_page=100&page=1"
+	var first, second []map[string]interface{}
+	if err := githubGet(context.Background(), u, "", &first); err != nil {
+		t.Fatal(err)
+	}
+	cached, ok := cachedGet(u, "")
+	if !ok {
+		t.Fatal("response not cached")
+	}
+	if err := githubGet(context.Background(), u, "", &second); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(eventIDs(first), eventIDs(second)) {
+		t.Errorf("revalidated events = %v, want %v", eventIDs(second), eventIDs(first))
+	}
+	if again, _ := cachedGet(u, ""); again.ETag != cached.ETag {
+		t.Errorf("ETag after revalidation = %s, want %s", again.ETag, cached.ETag)
+	}
+	if n := fake.requests.Load(); n != 2 {
+		t.Errorf("sent %d requests, want 2", n)
+	}
+}
+
+func TestBuildEntriesFromGitHub(t *testing.T) {
+	startFakeGitHub(t, map[string][]map[string]interface{}{"octocat": recordedEvents(t, "events_octocat.json")})
+
+	type entry struct{ status, title string }
+	tests := []struct {
+		name string
+		date string
+		user string
+		want []entry
+	}{
+		{"opened, reviewed and merged the same day", "2024-05-10", "octocat", []entry{
+			{"Done", "Add greeting"},
+			{"Reviewed (commented)", "Bump dependencies"},
+			{"Requested changes", "Drop Windows support"},
+			{"Reviewed (approved)", "Fix typo"},
+			{"WIP", "Try a new layout"},
+		}},
+		{"closed unmerged", "2024-05-09", "octocat", []entry{
+			{"In Review", "Add README"},
+		}},
+		{"as the reviewer, merged since", "2024-05-10", "hubot", []entry{
+			{"Reviewed and merged", "Add greeting"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := getDailyEvents(context.Background(), "octocat", "", tt.date)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []entry
+			for _, e := range buildEntries(events, tt.user) {
+				got = append(got, entry{e.status, e.title})
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("buildEntries(%s, %s) = %v, want %v", tt.date, tt.user, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Installation tokens are refreshed this long before they expire
+const appTokenMargin = 5 * time.Minute
+
+// The installation token of the GitHub App, cached until it nearly expires
+var appToken struct {
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// githubAppConfigured reports whether GITHUB_APP_ID is set, to authenticate
+// as a GitHub App installation instead of with a personal access token
+func githubAppConfigured() bool {
+	return os.Getenv("GITHUB_APP_ID") != ""
+}
+
+// appPrivateKey reads the App's private key from GITHUB_APP_PRIVATE_KEY,
+// either the PEM itself or the path of the .pem file GitHub generated
+func appPrivateKey() (*rsa.PrivateKey, error) {
+	value := os.Getenv("GITHUB_APP_PRIVATE_KEY")
+	if value == "" {
+		return nil, errors.New("GITHUB_APP_PRIVATE_KEY is not set")
+	}
+	data := []byte(value)
+	if !strings.Contains(value, "-----BEGIN") {
+		var err error
+		if data, err = os.ReadFile(value); err != nil {
+			return nil, err
+		}
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("GITHUB_APP_PRIVATE_KEY: no PEM data")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("GITHUB_APP_PRIVATE_KEY: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("GITHUB_APP_PRIVATE_KEY: not an RSA key")
+	}
+	return key, nil
+}
+
+// appJWT signs the short-lived JWT the App authenticates with (RS256)
+func appJWT(appID string, key *rsa.PrivateKey, now time.Time) (string, error) {
+	enc := base64.RawURLEncoding
+	header := enc.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]interface{}{
+		// Backdated against clock drift, as GitHub recommends
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": appID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := header + "." + enc.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(nil, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + enc.EncodeToString(signature), nil
+}
+
+// githubAppToken returns an installation access token of the App, creating
+// a new one when the cached token is about to expire
+func githubAppToken(ctx context.Context) (string, error) {
+	appToken.mu.Lock()
+	defer appToken.mu.Unlock()
+	if appToken.token != "" && time.Until(appToken.expiresAt) > appTokenMargin {
+		return appToken.token, nil
+	}
+
+	installation, err := appInstallation()
+	if err != nil {
+		return "", err
+	}
+	key, err := appPrivateKey()
+	if err != nil {
+		return "", err
+	}
+	jwt, err := appJWT(os.Getenv("GITHUB_APP_ID"), key, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	headers := map[string]string{"Authorization": "Bearer " + jwt, "Accept": "application/vnd.github+json"}
+	url := fmt.Sprintf("%s/app/installations/%s/access_tokens", githubAPI, installation)
+	if err := sendJSON(ctx, "POST", url, headers, nil, &result); err != nil {
+		return "", fmt.Errorf("GitHub App installation token: %w", err)
+	}
+
+	appToken.token, appToken.expiresAt = result.Token, result.ExpiresAt
+	return result.Token, nil
+}
+
+// appInstallation returns GITHUB_APP_INSTALLATION_ID, or else the
+// installation the App's webhook last reported
+func appInstallation() (string, error) {
+	if installation := os.Getenv("GITHUB_APP_INSTALLATION_ID"); installation != "" {
+		return installation, nil
+	}
+	h, err := openHistory(historyPath())
+	if err != nil {
+		return "", err
+	}
+	defer h.Close()
+	var installation int64
+	err = h.db.QueryRow(`SELECT installation FROM app_repositories ORDER BY added_at DESC LIMIT 1`).Scan(&installation)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", errors.New("GITHUB_APP_INSTALLATION_ID is not set and no installation was received by webhook")
+	}
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(installation, 10), nil
+}
+
+// repoNames returns the full names of the repositories listed in an
+// installation payload
+func repoNames(repositories interface{}) []string {
+	list, _ := repositories.([]interface{})
+	var names []string
+	for _, r := range list {
+		repo, _ := r.(map[string]interface{})
+		if name, ok := repo["full_name"].(string); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// saveSubscriptions keeps track of the repositories the App is installed
+// on from a webhook delivery: installation and installation_repositories
+// events add and remove them, and any other delivery adds its repository,
+// as the App only receives the events of the repositories it's installed
+// on. It reports whether the delivery was about the installation itself.
+func (h *historyStore) saveSubscriptions(kind string, payload map[string]interface{}) (bool, error) {
+	installation, _ := payload["installation"].(map[string]interface{})
+	id, ok := installation["id"].(float64)
+	if !ok {
+		return false, nil
+	}
+
+	var added, removed []string
+	switch kind {
+	case "installation":
+		switch payload["action"] {
+		case "created", "unsuspend":
+			added = repoNames(payload["repositories"])
+		case "deleted", "suspend":
+			_, err := h.db.Exec(`DELETE FROM app_repositories WHERE installation = ?`, int64(id))
+			return true, err
+		}
+	case "installation_repositories":
+		added, removed = repoNames(payload["repositories_added"]), repoNames(payload["repositories_removed"])
+	default:
+		repository, _ := payload["repository"].(map[string]interface{})
+		if name, ok := repository["full_name"].(string); ok {
+			added = []string{name}
+		}
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	for _, repo := range added {
+		if _, err := h.db.Exec(`INSERT INTO app_repositories (repo, installation, added_at) VALUES (?, ?, ?)
+			ON CONFLICT (repo) DO UPDATE SET installation = excluded.installation`, repo, int64(id), now); err != nil {
+			return true, err
+		}
+	}
+	for _, repo := range removed {
+		if _, err := h.db.Exec(`DELETE FROM app_repositories WHERE repo = ?`, repo); err != nil {
+			return true, err
+		}
+	}
+	return kind == "installation" || kind == "installation_repositories", nil
+}
+
+// subscribedRepos returns the repositories the App's webhook reported it
+// is installed on, by name
+func (h *historyStore) subscribedRepos() ([]string, error) {
+	rows, err := h.db.Query(`SELECT repo FROM app_repositories ORDER BY repo`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var repos []string
+	for rows.Next() {
+		var repo string
+		if err := rows.Scan(&repo); err != nil {
+			return nil, err
+		}
+		repos = append(repos, repo)
+	}
+	return repos, rows.Err()
+}
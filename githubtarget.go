@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Title of the monthly thread of github targets when title isn't set
+const defaultThreadTitle = "Daily reports of {{.Username}}, {{.Year}}-{{.Month}}"
+
+// githubTargetToken returns the token github targets post with: the
+// target's token, GITHUB_TOKEN, or the GitHub App installation's
+func githubTargetToken(ctx context.Context, t target) (string, error) {
+	token := pick(t.Token, "GITHUB_TOKEN", "")
+	if token == "" && githubAppConfigured() {
+		return githubAppToken(ctx)
+	}
+	if token == "" {
+		return "", errors.New("no token, set token or GITHUB_TOKEN")
+	}
+	return token, nil
+}
+
+func githubTargetHeaders(token string) map[string]string {
+	return map[string]string{"Authorization": githubAuthorization(token), "Accept": "application/vnd.github+json"}
+}
+
+// threadTitle returns the title of the month's issue or discussion, e.g.
+// "Daily reports of octocat, 2024-05"
+func (t target) threadTitle(rep *generatedReport) (string, error) {
+	title := t.Title
+	if title == "" {
+		title = defaultThreadTitle
+	}
+	return expandPath(title, rep)
+}
+
+// githubComment is the body of the comment a report is posted as, later
+// revisions marked in the header line and tables in a code block
+func githubComment(t target, rep *generatedReport, report string) string {
+	if t.Format == formatCSV || t.Format == formatTSV {
+		return revisionTitle(rep, rep.header) + "\n```\n" + report + "```"
+	}
+	header, rest, _ := strings.Cut(report, "\n")
+	if strings.HasSuffix(header, ":") {
+		header = revisionTitle(rep, strings.TrimSuffix(header, ":")) + ":"
+	}
+	return header + "\n" + rest
+}
+
+// postToGitHub posts the report as a comment of the target's tracking
+// issue, or of the month's issue or discussion, created on the first report
+// of the month. A report replacing the day's earlier one edits its comment.
+func postToGitHub(ctx context.Context, t target, rep *generatedReport, report string) error {
+	token, err := githubTargetToken(ctx, t)
+	if err != nil {
+		return err
+	}
+	headers := githubTargetHeaders(token)
+	if t.Category != "" {
+		return postToDiscussion(ctx, t, headers, rep, githubComment(t, rep, report))
+	}
+
+	delivery := "github:" + t.Repo
+	repoAPI := githubAPI + "/repos/" + t.Repo
+	body := map[string]string{"body": githubComment(t, rep, report)}
+	if rep.replaces {
+		previous, err := deliveredRef(rep.date, delivery, "comment")
+		if err != nil {
+			return err
+		}
+		if previous != "" {
+			return sendJSON(ctx, "PATCH", repoAPI+"/issues/comments/"+previous, headers, body, nil)
+		}
+	}
+
+	issue := t.Issue
+	if issue == 0 {
+		if issue, err = monthlyIssue(ctx, t, token, rep); err != nil {
+			return err
+		}
+	}
+	var comment struct {
+		ID int64 `json:"id"`
+	}
+	if err := sendJSON(ctx, "POST", fmt.Sprintf("%s/issues/%d/comments", repoAPI, issue), headers, body, &comment); err != nil {
+		return err
+	}
+	return recordDelivery(rep.date, delivery, "comment", strconv.FormatInt(comment.ID, 10))
+}
+
+// monthlyIssue returns the number of the month's issue of a github target:
+// the one created by an earlier report of the month, an open issue with its
+// title, or a new one
+func monthlyIssue(ctx context.Context, t target, token string, rep *generatedReport) (int, error) {
+	delivery, month := "github:"+t.Repo, rep.date[:7]
+	if ref, err := deliveredRef(month, delivery, "issue"); err != nil || ref != "" {
+		number, _ := strconv.Atoi(ref)
+		return number, err
+	}
+	title, err := t.threadTitle(rep)
+	if err != nil {
+		return 0, err
+	}
+
+	var number int
+	issues, err := searchIssues(ctx, fmt.Sprintf("repo:%s is:issue is:open in:title %q", t.Repo, title), token)
+	if err != nil {
+		return 0, err
+	}
+	for _, issue := range issues {
+		if issue.Title == title {
+			number = issue.Number
+			break
+		}
+	}
+	if number == 0 {
+		var created struct {
+			Number int `json:"number"`
+		}
+		err := sendJSON(ctx, "POST", githubAPI+"/repos/"+t.Repo+"/issues", githubTargetHeaders(token), map[string]string{"title": title}, &created)
+		if err != nil {
+			return 0, err
+		}
+		number = created.Number
+	}
+	return number, recordDelivery(month, delivery, "issue", strconv.Itoa(number))
+}
+
+// githubGraphQL runs a GraphQL query of the GitHub API, decoding its data
+// into out
+func githubGraphQL(ctx context.Context, headers map[string]string, query string, variables map[string]interface{}, out interface{}) error {
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	err := sendJSON(ctx, "POST", githubAPI+"/graphql", headers, map[string]interface{}{"query": query, "variables": variables}, &result)
+	if err != nil {
+		return err
+	}
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("GitHub GraphQL: %s", result.Errors[0].Message)
+	}
+	if out == nil {
+		return nil
+	}
+	return parseJSON(result.Data, out)
+}
+
+// postToDiscussion posts the report as a comment of the month's discussion
+// in the target's category, created on the first report of the month
+func postToDiscussion(ctx context.Context, t target, headers map[string]string, rep *generatedReport, body string) error {
+	delivery := "github:" + t.Repo + ":" + t.Category
+	if rep.replaces {
+		previous, err := deliveredRef(rep.date, delivery, "comment")
+		if err != nil {
+			return err
+		}
+		if previous != "" {
+			return githubGraphQL(ctx, headers, `mutation($id: ID!, $body: String!) {
+				updateDiscussionComment(input: {commentId: $id, body: $body}) { comment { id } }
+			}`, map[string]interface{}{"id": previous, "body": body}, nil)
+		}
+	}
+
+	discussion, err := monthlyDiscussion(ctx, t, headers, rep)
+	if err != nil {
+		return err
+	}
+	var added struct {
+		AddDiscussionComment struct {
+			Comment struct {
+				ID string `json:"id"`
+			} `json:"comment"`
+		} `json:"addDiscussionComment"`
+	}
+	err = githubGraphQL(ctx, headers, `mutation($id: ID!, $body: String!) {
+		addDiscussionComment(input: {discussionId: $id, body: $body}) { comment { id } }
+	}`, map[string]interface{}{"id": discussion, "body": body}, &added)
+	if err != nil {
+		return err
+	}
+	return recordDelivery(rep.date, delivery, "comment", added.AddDiscussionComment.Comment.ID)
+}
+
+// monthlyDiscussion returns the ID of the month's discussion of a github
+// target: the one created by an earlier report of the month, a recent one
+// of the category with its title, or a new one
+func monthlyDiscussion(ctx context.Context, t target, headers map[string]string, rep *generatedReport) (string, error) {
+	delivery, month := "github:"+t.Repo+":"+t.Category, rep.date[:7]
+	if ref, err := deliveredRef(month, delivery, "discussion"); err != nil || ref != "" {
+		return ref, err
+	}
+	title, err := t.threadTitle(rep)
+	if err != nil {
+		return "", err
+	}
+	owner, name, _ := strings.Cut(t.Repo, "/")
+
+	var repo struct {
+		Repository struct {
+			ID         string `json:"id"`
+			Categories struct {
+				Nodes []struct {
+					ID   string `json:"id"`
+					Name string `json:"name"`
+				} `json:"nodes"`
+			} `json:"discussionCategories"`
+		} `json:"repository"`
+	}
+	err = githubGraphQL(ctx, headers, `query($owner: String!, $name: String!) {
+		repository(owner: $owner, name: $name) { id discussionCategories(first: 100) { nodes { id name } } }
+	}`, map[string]interface{}{"owner": owner, "name": name}, &repo)
+	if err != nil {
+		return "", err
+	}
+	var category string
+	for _, c := range repo.Repository.Categories.Nodes {
+		if strings.EqualFold(c.Name, t.Category) {
+			category = c.ID
+		}
+	}
+	if category == "" {
+		return "", fmt.Errorf("%s has no discussion category %q", t.Repo, t.Category)
+	}
+
+	var recent struct {
+		Repository struct {
+			Discussions struct {
+				Nodes []struct {
+					ID    string `json:"id"`
+					Title string `json:"title"`
+				} `json:"nodes"`
+			} `json:"discussions"`
+		} `json:"repository"`
+	}
+	err = githubGraphQL(ctx, headers, `query($owner: String!, $name: String!, $category: ID!) {
+		repository(owner: $owner, name: $name) {
+			discussions(first: 50, categoryId: $category, orderBy: {field: CREATED_AT, direction: DESC}) { nodes { id title } }
+		}
+	}`, map[string]interface{}{"owner": owner, "name": name, "category": category}, &recent)
+	if err != nil {
+		return "", err
+	}
+	var id string
+	for _, d := range recent.Repository.Discussions.Nodes {
+		if d.Title == title {
+			id = d.ID
+			break
+		}
+	}
+	if id == "" {
+		var created struct {
+			CreateDiscussion struct {
+				Discussion struct {
+					ID string `json:"id"`
+				} `json:"discussion"`
+			} `json:"createDiscussion"`
+		}
+		err := githubGraphQL(ctx, headers, `mutation($repo: ID!, $category: ID!, $title: String!) {
+			createDiscussion(input: {repositoryId: $repo, categoryId: $category, title: $title, body: $title}) { discussion { id } }
+		}`, map[string]interface{}{"repo": repo.Repository.ID, "category": category, "title": title}, &created)
+		if err != nil {
+			return "", err
+		}
+		id = created.CreateDiscussion.Discussion.ID
+	}
+	return id, recordDelivery(month, delivery, "discussion", id)
+}
+
+// checkGitHubTarget checks the token of a github target can see its
+// repository and, for discussions, that they are enabled
+func checkGitHubTarget(ctx context.Context, t target) error {
+	token, err := githubTargetToken(ctx, t)
+	if err != nil {
+		return err
+	}
+	var repo struct {
+		HasDiscussions bool `json:"has_discussions"`
+	}
+	if err := sendJSON(ctx, "GET", githubAPI+"/repos/"+t.Repo, githubTargetHeaders(token), nil, &repo); err != nil {
+		return err
+	}
+	if t.Category != "" && !repo.HasDiscussions {
+		return fmt.Errorf("discussions are not enabled on %s", t.Repo)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+// Commit message of git targets when message isn't set
+const defaultCommitMessage = "Daily report of {{.Username}} for {{.Date}}"
+
+// commitMessage returns the commit message of a git target, expanded like
+// report paths, e.g. "Daily report of octocat for 2024-05-10"
+func (t target) commitMessage(rep *generatedReport) (string, error) {
+	message := t.Message
+	if message == "" {
+		message = defaultCommitMessage
+	}
+	return expandPath(message, rep)
+}
+
+// gitAuth returns the credentials pushed and pulled with over HTTPS: the
+// target's token, or GIT_TOKEN. Without either, SSH remotes use the SSH
+// agent.
+func (t target) gitAuth() transport.AuthMethod {
+	token := pick(t.Token, "GIT_TOKEN", "")
+	if token == "" {
+		return nil
+	}
+	return &githttp.BasicAuth{Username: "x-access-token", Password: token}
+}
+
+// commitAuthor signs the report commits as the git user of the global git
+// config, or the GitHub user the report is about
+func commitAuthor(rep *generatedReport) *object.Signature {
+	name, email := rep.username, localAuthorEmail()
+	if cfg, err := gitconfig.LoadConfig(gitconfig.GlobalScope); err == nil && cfg.User.Name != "" {
+		name = cfg.User.Name
+	}
+	if email == "" {
+		email = rep.username + "@users.noreply.github.com"
+	}
+	return &object.Signature{Name: name, Email: email, When: time.Now()}
+}
+
+// commitToRepo writes the report to the path of a git target in its clone,
+// commits it and pushes. The clone is pulled first, as teammates push their
+// reports to the same repository. A report that didn't change since the
+// last delivery isn't committed again.
+func commitToRepo(ctx context.Context, t target, rep *generatedReport, report string) error {
+	repo, err := git.PlainOpen(t.Repo)
+	if err != nil {
+		return fmt.Errorf("%s: %w", t.Repo, err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	err = wt.PullContext(ctx, &git.PullOptions{Auth: t.gitAuth()})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) && !errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		return fmt.Errorf("pull: %w", err)
+	}
+
+	path, err := writeReportFile(t, rep, report, t.Repo)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(t.Repo, path)
+	if err != nil {
+		return err
+	}
+	if _, err := wt.Add(filepath.ToSlash(rel)); err != nil {
+		return err
+	}
+	status, err := wt.Status()
+	if err != nil {
+		return err
+	}
+	if file, changed := status[filepath.ToSlash(rel)]; !changed || file.Staging == git.Unmodified {
+		return nil
+	}
+
+	message, err := t.commitMessage(rep)
+	if err != nil {
+		return err
+	}
+	if _, err := wt.Commit(message, &git.CommitOptions{Author: commitAuthor(rep)}); err != nil {
+		return err
+	}
+	if err := repo.PushContext(ctx, &git.PushOptions{Auth: t.gitAuth()}); err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("push: %w", err)
+	}
+	return nil
+}
+
+// checkRepo checks the clone of a git target can be opened and written to
+func checkRepo(cfg *config, t target) error {
+	if _, err := git.PlainOpen(t.Repo); err != nil {
+		return fmt.Errorf("%s: %w", t.Repo, err)
+	}
+	return writableReportDir(cfg, filepath.Join(t.Repo, t.Path))
+}
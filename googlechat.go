@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/url"
+)
+
+// Card widget showing one line of text
+func chatParagraph(text string) map[string]interface{} {
+	return map[string]interface{}{"textParagraph": map[string]string{"text": html.EscapeString(text)}}
+}
+
+// googleChatCard lays the report out as a card with the incident fixes, one
+// section per status (Done, In Review, ...), then the extra sections and Next
+func googleChatCard(rep *generatedReport, orgs []string) map[string]interface{} {
+	incidents, entries := splitIncidents(filterEntries(rep.entries, orgs))
+
+	var statuses []string
+	byStatus := make(map[string][]map[string]interface{})
+	for _, entry := range append(append([]reportEntry{}, rep.meetings...), entries...) {
+		status := entry.statusText(rep.messages)
+		if _, ok := byStatus[status]; !ok {
+			statuses = append(statuses, status)
+		}
+		byStatus[status] = append(byStatus[status], chatParagraph(entry.title))
+	}
+
+	// Incident fixes come first
+	var sections []map[string]interface{}
+	if len(incidents) > 0 {
+		var widgets []map[string]interface{}
+		for _, entry := range incidents {
+			widgets = append(widgets, chatParagraph(fmt.Sprintf("[%s] %s | %s", entry.severity, entry.statusText(rep.messages), entry.title)))
+		}
+		sections = append(sections, map[string]interface{}{"header": rep.messages.text("Incidents"), "widgets": widgets})
+	}
+	for _, status := range statuses {
+		sections = append(sections, map[string]interface{}{"header": status, "widgets": byStatus[status]})
+	}
+
+	for _, section := range filterSections(rep.sections, orgs) {
+		if len(section.lines) == 0 {
+			continue
+		}
+		var widgets []map[string]interface{}
+		for _, line := range section.lines {
+			widgets = append(widgets, chatParagraph(line.text))
+		}
+		sections = append(sections, map[string]interface{}{"header": rep.messages.text(section.title), "widgets": widgets})
+	}
+
+	next := filterLines(rep.next, orgs)
+	if len(next) == 0 {
+		next = []reportLine{{text: rep.messages.text(defaultNext)}}
+	}
+	var widgets []map[string]interface{}
+	for _, item := range next {
+		widgets = append(widgets, chatParagraph(item.text))
+	}
+	sections = append(sections, map[string]interface{}{"header": rep.messages.text("Next"), "widgets": widgets})
+
+	return map[string]interface{}{
+		"cardsV2": []map[string]interface{}{{
+			"cardId": fmt.Sprintf("daily-report-%s", rep.date),
+			"card": map[string]interface{}{
+				"header":   chatHeader(rep),
+				"sections": sections,
+			},
+		}},
+	}
+}
+
+// Card header, saying which revision of the day's report it is
+func chatHeader(rep *generatedReport) map[string]string {
+	header := map[string]string{"title": rep.header}
+	switch {
+	case rep.replaces:
+		header["subtitle"] = rep.messages.text("Updated")
+	case rep.revision > 1:
+		header["subtitle"] = rep.messages.textf("Revision %d", rep.revision)
+	}
+	return header
+}
+
+// postToGoogleChat sends the report as a card to a Google Chat space
+// through its incoming webhook URL. Every report of a day goes to the same
+// thread, as webhooks can't edit the messages they posted.
+func postToGoogleChat(ctx context.Context, t target, rep *generatedReport) error {
+	u, err := url.Parse(t.URL)
+	if err != nil {
+		return err
+	}
+	query := u.Query()
+	query.Set("threadKey", "daily-report-"+rep.date)
+	query.Set("messageReplyOption", "REPLY_MESSAGE_FALLBACK_TO_NEW_THREAD")
+	u.RawQuery = query.Encode()
+	return sendJSON(ctx, "POST", u.String(), nil, googleChatCard(rep, t.Orgs), nil)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Size of a heatmap day cell and the gap between cells, in pixels
+const (
+	heatmapCell = 11
+	heatmapGap  = 2
+)
+
+// Room for the month labels above the cells and weekday labels left of
+// them, in the SVG heatmap
+const (
+	heatmapTop  = 16
+	heatmapLeft = 28
+)
+
+// Colors of the heatmap from no entries to the most, as on GitHub profiles
+var heatmapColors = []color.RGBA{
+	{0xeb, 0xed, 0xf0, 0xff},
+	{0x9b, 0xe9, 0xa8, 0xff},
+	{0x40, 0xc4, 0x63, 0xff},
+	{0x30, 0xa1, 0x4e, 0xff},
+	{0x21, 0x6e, 0x39, 0xff},
+}
+
+// activityHeatmap counts the report entries of each day, from the Sunday
+// starting the week of from to to, one column per week
+type activityHeatmap struct {
+	start, end time.Time
+	counts     map[string]int
+	max        int
+}
+
+// newActivityHeatmap counts the entries of the reports stored from the day
+// from to the day to
+func newActivityHeatmap(records []historyRecord, from, to time.Time) activityHeatmap {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.Local)
+	m := activityHeatmap{
+		start:  from.AddDate(0, 0, -int(from.Weekday())),
+		end:    time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.Local),
+		counts: make(map[string]int),
+	}
+	for _, r := range records {
+		if r.date < from.Format(dateFormat) || r.date > m.end.Format(dateFormat) {
+			continue
+		}
+		titles, _ := reportLines(r.report)
+		m.counts[r.date] = len(titles)
+		m.max = max(m.max, len(titles))
+	}
+	return m
+}
+
+// days calls fn with each day of the heatmap, its week column and weekday
+// row
+func (m activityHeatmap) days(fn func(day time.Time, week, weekday int)) {
+	for day, i := m.start, 0; !day.After(m.end); day, i = day.AddDate(0, 0, 1), i+1 {
+		fn(day, i/7, int(day.Weekday()))
+	}
+}
+
+// level returns the color of a day's count, relative to the busiest day
+func (m activityHeatmap) level(count int) int {
+	if count == 0 || m.max == 0 {
+		return 0
+	}
+	return min((count*4+m.max-1)/m.max, 4)
+}
+
+// weeks returns the number of week columns
+func (m activityHeatmap) weeks() int {
+	var weeks int
+	m.days(func(day time.Time, week, weekday int) { weeks = week + 1 })
+	return weeks
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// svg draws the heatmap with month and weekday labels, and the day's count
+// as the tooltip of each cell
+func (m activityHeatmap) svg() []byte {
+	step := heatmapCell + heatmapGap
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="9" fill="#57606a">`+"\n",
+		heatmapLeft+m.weeks()*step, heatmapTop+7*step)
+	for _, weekday := range []time.Weekday{time.Monday, time.Wednesday, time.Friday} {
+		fmt.Fprintf(&b, `<text x="0" y="%d">%s</text>`+"\n", heatmapTop+int(weekday)*step+heatmapCell-2, weekday.String()[:3])
+	}
+
+	m.days(func(day time.Time, week, weekday int) {
+		x, y := heatmapLeft+week*step, heatmapTop+weekday*step
+		// Months are labelled on the week of their first Sunday
+		if weekday == 0 && day.Day() <= 7 {
+			fmt.Fprintf(&b, `<text x="%d" y="%d">%s</text>`+"\n", x, heatmapTop-6, day.Format("Jan"))
+		}
+		count := m.counts[day.Format(dateFormat)]
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" rx="2" fill="%s"><title>%s: %d entries</title></rect>`+"\n",
+			x, y, heatmapCell, heatmapCell, hexColor(heatmapColors[m.level(count)]), day.Format(dateFormat), count)
+	})
+	b.WriteString("</svg>\n")
+	return b.Bytes()
+}
+
+// png draws the cells of the heatmap only, without labels
+func (m activityHeatmap) png() ([]byte, error) {
+	step := heatmapCell + heatmapGap
+	img := image.NewRGBA(image.Rect(0, 0, m.weeks()*step+heatmapGap, 7*step+heatmapGap))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	m.days(func(day time.Time, week, weekday int) {
+		x, y := heatmapGap+week*step, heatmapGap+weekday*step
+		cell := image.Rect(x, y, x+heatmapCell, y+heatmapCell)
+		c := heatmapColors[m.level(m.counts[day.Format(dateFormat)])]
+		draw.Draw(img, cell, &image.Uniform{c}, image.Point{}, draw.Src)
+	})
+
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// heatmap implements "heatmap [--months 12] [--out heatmap.svg]", writing
+// the entry counts per day of the stored reports as a contribution-style
+// heatmap, SVG or PNG by the extension of the file
+func heatmap(args []string) error {
+	fs := flag.NewFlagSet("heatmap", flag.ExitOnError)
+	months := fs.Int("months", 12, "months of history to draw")
+	out := fs.String("out", "heatmap.svg", "file to write, .svg or .png")
+	fs.Parse(args)
+
+	ext := strings.ToLower(filepath.Ext(*out))
+	if ext != ".svg" && ext != ".png" {
+		return fmt.Errorf("unsupported heatmap file %q, expected .svg or .png", *out)
+	}
+	if *months < 1 {
+		return fmt.Errorf("invalid months %d", *months)
+	}
+
+	h, err := openHistory(historyPath())
+	if err != nil {
+		return err
+	}
+	defer h.Close()
+	records, err := h.list()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	m := newActivityHeatmap(records, now.AddDate(0, -*months, 1), now)
+	data := m.svg()
+	if ext == ".png" {
+		if data, err = m.png(); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(*out, data, 0644)
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// An entry hidden from or restored to a day's report, kept as audit trail
+type entryChange struct {
+	entry     string
+	action    string
+	actor     string
+	changedAt time.Time
+}
+
+// recordChange stores that actor hid ("hide") or restored ("restore") the
+// entry with the given key
+func (h *historyStore) recordChange(date, key, action, actor string) error {
+	_, err := h.db.Exec(`INSERT INTO entry_changes (date, entry, action, actor, changed_at) VALUES (?, ?, ?, ?, ?)`,
+		date, key, action, actor, time.Now().Format(time.RFC3339))
+	return err
+}
+
+// entryChanges returns the day's audit trail, oldest first
+func (h *historyStore) entryChanges(date string) ([]entryChange, error) {
+	rows, err := h.db.Query(`SELECT entry, action, actor, changed_at FROM entry_changes WHERE date = ? ORDER BY id`, date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []entryChange
+	for rows.Next() {
+		var c entryChange
+		var changedAt string
+		if err := rows.Scan(&c.entry, &c.action, &c.actor, &changedAt); err != nil {
+			return nil, err
+		}
+		c.changedAt, _ = time.Parse(time.RFC3339, changedAt)
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+// hiddenEntries returns the keys of the entries whose last change was a hide
+func (h *historyStore) hiddenEntries(date string) (map[string]bool, error) {
+	changes, err := h.entryChanges(date)
+	if err != nil {
+		return nil, err
+	}
+	hidden := make(map[string]bool)
+	for _, c := range changes {
+		if c.action == "hide" || c.action == "restore" {
+			hidden[c.entry] = c.action == "hide"
+		}
+	}
+	return hidden, nil
+}
+
+// Load the keys of the entries hidden from the day's report
+func loadHiddenEntries(date string) (map[string]bool, error) {
+	h, err := openHistory(historyPath())
+	if err != nil {
+		return nil, err
+	}
+	defer h.Close()
+
+	return h.hiddenEntries(date)
+}
+
+// hide marks the entries with the given keys as hidden
+func (r *generatedReport) hide(keys map[string]bool) {
+	for i := range r.entries {
+		if keys[r.entries[i].key()] {
+			r.entries[i].hidden = true
+		}
+	}
+}
+
+// changeEntry implements "hide|restore [--date YYYY-MM-DD] <title or owner/repo#12>",
+// "privacy [--date YYYY-MM-DD] <public|team|private> <title or owner/repo#12>"
+// and "hours [--date YYYY-MM-DD] <hours> <title or owner/repo#12>".
+// The stored report of the day is re-rendered from its events like
+// --regenerate, and the report file, if any, rewritten when the day is
+// today, in the format of --format and at the path of the stored revision.
+func changeEntry(action string, args []string, format, columns string) error {
+	fs := flag.NewFlagSet(action, flag.ExitOnError)
+	date := fs.String("date", time.Now().Format(dateFormat), "day of the report the entry is in")
+	fs.Parse(args)
+	usage := fmt.Errorf("usage: %s [--date YYYY-MM-DD] <title or owner/repo#number>", action)
+	args = fs.Args()
+	if action == "privacy" {
+		usage = errors.New("usage: privacy [--date YYYY-MM-DD] <public|team|private> <title or owner/repo#number>")
+		if len(args) > 0 {
+			// The change recorded is the level itself
+			if err := validPrivacy(args[0]); err != nil {
+				return err
+			}
+			action, args = args[0], args[1:]
+		}
+	}
+	var hours float64
+	if action == "hours" {
+		usage = errors.New("usage: hours [--date YYYY-MM-DD] <hours> <title or owner/repo#number>")
+		if len(args) > 0 {
+			var err error
+			if hours, err = parseHours(args[0]); err != nil {
+				return err
+			}
+			action, args = hoursChange+strconv.FormatFloat(hours, 'f', -1, 64), args[1:]
+		}
+	}
+	if len(args) == 0 {
+		return usage
+	}
+	name := strings.Join(args, " ")
+
+	cfg, err := loadConfig(configPath())
+	if err != nil {
+		return err
+	}
+	output := cfg.reportFileTarget(format, columns)
+	if err := validFormat(output.Format, output.Columns); err != nil {
+		return err
+	}
+	rep, err := regenerateReport(cfg, reportOptions{}, *date)
+	if err != nil {
+		return err
+	}
+
+	var entry *reportEntry
+	for i := range rep.entries {
+		if rep.entries[i].key() == name || rep.entries[i].title == name {
+			entry = &rep.entries[i]
+		}
+	}
+	switch {
+	case entry == nil:
+		return fmt.Errorf("no entry %q in the report of %s", name, *date)
+	case action == "hide" && entry.hidden:
+		return fmt.Errorf("%q is already hidden", name)
+	case action == "restore" && !entry.hidden:
+		return fmt.Errorf("%q is not hidden", name)
+	case entry.manualHours != nil && *entry.manualHours == hours && strings.HasPrefix(action, hoursChange):
+		return fmt.Errorf("%q is already at %s", name, formatHours(hours))
+	case entry.privacy == action:
+		return fmt.Errorf("%q is already %s", name, action)
+	}
+
+	h, err := openHistory(historyPath())
+	if err != nil {
+		return err
+	}
+	defer h.Close()
+
+	if err := h.recordChange(*date, entry.key(), action, cfg.GitHub.Username); err != nil {
+		return err
+	}
+
+	// Store the report with the change applied
+	switch {
+	case action == "hide" || action == "restore":
+		entry.hidden = action == "hide"
+	case strings.HasPrefix(action, hoursChange):
+		entry.manualHours = &hours
+	default:
+		entry.privacy = action
+	}
+	report := rep.render(nil)
+	if err := h.save(*date, rep.events, report); err != nil {
+		return err
+	}
+	if *date != time.Now().Format(dateFormat) || cfg.ReportFile == "" {
+		return nil
+	}
+	if rep.revision, err = h.storedRevision(*date); err != nil {
+		return err
+	}
+	rep.replaces = true
+	return deliverReport(context.Background(), cfg, rep, output, report)
+}
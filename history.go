@@ -0,0 +1,393 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Default location of the history database, overridable with HISTORY_DB
+const defaultHistoryDB = "history.db"
+
+const historySchema = `
+CREATE TABLE IF NOT EXISTS reports (
+	date       TEXT PRIMARY KEY,
+	events     TEXT NOT NULL,
+	report     TEXT NOT NULL,
+	created_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS report_revisions (
+	date       TEXT NOT NULL,
+	revision   INTEGER NOT NULL,
+	events     TEXT NOT NULL,
+	report     TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	PRIMARY KEY (date, revision)
+);
+
+CREATE TABLE IF NOT EXISTS deliveries (
+	date   TEXT NOT NULL,
+	target TEXT NOT NULL,
+	item   TEXT NOT NULL,
+	ref    TEXT NOT NULL,
+	PRIMARY KEY (date, target, item)
+);
+
+CREATE TABLE IF NOT EXISTS webhook_events (
+	id          TEXT PRIMARY KEY,
+	date        TEXT NOT NULL,
+	event       TEXT NOT NULL,
+	received_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS app_repositories (
+	repo         TEXT PRIMARY KEY,
+	installation INTEGER NOT NULL,
+	added_at     TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS manual_entries (
+	id         INTEGER PRIMARY KEY,
+	date       TEXT NOT NULL,
+	status     TEXT NOT NULL,
+	title      TEXT NOT NULL,
+	created_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS blockers (
+	id       INTEGER PRIMARY KEY,
+	text     TEXT NOT NULL,
+	raised   TEXT NOT NULL,
+	resolved TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS entry_changes (
+	id         INTEGER PRIMARY KEY,
+	date       TEXT NOT NULL,
+	entry      TEXT NOT NULL,
+	action     TEXT NOT NULL,
+	actor      TEXT NOT NULL,
+	changed_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS digest_checkpoints (
+	date       TEXT NOT NULL,
+	login      TEXT NOT NULL,
+	events     TEXT NOT NULL,
+	fetched_at TEXT NOT NULL,
+	PRIMARY KEY (date, login)
+);
+
+CREATE TABLE IF NOT EXISTS member_tokens (
+	login      TEXT NOT NULL,
+	source     TEXT NOT NULL,
+	nonce      BLOB NOT NULL,
+	ciphertext BLOB NOT NULL,
+	PRIMARY KEY (login, source)
+);`
+
+// historyStore keeps each day's raw events and generated report
+type historyStore struct {
+	db *sql.DB
+}
+
+// historyRecord is one stored day
+type historyRecord struct {
+	date      string
+	events    []map[string]interface{}
+	report    string
+	createdAt time.Time
+}
+
+// historyPath returns the history database, one per profile
+func historyPath() string {
+	if path := os.Getenv("HISTORY_DB"); path != "" {
+		return profilePath(path)
+	}
+	return profilePath(defaultHistoryDB)
+}
+
+func openHistory(path string) (*historyStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(historySchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &historyStore{db: db}, nil
+}
+
+func (h *historyStore) Close() error {
+	return h.db.Close()
+}
+
+// save stores the day's events and report, replacing an earlier run that day
+func (h *historyStore) save(date string, events []map[string]interface{}, report string) error {
+	if events == nil {
+		events = []map[string]interface{}{}
+	}
+	data, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+
+	_, err = h.db.Exec(`INSERT INTO reports (date, events, report, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (date) DO UPDATE SET events = excluded.events, report = excluded.report, created_at = excluded.created_at`,
+		date, string(data), report, time.Now().Format(time.RFC3339))
+	return err
+}
+
+// list returns every stored day, newest first, without the raw events
+func (h *historyStore) list() ([]historyRecord, error) {
+	rows, err := h.db.Query(`SELECT date, report, created_at FROM reports ORDER BY date DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []historyRecord
+	for rows.Next() {
+		var r historyRecord
+		var createdAt string
+		if err := rows.Scan(&r.date, &r.report, &createdAt); err != nil {
+			return nil, err
+		}
+		r.createdAt, _ = time.Parse(time.RFC3339, createdAt)
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// get returns the stored day, or an error if nothing was stored for it
+func (h *historyStore) get(date string) (historyRecord, error) {
+	r := historyRecord{date: date}
+	var events, createdAt string
+	err := h.db.QueryRow(`SELECT events, report, created_at FROM reports WHERE date = ?`, date).
+		Scan(&events, &r.report, &createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return r, fmt.Errorf("no report stored for %s", date)
+	}
+	if err != nil {
+		return r, err
+	}
+
+	r.createdAt, _ = time.Parse(time.RFC3339, createdAt)
+	return r, parseJSON([]byte(events), &r.events)
+}
+
+// addManual stores an entry added by hand to the day's report
+func (h *historyStore) addManual(date string, entry reportEntry) error {
+	_, err := h.db.Exec(`INSERT INTO manual_entries (date, status, title, created_at) VALUES (?, ?, ?, ?)`,
+		date, entry.status, entry.title, time.Now().Format(time.RFC3339))
+	return err
+}
+
+// manual returns the entries added by hand to the day's report
+func (h *historyStore) manual(date string) ([]reportEntry, error) {
+	rows, err := h.db.Query(`SELECT status, title FROM manual_entries WHERE date = ? ORDER BY id`, date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []reportEntry
+	for rows.Next() {
+		var e reportEntry
+		if err := rows.Scan(&e.status, &e.title); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// Load the entries added by hand to the day's report
+func loadManualEntries(date string) ([]reportEntry, error) {
+	h, err := openHistory(historyPath())
+	if err != nil {
+		return nil, err
+	}
+	defer h.Close()
+
+	return h.manual(date)
+}
+
+// Parse a manual entry written like a report line, "In Review | Title".
+// Without a status the entry is Done.
+func parseManualEntry(line string) (reportEntry, error) {
+	status, title, ok := strings.Cut(line, "|")
+	if !ok {
+		status, title = "Done", line
+	}
+	entry := reportEntry{status: strings.TrimSpace(status), title: strings.TrimSpace(title)}
+	if entry.status == "" || entry.title == "" {
+		return entry, fmt.Errorf("invalid entry %q, expected \"Status | Title\"", line)
+	}
+	return entry, nil
+}
+
+// addEntry implements "add [--date YYYY-MM-DD] <Status | Title>"
+func addEntry(args []string) error {
+	fs := flag.NewFlagSet("add", flag.ExitOnError)
+	date := fs.String("date", time.Now().Format(dateFormat), "day of the report the entry is added to")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return errors.New("usage: add [--date YYYY-MM-DD] \"Status | Title\"")
+	}
+
+	entry, err := parseManualEntry(strings.Join(fs.Args(), " "))
+	if err != nil {
+		return err
+	}
+	if _, err := time.Parse(dateFormat, *date); err != nil {
+		return fmt.Errorf("invalid date %q, expected YYYY-MM-DD", *date)
+	}
+
+	h, err := openHistory(historyPath())
+	if err != nil {
+		return err
+	}
+	defer h.Close()
+
+	return h.addManual(*date, entry)
+}
+
+// Store the day's events and report in the history database, keeping the
+// report stored before as an earlier revision when this is a new one
+func recordHistory(rep *generatedReport, report string) error {
+	h, err := openHistory(historyPath())
+	if err != nil {
+		return err
+	}
+	defer h.Close()
+
+	if rep.revision > 1 && !rep.replaces {
+		if err := h.archive(rep.date, rep.revision-1); err != nil {
+			return err
+		}
+	}
+	return h.save(rep.date, rep.events, report)
+}
+
+// regenerateReport re-renders a stored day's events with the current
+// formatting and status rules, without calling the API
+func regenerateReport(cfg *config, opts reportOptions, date string) (*generatedReport, error) {
+	day, err := time.ParseInLocation(dateFormat, date, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", date)
+	}
+
+	h, err := openHistory(historyPath())
+	if err != nil {
+		return nil, err
+	}
+	defer h.Close()
+
+	r, err := h.get(date)
+	if err != nil {
+		return nil, err
+	}
+	return h.renderStored(cfg, opts, day, r.events)
+}
+
+// renderStored renders events of a stored day with the day's manual
+// entries and blockers, and the entries hidden and tagged since it was
+// generated
+func (h *historyStore) renderStored(cfg *config, opts reportOptions, day time.Time, events []map[string]interface{}) (*generatedReport, error) {
+	date := day.Format(dateFormat)
+	manual, err := h.manual(date)
+	if err != nil {
+		return nil, err
+	}
+
+	hidden, err := h.hiddenEntries(date)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := h.privacyTags(date)
+	if err != nil {
+		return nil, err
+	}
+	hours, err := h.hourOverrides(date)
+	if err != nil {
+		return nil, err
+	}
+	blockers, err := h.openBlockers(date)
+	if err != nil {
+		return nil, err
+	}
+
+	sections := []reportSection{blockersSection(blockers, date, cfg.messages())}
+	rep := renderReport(cfg, opts, day, events, manual, sections, nil)
+	rep.hide(hidden)
+	rep.tagPrivacy(tags)
+	rep.setHours(hours)
+	return rep, nil
+}
+
+// history implements the "history list", "history show <date>",
+// "history revisions <date>", "history audit <date>" and "history web"
+// commands
+func history(args []string) error {
+	h, err := openHistory(historyPath())
+	if err != nil {
+		return err
+	}
+	defer h.Close()
+
+	switch {
+	case len(args) == 1 && args[0] == "list":
+		records, err := h.list()
+		if err != nil {
+			return err
+		}
+		for _, r := range records {
+			fmt.Printf("%s  generated %s\n", r.date, r.createdAt.Local().Format(time.RFC1123))
+		}
+		return nil
+
+	case len(args) == 2 && args[0] == "show":
+		r, err := h.get(args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Println(r.report)
+		return nil
+
+	case len(args) == 2 && args[0] == "revisions":
+		revisions, err := h.revisions(args[1])
+		if err != nil {
+			return err
+		}
+		for _, r := range revisions {
+			fmt.Printf("Revision %d, generated %s:\n%s\n", r.revision, r.createdAt.Local().Format(time.RFC1123), r.report)
+		}
+		return nil
+
+	case len(args) == 2 && args[0] == "audit":
+		changes, err := h.entryChanges(args[1])
+		if err != nil {
+			return err
+		}
+		for _, c := range changes {
+			fmt.Printf("%s  %-7s %-10s %s\n", c.changedAt.Local().Format(time.RFC1123), c.action, c.actor, c.entry)
+		}
+		return nil
+
+	case len(args) >= 1 && args[0] == "web":
+		return historyWeb(h, args[1:])
+
+	default:
+		return errors.New("usage: history list | history show <YYYY-MM-DD> | history revisions <YYYY-MM-DD> | history audit <YYYY-MM-DD> | history web [--addr localhost:8091]")
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// hookConfig lists shell commands run around each delivery. They get the
+// report as JSON on stdin; a pre-delivery hook failing cancels the delivery.
+type hookConfig struct {
+	PreDelivery  []string `json:"pre_delivery,omitempty"`
+	PostDelivery []string `json:"post_delivery,omitempty"`
+}
+
+// runHooks runs the commands of a stage ("pre_delivery" or "post_delivery")
+// one after the other, stopping at the first that fails
+func runHooks(ctx context.Context, stage string, commands []string, rep *generatedReport, report string) error {
+	if len(commands) == 0 {
+		return nil
+	}
+
+	doc := rep.document()
+	doc.Report = report
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	for _, command := range commands {
+		cmd := shellCommand(ctx, command)
+		cmd.Stdin = bytes.NewReader(data)
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		cmd.Env = append(os.Environ(), "REPORT_HOOK="+stage, "REPORT_DATE="+rep.date)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s hook %q: %w", stage, command, err)
+		}
+	}
+	return nil
+}
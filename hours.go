@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Time spent estimates are rounded up to quarter hours, at least one
+const hoursStep = 0.25
+
+// Prefix of the entry changes setting the hours of an entry by hand, e.g.
+// "hours=1.5"
+const hoursChange = "hours="
+
+// hours returns the time spent on the entry in hours: set by hand, tracked
+// in Toggl or Clockify, or estimated from the first to the last of its events
+// of the day. It is 0 when unknown, for meetings and entries added by hand.
+func (e reportEntry) hours() float64 {
+	if e.manualHours != nil {
+		return *e.manualHours
+	}
+	if e.trackedHours > 0 {
+		return roundHours(e.trackedHours)
+	}
+	if e.started.IsZero() {
+		return 0
+	}
+	return roundHours(e.ended.Sub(e.started).Hours())
+}
+
+// roundHours rounds hours up to quarter hours, at least one
+func roundHours(hours float64) float64 {
+	return max(math.Ceil(hours/hoursStep)*hoursStep, hoursStep)
+}
+
+// formatHours writes hours without trailing zeros, e.g. "1.5h", empty when
+// unknown
+func formatHours(hours float64) string {
+	if hours == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(hours, 'f', -1, 64) + "h"
+}
+
+// spanEvent widens the times of the entry to include the event's
+func (e *reportEntry) spanEvent(event map[string]interface{}) {
+	createdAt, _ := event["created_at"].(string)
+	t, err := time.Parse(time.RFC3339, createdAt)
+	if err != nil {
+		return
+	}
+	if e.started.IsZero() || t.Before(e.started) {
+		e.started = t
+	}
+	if t.After(e.ended) {
+		e.ended = t
+	}
+}
+
+// withHours returns the entries with the time spent after their titles, e.g.
+// "Add CSV export (1.5h)"
+func withHours(entries []reportEntry) []reportEntry {
+	with := make([]reportEntry, len(entries))
+	for i, entry := range entries {
+		if hours := formatHours(entry.hours()); hours != "" {
+			entry.title = fmt.Sprintf("%s (%s)", entry.title, hours)
+		}
+		with[i] = entry
+	}
+	return with
+}
+
+// parseHours parses the hours given to "hours", e.g. "1.5"
+func parseHours(value string) (float64, error) {
+	hours, err := strconv.ParseFloat(strings.TrimSuffix(value, "h"), 64)
+	if err != nil || hours < 0 || hours > 24 {
+		return 0, fmt.Errorf("invalid hours %q, expected a number from 0 to 24, e.g. 1.5", value)
+	}
+	return hours, nil
+}
+
+// hourOverrides returns the hours set by hand for the entries of the day
+func (h *historyStore) hourOverrides(date string) (map[string]float64, error) {
+	changes, err := h.entryChanges(date)
+	if err != nil {
+		return nil, err
+	}
+	overrides := make(map[string]float64)
+	for _, c := range changes {
+		if value, ok := strings.CutPrefix(c.action, hoursChange); ok {
+			if hours, err := parseHours(value); err == nil {
+				overrides[c.entry] = hours
+			}
+		}
+	}
+	return overrides, nil
+}
+
+// Load the hours set by hand for the entries of the day's report
+func loadHourOverrides(date string) (map[string]float64, error) {
+	h, err := openHistory(historyPath())
+	if err != nil {
+		return nil, err
+	}
+	defer h.Close()
+
+	return h.hourOverrides(date)
+}
+
+// setHours overrides the time spent on the entries with the given keys
+func (r *generatedReport) setHours(overrides map[string]float64) {
+	for i := range r.entries {
+		if hours, ok := overrides[r.entries[i].key()]; ok {
+			r.entries[i].manualHours = &hours
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	htmltemplate "html/template"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+// Theme of html reports without one
+const defaultTheme = "plain"
+
+// theme returns the CSS of the report: the theme's, built in or an asset
+// override such as ~/.config/daily-reporting/themes/acme.css, then the css
+// file of the config
+func (c pdfConfig) theme() (string, error) {
+	name := c.Theme
+	if name == "" {
+		name = defaultTheme
+	}
+	css, err := readAsset("themes/" + name + ".css")
+	if err != nil {
+		return "", fmt.Errorf("theme %q: %w", name, err)
+	}
+	if c.CSS != "" {
+		own, err := os.ReadFile(c.CSS)
+		if err != nil {
+			return "", err
+		}
+		css = append(append(css, '\n'), own...)
+	}
+	return string(css), nil
+}
+
+// Accent color of a theme, e.g. "--accent: #1f4e79;"; the last one wins, so
+// the css file overrides the theme's
+var accentColor = regexp.MustCompile(`--accent:\s*#([0-9a-fA-F]{6})\b`)
+
+// accent returns the accent color of the theme of pdf reports, ok false
+// without theme or css file, keeping the default layout
+func (c pdfConfig) accent() (rgb [3]int, ok bool) {
+	if c.Theme == "" && c.CSS == "" {
+		return rgb, false
+	}
+	css, err := c.theme()
+	if err != nil {
+		return rgb, false
+	}
+	matches := accentColor.FindAllStringSubmatch(css, -1)
+	if len(matches) == 0 {
+		return rgb, false
+	}
+	hex := matches[len(matches)-1][1]
+	for i := range rgb {
+		value, _ := strconv.ParseUint(hex[2*i:2*i+2], 16, 8)
+		rgb[i] = int(value)
+	}
+	return rgb, true
+}
+
+// logoURL returns the logo as a data URL, so the html report shows it
+// wherever it is opened
+func (c pdfConfig) logoURL() (htmltemplate.URL, error) {
+	if c.Logo == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(c.Logo)
+	if err != nil {
+		return "", err
+	}
+	return htmltemplate.URL("data:" + http.DetectContentType(data) + ";base64," + base64.StdEncoding.EncodeToString(data)), nil
+}
+
+// A row of the task table of html reports
+type htmlRow struct {
+	Status, Title, Repo, Severity, URL string
+}
+
+// A section of html reports
+type htmlSection struct {
+	Title string
+	Lines []string
+}
+
+// renderHTML lays the report out as a standalone HTML page styled by the
+// theme: the company header with the logo, the date, a table of the meetings
+// and entries, then the sections and Next, as the PDF does
+func (r *generatedReport) renderHTML(c pdfConfig, orgs []string) ([]byte, error) {
+	css, err := c.theme()
+	if err != nil {
+		return nil, err
+	}
+	logo, err := c.logoURL()
+	if err != nil {
+		return nil, err
+	}
+	pageHTML, err := readAsset("web/report.html")
+	if err != nil {
+		return nil, err
+	}
+	page, err := htmltemplate.New("report").Parse(string(pageHTML))
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []htmlRow
+	incidents, entries := splitIncidents(filterEntries(r.entries, orgs))
+	for _, entry := range incidents {
+		rows = append(rows, htmlRow{Status: entry.statusText(r.messages), Title: entry.title, Repo: entry.repo, Severity: entry.severity, URL: entry.url()})
+	}
+	for _, entry := range append(append([]reportEntry{}, r.meetings...), entries...) {
+		rows = append(rows, htmlRow{Status: entry.statusText(r.messages), Title: entry.title, Repo: entry.repo, URL: entry.url()})
+	}
+
+	next := filterLines(r.next, orgs)
+	if len(next) == 0 {
+		next = []reportLine{{text: r.messages.text(defaultNext)}}
+	}
+	var sections []htmlSection
+	for _, section := range append(filterSections(r.sections, orgs), reportSection{title: "Next", lines: next}) {
+		if len(section.lines) == 0 {
+			continue
+		}
+		s := htmlSection{Title: r.messages.text(section.title)}
+		for _, line := range section.lines {
+			s.Lines = append(s.Lines, line.text)
+		}
+		sections = append(sections, s)
+	}
+
+	var b bytes.Buffer
+	err = page.Execute(&b, map[string]interface{}{
+		"Title":   r.messages.textf("Daily report, %s", r.header),
+		"CSS":     htmltemplate.CSS(css),
+		"Company": c.Company,
+		"Logo":    logo,
+		"Headings": map[string]string{
+			"Status":     r.messages.text("Status"),
+			"Task":       r.messages.text("Task"),
+			"Repository": r.messages.text("Repository"),
+			"Signature":  r.messages.text("Signature"),
+			"Date":       r.messages.text("Date"),
+		},
+		"Rows":      rows,
+		"Sections":  sections,
+		"Signature": c.Signature,
+	})
+	return b.Bytes(), err
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// catalog translates the fixed texts of the report: status labels, section
+// headings and headers. English texts are the keys; a nil catalog is English.
+type catalog map[string]string
+
+// text returns the translation of msg, msg itself when there is none
+func (c catalog) text(msg string) string {
+	if translated, ok := c[msg]; ok {
+		return translated
+	}
+	return msg
+}
+
+// textf translates a format string, e.g. "Week of %s", and formats it
+func (c catalog) textf(format string, args ...interface{}) string {
+	return fmt.Sprintf(c.text(format), args...)
+}
+
+// A language of the report, read from locales/<language>.json: its default
+// date header layout, month and weekday names in time.Month and
+// time.Weekday order, and message catalog
+type locale struct {
+	DateFormat    string     `json:"date_format"`
+	Months        [12]string `json:"months"`
+	ShortMonths   [12]string `json:"short_months"`
+	Weekdays      [7]string  `json:"weekdays"`
+	ShortWeekdays [7]string  `json:"short_weekdays"`
+	Messages      catalog    `json:"messages"`
+}
+
+// locales returns the languages available, built in or added to the asset
+// directories, loaded once. English is the language of the keys and needs
+// no file.
+var locales = sync.OnceValue(func() map[string]*locale {
+	loaded := make(map[string]*locale)
+	for _, name := range assetNames("locales") {
+		language, ok := strings.CutSuffix(name, ".json")
+		if !ok {
+			continue
+		}
+		data, err := readAsset("locales/" + name)
+		if err == nil {
+			var l locale
+			if err = parseJSON(data, &l); err == nil {
+				loaded[language] = &l
+				continue
+			}
+		}
+		slog.Warn("Skipped locale", "file", name, "err", err)
+	}
+	return loaded
+})
+
+// language of the report, from "language" or the older "locale"
+func (cfg *config) language() string {
+	if cfg.Language != "" {
+		return cfg.Language
+	}
+	return cfg.Locale
+}
+
+// messages returns the catalog of the report language, nil for English
+func (cfg *config) messages() catalog {
+	if l, ok := locales()[cfg.language()]; ok {
+		return l.Messages
+	}
+	return nil
+}
+
+// formatHeaderDate formats a date of a report header in the configured
+// layout, or the default one of the language, with its month and weekday
+// names
+func (cfg *config) formatHeaderDate(t time.Time) string {
+	layout := cfg.DateFormat
+	if l, ok := locales()[cfg.language()]; ok && layout == "" {
+		layout = l.DateFormat
+	}
+	return formatDate(t, layout, cfg.language())
+}
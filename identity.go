@@ -0,0 +1,64 @@
+package main
+
+import (
+	"slices"
+	"strings"
+)
+
+// machineUser identifies the events of a machine user, such as the CI
+// account approving pull requests, which are never attributed to me. An
+// event is the machine user's when it matches all of the rule's patterns.
+type machineUser struct {
+	// Login of the machine user, e.g. "acme-ci"
+	Login string `json:"login,omitempty"`
+	// Part of the body of the reviews and comments it posts, ignoring
+	// case, e.g. "approved by CI", for when it posts them with my token
+	Body string `json:"body,omitempty"`
+}
+
+func (m machineUser) matches(event map[string]interface{}) bool {
+	if m.Login != "" && !slices.ContainsFunc(eventActors(event), func(login string) bool { return strings.EqualFold(login, m.Login) }) {
+		return false
+	}
+	return m.Body == "" || strings.Contains(strings.ToLower(eventBody(event)), strings.ToLower(m.Body))
+}
+
+// eventActors returns who did the event and who posted its review or
+// comment, unlike eventAuthors leaving out the author of the pull request
+func eventActors(event map[string]interface{}) []string {
+	actor, _ := event["actor"].(map[string]interface{})
+	login, _ := actor["login"].(string)
+	actors := []string{login}
+	payload, _ := event["payload"].(map[string]interface{})
+	for _, key := range []string{"review", "comment"} {
+		item, _ := payload[key].(map[string]interface{})
+		user, _ := item["user"].(map[string]interface{})
+		if poster, ok := user["login"].(string); ok {
+			actors = append(actors, poster)
+		}
+	}
+	return actors
+}
+
+// eventBody returns the body of the review or comment of the event
+func eventBody(event map[string]interface{}) string {
+	payload, _ := event["payload"].(map[string]interface{})
+	for _, key := range []string{"review", "comment"} {
+		item, _ := payload[key].(map[string]interface{})
+		if body, ok := item["body"].(string); ok {
+			return body
+		}
+	}
+	return ""
+}
+
+// machineUserOf returns the rule of the machine user who did the event, if
+// any
+func (cfg *config) machineUserOf(event map[string]interface{}) (machineUser, bool) {
+	for _, m := range cfg.MachineUsers {
+		if m.matches(event) {
+			return m, true
+		}
+	}
+	return machineUser{}, false
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ignoreConfig leaves automated activity out of the reports
+type ignoreConfig struct {
+	// Leave out what accounts ending in "[bot]" did or authored, e.g.
+	// "dependabot[bot]" and "renovate[bot]"
+	Bots bool `json:"bots,omitempty"`
+	// Other accounts whose events and pull requests are left out, e.g.
+	// "renovate-bot"
+	Authors []string `json:"authors,omitempty"`
+	// Regular expressions matching the titles of pull requests, issues and
+	// commits left out, e.g. "^chore\\(deps\\)", compiled on validation
+	Titles []string `json:"titles,omitempty"`
+	titles []*regexp.Regexp
+}
+
+func (ig *ignoreConfig) compile() error {
+	ig.titles = nil
+	for _, pattern := range ig.Titles {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("titles: %w", err)
+		}
+		ig.titles = append(ig.titles, re)
+	}
+	return nil
+}
+
+// ignoredAuthor reports whether login is a bot or one of the authors left
+// out
+func (ig ignoreConfig) ignoredAuthor(login string) bool {
+	return login != "" && (ig.Bots && strings.HasSuffix(login, "[bot]") || slices.Contains(ig.Authors, login))
+}
+
+// eventAuthors returns who did the event and who authored the pull request
+// or issue it is about
+func eventAuthors(event map[string]interface{}) []string {
+	actor, _ := event["actor"].(map[string]interface{})
+	login, _ := actor["login"].(string)
+	authors := []string{login}
+	payload, _ := event["payload"].(map[string]interface{})
+	for _, key := range []string{"pull_request", "issue"} {
+		item, _ := payload[key].(map[string]interface{})
+		user, _ := item["user"].(map[string]interface{})
+		if author, ok := user["login"].(string); ok {
+			authors = append(authors, author)
+		}
+	}
+	return authors
+}
+
+// eventTitle returns the title of the pull request or issue of the event,
+// or the message of its commit
+func eventTitle(event map[string]interface{}) string {
+	payload, _ := event["payload"].(map[string]interface{})
+	for _, key := range []string{"pull_request", "issue"} {
+		item, _ := payload[key].(map[string]interface{})
+		if title, ok := item["title"].(string); ok {
+			return title
+		}
+	}
+	message, _ := payload["message"].(string)
+	return message
+}
+
+// withoutIgnored returns the events that aren't left out by the ignore
+// config: done by or about pull requests authored by ignored accounts, or
+// whose title matches one of the patterns, the events of machine users, and
+// those in repositories outside the repos of the config. The stored events
+// keep them, so changing the config and regenerating
+// brings them back.
+func (cfg *config) withoutIgnored(events []map[string]interface{}) []map[string]interface{} {
+	ig := cfg.Ignore
+	if !ig.Bots && len(ig.Authors) == 0 && len(ig.titles) == 0 && len(cfg.MachineUsers) == 0 && len(cfg.Repos) == 0 {
+		return events
+	}
+	var kept []map[string]interface{}
+	for _, event := range events {
+		repo, _ := event["repo"].(map[string]interface{})
+		if name, _ := repo["name"].(string); !cfg.reportsOn(name) {
+			slog.Debug("Skipped event", "id", event["id"], "type", event["type"], "reason", "repository not in repos", "repo", name)
+			continue
+		}
+		if m, ok := cfg.machineUserOf(event); ok {
+			slog.Debug("Skipped event", "id", event["id"], "type", event["type"], "reason", "machine user", "login", m.Login, "body", m.Body)
+			continue
+		}
+		if author := slices.IndexFunc(eventAuthors(event), ig.ignoredAuthor); author >= 0 {
+			slog.Debug("Skipped event", "id", event["id"], "type", event["type"], "reason", "ignored author "+eventAuthors(event)[author])
+			continue
+		}
+		title := eventTitle(event)
+		if slices.ContainsFunc(ig.titles, func(re *regexp.Regexp) bool { return re.MatchString(title) }) {
+			slog.Debug("Skipped event", "id", event["id"], "type", event["type"], "title", title, "reason", "ignored title")
+			continue
+		}
+		kept = append(kept, event)
+	}
+	return kept
+}
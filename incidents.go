@@ -0,0 +1,58 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Matches severities such as "SEV1", "sev-2" or "Sev 3" in labels and titles
+var severityPattern = regexp.MustCompile(`(?i)\bsev[-_ ]?([0-9])\b`)
+
+// Badge of entries labelled as incidents without a severity
+const incidentBadge = "INCIDENT"
+
+// severityOf returns the severity badge of an incident fix, e.g. "SEV1",
+// or "" when the pull request isn't about an incident
+func severityOf(title string, labels []string) string {
+	incident := false
+	for _, label := range labels {
+		if m := severityPattern.FindStringSubmatch(label); m != nil {
+			return "SEV" + m[1]
+		}
+		if strings.EqualFold(label, "incident") {
+			incident = true
+		}
+	}
+	if m := severityPattern.FindStringSubmatch(title); m != nil {
+		return "SEV" + m[1]
+	}
+	if incident {
+		return incidentBadge
+	}
+	return ""
+}
+
+// Order of the badges, SEV0 first and INCIDENT last
+func severityRank(badge string) int {
+	if badge == incidentBadge {
+		return 10
+	}
+	return int(badge[len(badge)-1] - '0')
+}
+
+// splitIncidents separates incident entries, most severe first, from the others
+func splitIncidents(entries []reportEntry) (incidents, others []reportEntry) {
+	for _, entry := range entries {
+		if entry.severity != "" {
+			incidents = append(incidents, entry)
+		} else {
+			others = append(others, entry)
+		}
+	}
+
+	sort.SliceStable(incidents, func(i, j int) bool {
+		return severityRank(incidents[i].severity) < severityRank(incidents[j].severity)
+	})
+	return incidents, others
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Default pattern of Jira issue keys, e.g. ABC-123
+const defaultJiraKeyPattern = `[A-Z][A-Z0-9]+-[0-9]+`
+
+// Default time logged per entry when posting worklogs
+const defaultWorklogTime = "30m"
+
+// jiraKeys returns the issue keys found in the entry's title and branch, once each
+func jiraKeys(entry reportEntry, pattern *regexp.Regexp) []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, key := range pattern.FindAllString(entry.title+" "+entry.branch, -1) {
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Atlassian document with a single paragraph, as Jira Cloud expects
+func jiraDoc(text string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":    "doc",
+		"version": 1,
+		"content": []map[string]interface{}{{
+			"type":    "paragraph",
+			"content": []map[string]interface{}{{"type": "text", "text": text}},
+		}},
+	}
+}
+
+// jiraHeaders signs requests in as the target's account, with its token or
+// JIRA_TOKEN
+func jiraHeaders(t target) map[string]string {
+	token := t.Token
+	if token == "" {
+		token = os.Getenv("JIRA_TOKEN")
+	}
+	auth := base64.StdEncoding.EncodeToString([]byte(t.Email + ":" + token))
+	return map[string]string{"Authorization": "Basic " + auth}
+}
+
+// postToJira posts each entry's report line to the Jira issues whose keys
+// appear in its title or branch, as a comment or, with "post_as": "worklog",
+// as a worklog entry. A report replacing the day's earlier one updates the
+// comments and worklogs posted for it.
+func postToJira(ctx context.Context, t target, rep *generatedReport) error {
+	pattern, err := regexp.Compile(t.jiraKeyPattern())
+	if err != nil {
+		return err
+	}
+
+	headers := jiraHeaders(t)
+	base := strings.TrimSuffix(t.URL, "/") + "/rest/api/3/issue/"
+	delivery := "jira:" + t.URL
+
+	for _, entry := range filterEntries(rep.entries, t.Orgs) {
+		line := fmt.Sprintf("%s | %s", entry.status, entry.title)
+
+		for _, key := range jiraKeys(entry, pattern) {
+			kind := "comment"
+			body := map[string]interface{}{"body": jiraDoc(line)}
+			if t.PostAs == "worklog" {
+				worklogTime := t.WorklogTime
+				if worklogTime == "" {
+					worklogTime = defaultWorklogTime
+				}
+				kind = "worklog"
+				body = map[string]interface{}{
+					"timeSpent": worklogTime,
+					"started":   time.Now().Format("2006-01-02T15:04:05.000-0700"),
+					"comment":   jiraDoc(line),
+				}
+			}
+
+			// Update what was posted for the entry instead of posting it again
+			item := key + " " + kind + " " + entry.key()
+			method, url := "POST", base+key+"/"+kind
+			if rep.replaces {
+				previous, err := deliveredRef(rep.date, delivery, item)
+				if err != nil {
+					return err
+				}
+				if previous != "" {
+					method, url = "PUT", url+"/"+previous
+				}
+			}
+
+			var posted struct {
+				ID string `json:"id"`
+			}
+			if err := sendJSON(ctx, method, url, headers, body, &posted); err != nil {
+				return fmt.Errorf("jira %s: %w", key, err)
+			}
+			if err := recordDelivery(rep.date, delivery, item, posted.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (t target) jiraKeyPattern() string {
+	if t.KeyPattern != "" {
+		return t.KeyPattern
+	}
+	return defaultJiraKeyPattern
+}
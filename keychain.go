@@ -0,0 +1,20 @@
+//go:build !nokeychain
+
+package main
+
+import "github.com/zalando/go-keyring"
+
+// Returned when the keychain has no such item
+var errKeychainNotFound = keyring.ErrNotFound
+
+func keychainGet(service, user string) (string, error) {
+	return keyring.Get(service, user)
+}
+
+func keychainSet(service, user, secret string) error {
+	return keyring.Set(service, user, secret)
+}
+
+func keychainDelete(service, user string) error {
+	return keyring.Delete(service, user)
+}
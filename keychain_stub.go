@@ -0,0 +1,24 @@
+//go:build nokeychain
+
+package main
+
+import "errors"
+
+// Builds with the nokeychain tag leave out the OS keychain, e.g. for servers
+// without D-Bus; tokens then come from the environment or the history store
+var (
+	errKeychainNotFound    = errors.New("not found in the keychain")
+	errKeychainUnsupported = errors.New("built without keychain support (nokeychain)")
+)
+
+func keychainGet(service, user string) (string, error) {
+	return "", errKeychainNotFound
+}
+
+func keychainSet(service, user, secret string) error {
+	return errKeychainUnsupported
+}
+
+func keychainDelete(service, user string) error {
+	return errKeychainUnsupported
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Keychain item the GitHub token is stored in by "auth login"
+const (
+	keyringService = "daily-reporting"
+	keyringUser    = "github"
+)
+
+// keyringToken returns the GitHub token stored in the OS keychain, or ""
+// when there is none or no keychain is available (e.g. on a headless server)
+func keyringToken() string {
+	token, _ := keychainGet(keyringService, keyringUser)
+	return token
+}
+
+// authCommand implements "auth login", which checks a GitHub token and stores
+// it in the OS keychain, and "auth logout", which removes it
+func authCommand(ctx context.Context, args []string) error {
+	switch {
+	case len(args) == 1 && args[0] == "login":
+		fmt.Fprint(os.Stderr, "GitHub token: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		token := strings.TrimSpace(line)
+		if token == "" {
+			if err != nil {
+				return err
+			}
+			return errors.New("no token given")
+		}
+
+		var user struct {
+			Login string `json:"login"`
+		}
+		if err := githubGet(ctx, githubAPI+"/user", token, &user); err != nil {
+			return fmt.Errorf("checking the token: %w", err)
+		}
+		if err := keychainSet(keyringService, keyringUser, token); err != nil {
+			return fmt.Errorf("storing the token in the keychain: %w", err)
+		}
+		fmt.Printf("Logged in as %s\n", user.Login)
+		return nil
+
+	case len(args) == 1 && args[0] == "logout":
+		err := keychainDelete(keyringService, keyringUser)
+		if errors.Is(err, errKeychainNotFound) {
+			return nil
+		}
+		return err
+
+	default:
+		return errors.New("usage: auth login | auth logout")
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Heading of the entries no label rule matches, when grouping by category
+const otherCategory = "Other"
+
+// labelRule puts the pull requests with a label in a category, e.g. "bug"
+// in "🐛 Fix"
+type labelRule struct {
+	// Label name, ignoring case
+	Label    string `json:"label"`
+	Category string `json:"category"`
+}
+
+// categorizeEntries sets the category of each pull request entry from the
+// first rule matching one of its labels. Unless grouping by category, the
+// category prefixes the title, e.g. "🐛 Fix: Handle empty payloads".
+func categorizeEntries(cfg *config, entries []reportEntry) {
+	for i, entry := range entries {
+		for _, rule := range cfg.LabelCategories {
+			if slices.ContainsFunc(entry.labels, func(label string) bool { return strings.EqualFold(label, rule.Label) }) {
+				entries[i].category = rule.Category
+				break
+			}
+		}
+		if entries[i].category != "" && !cfg.GroupByCategory {
+			entries[i].title = fmt.Sprintf("%s: %s", entries[i].category, entry.title)
+		}
+	}
+}
+
+// categoryOrder returns the categories in the order of their first rule
+func categoryOrder(rules []labelRule) []string {
+	var order []string
+	for _, rule := range rules {
+		if !slices.Contains(order, rule.Category) {
+			order = append(order, rule.Category)
+		}
+	}
+	return order
+}
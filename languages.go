@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Languages by file extension, for the languages footer
+var languageExtensions = map[string]string{
+	".go":    "Go",
+	".sql":   "SQL",
+	".yml":   "YAML",
+	".yaml":  "YAML",
+	".json":  "JSON",
+	".js":    "JavaScript",
+	".jsx":   "JavaScript",
+	".mjs":   "JavaScript",
+	".ts":    "TypeScript",
+	".tsx":   "TypeScript",
+	".py":    "Python",
+	".rb":    "Ruby",
+	".java":  "Java",
+	".kt":    "Kotlin",
+	".swift": "Swift",
+	".rs":    "Rust",
+	".c":     "C",
+	".h":     "C",
+	".cpp":   "C++",
+	".cc":    "C++",
+	".cs":    "C#",
+	".php":   "PHP",
+	".sh":    "Shell",
+	".html":  "HTML",
+	".css":   "CSS",
+	".scss":  "CSS",
+	".md":    "Markdown",
+	".tf":    "Terraform",
+	".proto": "Protobuf",
+}
+
+// languageBreakdown summarizes the languages of the files changed by the
+// entries, by share of files, e.g. "Go 70%, SQL 20%, YAML 10%". Files with
+// an unknown extension are not counted, and it is empty when none is known.
+func languageBreakdown(entries []reportEntry) string {
+	counts := make(map[string]int)
+	seen := make(map[string]bool)
+	total := 0
+	for _, entry := range entries {
+		for _, file := range entry.files {
+			language, ok := languageExtensions[strings.ToLower(path.Ext(file))]
+			if !ok || seen[entry.repo+"\x00"+file] {
+				continue
+			}
+			seen[entry.repo+"\x00"+file] = true
+			counts[language]++
+			total++
+		}
+	}
+	if total == 0 {
+		return ""
+	}
+
+	languages := make([]string, 0, len(counts))
+	for language := range counts {
+		languages = append(languages, language)
+	}
+	sort.Slice(languages, func(i, j int) bool {
+		if counts[languages[i]] != counts[languages[j]] {
+			return counts[languages[i]] > counts[languages[j]]
+		}
+		return languages[i] < languages[j]
+	})
+
+	parts := make([]string, len(languages))
+	for i, language := range languages {
+		parts[i] = fmt.Sprintf("%s %d%%", language, (counts[language]*100+total/2)/total)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// languageSection is the languages footer of the entries
+func languageSection(entries []reportEntry) reportSection {
+	section := reportSection{title: "Languages"}
+	if breakdown := languageBreakdown(entries); breakdown != "" {
+		section.lines = []reportLine{{text: breakdown}}
+	}
+	return section
+}
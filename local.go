@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Event type used for commits found in local clones
+const localCommitEvent = "LocalCommitEvent"
+
+// Author email used to pick my commits, falling back to the global git config
+func localAuthorEmail() string {
+	if email := os.Getenv("GIT_AUTHOR_EMAIL"); email != "" {
+		return email
+	}
+	cfg, err := gitconfig.LoadConfig(gitconfig.GlobalScope)
+	if err != nil {
+		return ""
+	}
+	return cfg.User.Email
+}
+
+// getLocalEvents walks root for git clones and returns the commits authored
+// by email on the given date, shaped like GitHub events so they can be merged
+// with the events fetched from the API. Clones are scanned concurrently and
+// their commits returned in the order the clones were found.
+func getLocalEvents(ctx context.Context, root, date, email string) ([]map[string]interface{}, error) {
+	paths, err := findClones(root)
+	if err != nil {
+		return nil, err
+	}
+
+	repoEvents := make([][]map[string]interface{}, len(paths))
+	err = runPool(ctx, fetchConcurrency, len(paths), func(ctx context.Context, i int) (err error) {
+		repoEvents[i], err = getRepoCommits(paths[i], date, email)
+		return err
+	})
+
+	var events []map[string]interface{}
+	for _, e := range repoEvents {
+		events = append(events, e...)
+	}
+	return events, err
+}
+
+// findClones returns the git clones under root
+func findClones(root string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		if _, err := os.Stat(filepath.Join(path, ".git")); err != nil {
+			return nil
+		}
+		paths = append(paths, path)
+
+		// Nested clones are not scanned
+		return filepath.SkipDir
+	})
+	return paths, err
+}
+
+func getRepoCommits(path, date, email string) ([]map[string]interface{}, error) {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return nil, err
+	}
+
+	day, err := time.ParseInLocation(dateFormat, date, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	since, until := day, day.AddDate(0, 0, 1)
+
+	iter, err := repo.Log(&git.LogOptions{All: true, Since: &since, Until: &until})
+	if err != nil {
+		return nil, err
+	}
+
+	var events []map[string]interface{}
+	seen := make(map[string]bool)
+	name := localRepoName(repo, path)
+
+	err = iter.ForEach(func(c *object.Commit) error {
+		// Skip merge commits and commits already seen on another branch
+		if c.NumParents() > 1 || seen[c.Hash.String()] {
+			return nil
+		}
+		seen[c.Hash.String()] = true
+
+		if !strings.EqualFold(c.Author.Email, email) || c.Author.When.In(time.Local).Format(dateFormat) != date {
+			return nil
+		}
+
+		message, _, _ := strings.Cut(strings.TrimSpace(c.Message), "\n")
+		var files []interface{}
+		if stats, err := c.Stats(); err == nil {
+			for _, s := range stats {
+				files = append(files, s.Name)
+			}
+		}
+		events = append(events, map[string]interface{}{
+			"type":       localCommitEvent,
+			"created_at": c.Author.When.Format(time.RFC3339),
+			"repo":       map[string]interface{}{"name": name},
+			"payload": map[string]interface{}{
+				"sha":     c.Hash.String(),
+				"message": message,
+				"files":   files,
+			},
+		})
+		return nil
+	})
+
+	return events, err
+}
+
+// Name of the clone's GitHub repository ("owner/name") taken from its origin
+// remote, falling back to the directory name
+func localRepoName(repo *git.Repository, path string) string {
+	remote, err := repo.Remote("origin")
+	if err != nil || len(remote.Config().URLs) == 0 {
+		return filepath.Base(path)
+	}
+
+	// git@github.com:owner/name.git or https://github.com/owner/name.git
+	u := strings.TrimSuffix(remote.Config().URLs[0], ".git")
+	if _, rest, ok := strings.Cut(u, "github.com"); ok {
+		if name := strings.Trim(rest, ":/"); strings.Count(name, "/") == 1 {
+			return name
+		}
+	}
+	return filepath.Base(path)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// setupLogging logs to stderr at LOG_LEVEL (debug, info, warn or error,
+// info by default), or at debug with verbose. LOG_FORMAT=json logs JSON
+// lines for log collectors instead of key=value text.
+func setupLogging(verbose bool) {
+	level := slog.LevelInfo
+	if value := os.Getenv("LOG_LEVEL"); value != "" {
+		if err := level.UnmarshalText([]byte(value)); err != nil {
+			fatal("invalid LOG_LEVEL", "value", value)
+		}
+	}
+	if verbose {
+		level = slog.LevelDebug
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler = slog.NewTextHandler(os.Stderr, opts)
+	if strings.EqualFold(os.Getenv("LOG_FORMAT"), "json") {
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	}
+	slog.SetDefault(slog.New(handler))
+}
+
+// fatal logs an error and exits, e.g. fatal("report failed", "err", err)
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
@@ -1,21 +1,24 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"io/ioutil"
-	"log"
-	"net/http"
+	"log/slog"
 	"os"
+	"slices"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
-// Load environment variables from .env file
+// loadEnv sets the variables of .env that the environment doesn't set.
+// The file is optional, e.g. in CI or containers setting the environment.
 func loadEnv() {
-	if err := godotenv.Load(); err != nil {
-		log.Fatal("Error loading .env file")
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		fatal("Error loading .env file", "err", err)
 	}
 }
 
@@ -27,145 +30,748 @@ const (
 func main() {
 	// Load environment variables
 	loadEnv()
+	setHTTPTimeout()
+
+	opts := reportOptions{mode: modeFinal}
+	var regenerate, server string
+	var interactive, dryRunOnly, verbose, standup, diff bool
+	var overwrite, appendRevision, abort bool
+	var noCache, offlineOnly bool
+	var format, columns string
+	flag.StringVar(&opts.localRoot, "local", os.Getenv("LOCAL_REPOS"), "directory of local git clones to scan for today's commits")
+	flag.StringVar(&regenerate, "regenerate", "", "re-render the stored events of a past date (YYYY-MM-DD) without calling the API")
+	flag.BoolVar(&interactive, "interactive", false, "edit the report in $EDITOR before it is saved and delivered")
+	flag.BoolVar(&diff, "diff", false, "show the entries new, changed and gone since the previous day's report before delivering")
+	flag.BoolVar(&standup, "standup", false, "ask for blockers on the terminal before the report is generated")
+	flag.BoolVar(&dryRunOnly, "dry-run", false, "print what would be delivered and the changes from the stored report, without saving or delivering")
+	flag.StringVar(&flagSettings.username, "user", "", "GitHub login whose events are reported, overriding the config and GITHUB_USERNAME")
+	flag.StringVar(&flagSettings.reportFile, "report-file", "", "file the report is written to, overriding the config and REPORT_FILE")
+	flag.StringVar(&activeProfile, "profile", os.Getenv("REPORT_PROFILE"), "profile of the config to use, e.g. a client's, with its own history")
+	flag.StringVar(&server, "server", os.Getenv("REPORT_SERVER"), "URL of a report server to run the command against instead of locally")
+	flag.BoolVar(&overwrite, "overwrite", false, "replace the report already stored for the day, updating what was delivered")
+	flag.BoolVar(&appendRevision, "append-revision", false, "keep the report already stored for the day and deliver this one as its next revision")
+	flag.BoolVar(&abort, "abort", false, "stop when a report is already stored for the day")
+	flag.StringVar(&format, "format", formatText, "format of the report file: text, csv, tsv, pdf or html")
+	flag.BoolVar(&opts.summary, "summary", false, "add a summary of the day written by the LLM of the summary config")
+	flag.BoolVar(&opts.annotate, "annotate", false, "mark each entry with a footnote linking to the events it comes from")
+	flag.StringVar(&columns, "columns", "", "comma-separated columns of csv and tsv reports, e.g. date,status,title")
+	flag.BoolVar(&offlineOnly, "offline", false, "build the report from the cache, history, local clones and manual entries without the network")
+	flag.BoolVar(&noCache, "no-cache", false, "don't reuse or cache GitHub API responses")
+	flag.BoolVar(&verbose, "verbose", false, "log requests, rate limits and why each event was kept or skipped")
+	flag.Parse()
+	setupLogging(verbose)
+	setupCache(noCache)
+	if offline = offlineOnly; offline && noCache {
+		fatal("--offline needs the cache, it can't be combined with --no-cache")
+	}
 
-	// Get GitHub token and username from environment variables
-	githubToken := os.Getenv("GITHUB_TOKEN")
-	username := os.Getenv("GITHUB_USERNAME")
+	// Bound the whole run by RUN_TIMEOUT
+	ctx, cancel := runContext(context.Background())
+	defer cancel()
 
-	// Get today's date in the format used in GitHub events
-	today := time.Now().Format(dateFormat)
+	// Run the command through the API of a remote server
+	if server != "" {
+		if err := runRemote(ctx, server, flag.Args()); err != nil {
+			fatal("command failed", "command", flag.Arg(0), "err", err)
+		}
+		return
+	}
+
+	switch flag.Arg(0) {
+	// Run as a long-lived scheduler when asked to
+	case "serve":
+		serve(flag.Args()[1:])
+		return
+
+	// Catch up with what happened while I was away
+	case "catchup":
+		if err := catchup(ctx, flag.Args()[1:]); err != nil {
+			fatal("command failed", "command", flag.Arg(0), "err", err)
+		}
+		return
+
+	// Add what the last report missed once the events API caught up
+	case "reconcile":
+		if err := reconcile(ctx, flag.Args()[1:]); err != nil {
+			fatal("command failed", "command", flag.Arg(0), "err", err)
+		}
+		return
+
+	// Only capture webhook events as they arrive, for the reports to add
+	case "receive":
+		if err := receive(flag.Args()[1:]); err != nil {
+			fatal("command failed", "command", flag.Arg(0), "err", err)
+		}
+		return
+
+	// Print this week's report grouped by repository
+	case "weekly":
+		cfg, err := loadConfig(configPath())
+		if err != nil {
+			fatal("command failed", "command", flag.Arg(0), "err", err)
+		}
+		report, err := generateWeeklyReport(ctx, cfg, opts)
+		if err != nil {
+			fatal("command failed", "command", flag.Arg(0), "err", err)
+		}
+		fmt.Println(report.render(nil))
+		return
+
+	// Print the day's digest of the team's activity
+	case "digest":
+		if err := digest(ctx, flag.Args()[1:]); err != nil {
+			fatal("command failed", "command", flag.Arg(0), "err", err)
+		}
+		return
+
+	// Review today's entries in a terminal dashboard before sending
+	case "tui":
+		if err := dashboard(opts); err != nil {
+			fatal("command failed", "command", flag.Arg(0), "err", err)
+		}
+		return
+
+	// Page through past reports in a terminal browser
+	case "browse":
+		if err := browse(); err != nil {
+			fatal("command failed", "command", flag.Arg(0), "err", err)
+		}
+		return
+
+	// Browse past reports
+	case "history":
+		if err := history(flag.Args()[1:]); err != nil {
+			fatal("command failed", "command", flag.Arg(0), "err", err)
+		}
+		return
+
+	// Check the config, sources and targets work before relying on them
+	case "doctor":
+		if err := doctorCommand(); err != nil {
+			fatal("command failed", "command", flag.Arg(0), "err", err)
+		}
+		return
+
+	// Draw the entries per day of the stored reports as a heatmap
+	case "heatmap":
+		if err := heatmap(flag.Args()[1:]); err != nil {
+			fatal("command failed", "command", flag.Arg(0), "err", err)
+		}
+		return
+
+	// Sign in with GitHub in the browser
+	case "login":
+		if err := login(context.Background(), flag.Args()[1:]); err != nil {
+			fatal("command failed", "command", flag.Arg(0), "err", err)
+		}
+		return
+
+	// Keep the GitHub token in the OS keychain
+	case "auth":
+		if err := authCommand(ctx, flag.Args()[1:]); err != nil {
+			fatal("command failed", "command", flag.Arg(0), "err", err)
+		}
+		return
+
+	// Preview a report template while editing it
+	case "template":
+		if err := templateCommand(flag.Args()[1:]); err != nil {
+			fatal("command failed", "command", flag.Arg(0), "err", err)
+		}
+		return
+
+	// Write the config equivalent to the deprecated environment variables
+	case "config":
+		if err := configCommand(flag.Args()[1:]); err != nil {
+			fatal("command failed", "command", flag.Arg(0), "err", err)
+		}
+		return
+
+	// Store an encrypted source token
+	case "token":
+		if err := tokenCommand(flag.Args()[1:]); err != nil {
+			fatal("command failed", "command", flag.Arg(0), "err", err)
+		}
+		return
+
+	// Hide an entry from a published report, bring it back, or set its
+	// privacy or hours
+	case "hide", "restore", "privacy", "hours":
+		if err := changeEntry(flag.Arg(0), flag.Args()[1:], format, columns); err != nil {
+			fatal("command failed", "command", flag.Arg(0), "err", err)
+		}
+		return
+
+	// The month's report for clients, in the Japanese 月報 format
+	case "monthly-report":
+		if err := monthlyReport(flag.Args()[1:]); err != nil {
+			fatal("command failed", "command", flag.Arg(0), "err", err)
+		}
+		return
 
-	// Get daily events from GitHub profile
-	dailyEvents, err := getDailyEvents(today, username, githubToken)
+	// Explain the status rule an event matches
+	case "rules":
+		if err := rulesCommand(flag.Args()[1:]); err != nil {
+			fatal("command failed", "command", flag.Arg(0), "err", err)
+		}
+		return
+
+	// Export a stored day as JSON, or merge days captured on several machines
+	case "export":
+		if err := exportReport(flag.Args()[1:]); err != nil {
+			fatal("command failed", "command", flag.Arg(0), "err", err)
+		}
+		return
+	case "merge":
+		if err := mergeReports(flag.Args()[1:]); err != nil {
+			fatal("command failed", "command", flag.Arg(0), "err", err)
+		}
+		return
+
+	// Raise, resolve and list blockers, or ask for them in a Slack DM
+	case "blockers":
+		if err := blockersCommand(ctx, flag.Args()[1:]); err != nil {
+			fatal("command failed", "command", flag.Arg(0), "err", err)
+		}
+		return
+
+	// Add a manual entry to a day's report
+	case "add":
+		if err := addEntry(flag.Args()[1:]); err != nil {
+			fatal("command failed", "command", flag.Arg(0), "err", err)
+		}
+		return
+
+	case "":
+	default:
+		fatal("unknown command", "command", flag.Arg(0))
+	}
+
+	cfg, err := loadConfig(configPath())
+	if err != nil {
+		fatal("report failed", "err", err)
+	}
+	policy, err := duplicatePolicy(cfg, overwrite, appendRevision, abort)
 	if err != nil {
-		log.Fatal(err)
+		fatal("report failed", "err", err)
+	}
+	output := cfg.reportFileTarget(format, columns)
+	if err := validFormat(output.Format, output.Columns); err != nil {
+		fatal("report failed", "err", err)
 	}
 
-	// Format events for the report
-	report := formatEvents(dailyEvents)
+	// The report file, if any, and the targets of deliver
+	var targets []target
+	if output.Path != "" {
+		targets = append(targets, output)
+	}
+	targets = append(targets, cfg.resolveTargets(cfg.Deliver)...)
+	if len(targets) == 0 {
+		fatal("report failed", "err", "report_file or deliver is required")
+	}
 
-	// Print or save the report as needed
-	fmt.Println(report)
+	// Ask for the day's blockers, carrying over the open ones
+	if standup && regenerate == "" && !dryRunOnly {
+		if err := askBlockers(time.Now().Format(dateFormat), os.Stdin); err != nil {
+			fatal("report failed", "err", err)
+		}
+	}
 
-	// Save the report to a file
-	reportFile := os.Getenv("REPORT_FILE")
-	err = ioutil.WriteFile(reportFile, []byte(report), 0644)
+	var rep *generatedReport
+	if regenerate != "" {
+		rep, err = regenerateReport(cfg, opts, regenerate)
+	} else {
+		rep, err = generateReport(ctx, cfg, opts)
+	}
 	if err != nil {
-		log.Fatal(err)
+		fatal("report failed", "err", err)
+	}
+	// Decide what happens to a report already stored for the day
+	if err := applyDuplicatePolicy(rep, policy); err != nil {
+		fatal("report failed", "err", err)
+	}
+	report := rep.render(nil)
+
+	// Show what changed since the previous day, to check before sending
+	if diff {
+		changes, err := previousDayDiff(cfg, rep)
+		if err != nil {
+			fatal("report failed", "err", err)
+		}
+		fmt.Fprint(os.Stderr, changes)
+	}
+
+	// Let me add manual items and drop noise before anything is delivered
+	if interactive {
+		if err := checkEditable(rep, targets); err != nil {
+			fatal("report failed", "err", err)
+		}
+		if report, err = editReport(report); err != nil {
+			fatal("report failed", "err", err)
+		}
+		rep.edited = true
+	}
+
+	// Show what would happen instead of doing it
+	if dryRunOnly {
+		for i := range targets {
+			switch {
+			case (targets[i].Type == "file" || targets[i].Type == "git") && targets[i].Append:
+				targets[i].Path, err = expandPath(targets[i].Path, rep)
+			case targets[i].Type == "file" || targets[i].Type == "git":
+				targets[i].Path, err = reportPath(targets[i].Path, rep)
+			}
+			if err != nil {
+				fatal("report failed", "err", err)
+			}
+		}
+		if err := dryRun(rep, report, targets); err != nil {
+			fatal("report failed", "err", err)
+		}
+		return
+	}
+
+	// Keep the raw events and report for later
+	if err := recordHistory(rep, report); err != nil {
+		fatal("report failed", "err", err)
+	}
+
+	// Print the report in the same format it is saved in, as text for PDFs
+	// and HTML pages
+	printed := target{Type: "stdout", Format: output.Format, Columns: output.Columns}
+	if printed.Format == formatPDF || printed.Format == formatHTML {
+		printed.Format = formatText
+	}
+	printed = cfg.withStyle(printed)
+	if err := writeTarget(ctx, printed, rep, report); err != nil {
+		fatal("report failed", "err", err)
+	}
+
+	// Save the report to a file and deliver it to the other targets
+	if err := deliverToTargets(ctx, cfg.Hooks, rep, report, targets); err != nil {
+		fatal("report failed", "err", err)
+	}
+
+	// Check off the Notion tasks of what got merged
+	if cfg.NotionTasks != nil && opts.mode == modeFinal {
+		if err := completeNotionTasks(ctx, cfg.NotionTasks, rep); err != nil {
+			slog.Warn("Checking off Notion tasks failed", "err", err)
+		}
+	}
+
+	// Back from an absence, point to what happened meanwhile
+	if regenerate == "" {
+		offerAwaySummary(cfg, time.Now())
 	}
 }
 
-func getDailyEvents(date, username, token string) ([]map[string]interface{}, error) {
-	url := fmt.Sprintf(eventsAPI, username)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
+// Options controlling how a report is generated
+type reportOptions struct {
+	// Directory scanned for local git clones, if any
+	localRoot string
+	// One of modeFinal (default), modeDraft, modeWeekly, modeDigest or
+	// modeReconcile
+	mode string
+	// Build the report from the events received by webhook instead of
+	// fetching them from the events API
+	webhook bool
+	// Add a summary of the day written by the configured LLM
+	summary bool
+	// Mark each entry with a footnote linking to the events it comes from
+	annotate bool
+}
+
+// A generated report: the events it was built from and the entries derived
+// from them, which can be edited before the report is rendered
+type generatedReport struct {
+	date string
+	// GitHub login the report is about
+	username string
+	header   string
+	events   []map[string]interface{}
+	meetings []reportEntry
+	entries  []reportEntry
+	sections []reportSection
+	next     []reportLine
+	// Renders the given entries of the report, r being the report or a copy
+	// of it, e.g. styled for a target
+	format func(r *generatedReport, entries []reportEntry, orgs []string) string
+	// Revision of the day's report, and whether it replaces the revision
+	// stored and delivered before instead of following it
+	revision int
+	replaces bool
+	// Translations of the statuses and headings of the report language
+	messages catalog
+	// For the addendum of a reconciled day, the day's report it updated,
+	// which file and git targets get instead
+	reconciled *generatedReport
+	// Edited with --interactive, so targets get the text as edited rather
+	// than rendering it again
+	edited bool
+}
+
+// render returns the report as seen by a target restricted to the given
+// organizations, or the whole report when orgs is empty
+func (r *generatedReport) render(orgs []string) string {
+	return r.format(r, filterEntries(r.entries, orgs), orgs)
+}
+
+// Build today's report from the GitHub profile events
+func generateReport(ctx context.Context, cfg *config, opts reportOptions) (*generatedReport, error) {
+	username := cfg.GitHub.Username
+	if username == "" {
+		return nil, errNoUsername
+	}
+	githubToken, err := githubToken(ctx, cfg)
+	if err != nil && !offline {
 		return nil, err
 	}
 
-	req.Header.Set("Authorization", "token "+token)
+	// The weekly rollup is aggregated from the whole week
+	if opts.mode == modeWeekly {
+		return generateWeeklyReport(ctx, cfg, opts)
+	}
+
+	// Reconciling adds what the last report missed
+	if opts.mode == modeReconcile {
+		return reconcileReport(ctx, cfg, opts, "")
+	}
 
-	resp, err := http.DefaultClient.Do(req)
+	// The digest covers the whole team
+	if opts.mode == modeDigest {
+		return generateDigest(ctx, cfg, time.Now().Format(dateFormat), opts.webhook)
+	}
+
+	// Get today's date in the format used in GitHub events
+	now := time.Now()
+	today := now.Format(dateFormat)
+
+	// Fetch the sources of the report concurrently, each into its own result
+	var dailyEvents, localEvents []map[string]interface{}
+	var feedback, assigned, next, triage, requested, pending, coding []reportLine
+	var tasks []reportLine
+	var blockers []blocker
+	var manual, meetings []reportEntry
+	var haveMeetings bool
+	var timeEntries []timeEntry
+	var hidden map[string]bool
+	var tags map[string]string
+	var hours map[string]float64
+	notes := &offlineNotes{}
+	sources := []func(ctx context.Context) error{
+		// Get daily events from GitHub profile, or as received by webhook
+		notes.source("GitHub events", func(ctx context.Context) (err error) {
+			if opts.webhook {
+				dailyEvents, err = storedWebhookEvents(today, username)
+				return err
+			}
+			dailyEvents, err = getDailyEvents(ctx, username, githubToken, today)
+			// Offline without cached events, use the ones of an earlier run
+			if errors.Is(err, errOffline) {
+				var storedAt time.Time
+				if dailyEvents, storedAt, err = storedEvents(today); err == nil {
+					notes.add("GitHub events: stored by the run of %s", storedAt.Local().Format("Jan 2 15:04"))
+				}
+			}
+			if err != nil {
+				return err
+			}
+			// Add what "receive" captured, which the events API may not
+			// show yet or anymore
+			captured, err := storedWebhookEvents(today, username)
+			dailyEvents = append(dailyEvents, captured...)
+			return err
+		}),
+		// Commits from local clones, and whether they were all pushed
+		func(ctx context.Context) (err error) {
+			if opts.localRoot == "" {
+				return nil
+			}
+			if localEvents, err = getLocalEvents(ctx, opts.localRoot, today, localAuthorEmail()); err != nil || !cfg.CheckUnpushed {
+				return err
+			}
+			warnings, err := checkUnpushed(ctx, opts.localRoot, today, localAuthorEmail())
+			for _, w := range warnings {
+				slog.Warn(w)
+			}
+			return err
+		},
+		// Review feedback received on my pull requests today
+		notes.source("Feedback received", func(ctx context.Context) (err error) {
+			if cfg.Feedback {
+				feedback, err = getFeedback(ctx, today, username, githubToken)
+			}
+			return err
+		}),
+		// What was assigned to me today
+		notes.source("Newly assigned", func(ctx context.Context) (err error) {
+			if cfg.NewlyAssigned {
+				assigned, err = getNewlyAssigned(ctx, today, username, githubToken)
+			}
+			return err
+		}),
+		// Plan tomorrow from open pull requests that need me and my issues
+		notes.source("Next", func(ctx context.Context) (err error) {
+			if cfg.AutoNext && cfg.nextLimit() > 0 {
+				next, err = getNextItems(ctx, now, username, githubToken, cfg.nextLimit(), cfg.nextAgeDays())
+			}
+			return err
+		}),
+		// My Notion tasks due by today or being worked on
+		notes.source("Notion tasks", func(ctx context.Context) (err error) {
+			if cfg.NotionTasks != nil {
+				tasks, err = getNotionTasks(ctx, cfg.NotionTasks, today)
+			}
+			return err
+		}),
+		// Reviews requested from me today, and those I haven't done yet
+		notes.source("Review requests", func(ctx context.Context) (err error) {
+			if cfg.ReviewRequests {
+				requested, pending, err = getReviewRequests(ctx, now, today, username, githubToken)
+			}
+			return err
+		}),
+		// How I went through my notifications today
+		notes.source("Notifications", func(ctx context.Context) (err error) {
+			if cfg.Notifications {
+				triage, err = getNotificationsTriage(ctx, today, githubToken)
+			}
+			return err
+		}),
+		// Entries added by hand, and entries hidden from the report, given
+		// another privacy or their hours
+		func(ctx context.Context) (err error) {
+			if manual, err = loadManualEntries(today); err != nil {
+				return err
+			}
+			if hidden, err = loadHiddenEntries(today); err != nil {
+				return err
+			}
+			if tags, err = loadPrivacyTags(today); err != nil {
+				return err
+			}
+			hours, err = loadHourOverrides(today)
+			return err
+		},
+		// Today's meetings instead of the default lines
+		notes.source("Meetings", func(ctx context.Context) (err error) {
+			if cfg.Calendar != nil {
+				meetings, err = getMeetings(ctx, cfg.Calendar, now)
+				haveMeetings = err == nil
+			}
+			return err
+		}),
+		// Coding time from WakaTime
+		notes.source("Coding activity", func(ctx context.Context) (err error) {
+			if cfg.WakaTime != nil {
+				coding, err = getCodingActivity(ctx, cfg.WakaTime, today, cfg.messages())
+			}
+			return err
+		}),
+		// Blockers open today, after reading the replies to the Slack question
+		notes.source("Blockers", func(ctx context.Context) (err error) {
+			if cfg.Blockers != nil {
+				err = collectSlackBlockers(ctx, cfg.Blockers, today)
+			}
+			if loaded, loadErr := loadOpenBlockers(today); loadErr == nil {
+				blockers = loaded
+			} else {
+				err = errors.Join(err, loadErr)
+			}
+			return err
+		}),
+		// Hours tracked in Toggl or Clockify
+		notes.source("Time tracking", func(ctx context.Context) (err error) {
+			if cfg.TimeTracking != nil {
+				timeEntries, err = getTimeEntries(ctx, cfg.TimeTracking, now)
+			}
+			return err
+		}),
+	}
+	err = runPool(ctx, fetchConcurrency, len(sources), func(ctx context.Context, i int) error {
+		return sources[i](ctx)
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
+	// Merge in commits from local clones
+	dailyEvents = append(dailyEvents, localEvents...)
+
+	// The items planned by hand come first, then my Notion tasks
+	next = append(append(cfg.manualNext(), tasks...), next...)
+
+	// Plan to follow up on the TODOs added today
+	err = notes.source("TODOs", func(ctx context.Context) error {
+		if !cfg.TodoNext {
+			return nil
+		}
+		todos, err := getTodoItems(ctx, buildEntries(cfg.withoutIgnored(dailyEvents), username), githubToken)
+		next = append(next, todos...)
+		return err
+	})(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	var events []map[string]interface{}
-	err = parseJSON(body, &events)
+	// List what each pull request got today
+	err = notes.source("Pull request commits", func(ctx context.Context) error {
+		if !cfg.PRCommits {
+			return nil
+		}
+		return addDayCommits(ctx, today, dailyEvents, githubToken)
+	})(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	var dailyEvents []map[string]interface{}
-	for _, event := range events {
-		createdAt, ok := event["created_at"].(string)
-		if !ok {
-			continue
+	// Find the files changed in monorepos, or everywhere for the languages footer
+	err = notes.source("Changed files", func(ctx context.Context) error {
+		if len(cfg.Components) == 0 && !cfg.Languages {
+			return nil
 		}
-
-		if startTime, err := time.Parse(time.RFC3339, createdAt); err == nil {
-			if startTime.Format(dateFormat) == date {
-				dailyEvents = append(dailyEvents, event)
-			}
+		want := func(repo string) bool {
+			_, monorepo := cfg.Components[repo]
+			return monorepo || cfg.Languages
 		}
+		return addChangedFiles(ctx, want, dailyEvents, githubToken)
+	})(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	return dailyEvents, nil
+	sections := []reportSection{blockersSection(blockers, today, cfg.messages())}
+	if cfg.Feedback {
+		sections = append(sections, reportSection{title: "Feedback received", lines: feedback})
+	}
+	if cfg.NewlyAssigned {
+		sections = append(sections, reportSection{title: "Newly assigned", lines: assigned})
+	}
+	if cfg.ReviewRequests {
+		requested = append(requested, reviewRequestsGiven(dailyEvents, username)...)
+		sections = append(sections, reportSection{title: "Review requests", lines: requested},
+			reportSection{title: "Pending", lines: pending})
+	}
+	if cfg.Notifications {
+		sections = append(sections, reportSection{title: "Notifications", lines: triage})
+	}
+	if cfg.WakaTime != nil {
+		sections = append(sections, reportSection{title: "Coding activity", lines: coding})
+	}
+	rep := renderReport(cfg, opts, now, dailyEvents, manual, sections, next)
+	rep.hide(hidden)
+	rep.tagPrivacy(tags)
+	rep.setHours(hours)
+	rep.trackTime(timeEntries)
+
+	// Summarize the entries that made it into the report; a summary that
+	// fails is left out rather than holding up the report
+	if opts.summary {
+		err = notes.source("Summary", func(ctx context.Context) error {
+			summary, err := summarizeDay(ctx, cfg, rep)
+			rep.sections = append(rep.sections, summary)
+			return err
+		})(ctx)
+		if err != nil {
+			slog.Warn("Summarizing the day failed", "err", err)
+		}
+	}
+	rep.sections = append(rep.sections, notes.section())
+	if haveMeetings {
+		rep.meetings = meetings
+	}
+	return rep, nil
 }
 
-func formatEvents(events []map[string]interface{}) string {
-	report := fmt.Sprintf("%s:\n", time.Now().Format("Jan 02, 2006"))
-
-	// Default lines in every report
-	report += "Done | Attended frail-check meeting\n"
-	report += "Done | Attended frail-check followup meeting\n"
-
-	// Keep track of seen pull request titles
-	seenTitles := make(map[string]bool)
-
-	for _, event := range events {
-		eventType, ok := event["type"].(string)
-		if !ok {
-			continue
+// Render the report of the day from events that were already collected and
+// entries added by hand
+func renderReport(cfg *config, opts reportOptions, day time.Time, events []map[string]interface{}, manual []reportEntry, sections []reportSection, next []reportLine) *generatedReport {
+	rep := &generatedReport{
+		date:     day.Format(dateFormat),
+		username: cfg.GitHub.Username,
+		header:   cfg.formatHeaderDate(day),
+		events:   events,
+		meetings: defaultEntries,
+		entries:  append(buildEntries(cfg.withoutIgnored(events), cfg.GitHub.Username), manual...),
+		sections: cfg.onRepos(sections),
+		next:     cfg.linesOnRepos(next),
+		messages: cfg.messages(),
+	}
+	tagComponents(cfg.Components, rep.entries)
+	classifyPrivacy(cfg, rep.entries)
+	categorizeEntries(cfg, rep.entries)
+	rep.format = func(r *generatedReport, entries []reportEntry, orgs []string) string {
+		// Format entries for the report
+		sections := filterSections(r.sections, orgs)
+		if cfg.Languages {
+			sections = append(sections, languageSection(entries))
+		}
+		next := filterLines(r.next, orgs)
+		if opts.annotate {
+			var sources reportSection
+			entries, sources = annotateEntries(r.meetings, entries, cfg.entryGrouping())
+			sections = append(sections, sources)
+		}
+		if cfg.Hours || cfg.TimeTracking != nil {
+			entries = withHours(entries)
 		}
+		report := formatEntries(r.header, r.meetings, entries, sections, next, cfg.entryGrouping(), r.messages)
+		if cfg.reportTemplate != nil {
+			data := newReportTemplateData(r.header, r.meetings, entries, sections, next, cfg.entryGrouping(), r.messages)
+			if templated, err := executeTemplate(cfg.reportTemplate, data); err == nil {
+				report = templated
+			} else {
+				slog.Warn("Rendering the report template failed, using the default layout", "err", err)
+			}
+		}
+		if opts.mode == modeDraft {
+			report = r.messages.text("[Draft]") + "\n" + report
+		}
+		return report
+	}
+	return rep
+}
 
-		var prTitle, status, action, author string
-		merged := false
+// The events API returns at most this many pages of 100 events
+const maxEventPages = 3
 
-		switch eventType {
-		case "PullRequestEvent":
-			action, _ = event["payload"].(map[string]interface{})["action"].(string)
-			merged, _ = event["payload"].(map[string]interface{})["pull_request"].(map[string]interface{})["merged"].(bool)
-			prTitle, _ = event["payload"].(map[string]interface{})["pull_request"].(map[string]interface{})["title"].(string)
-			author, _ = event["payload"].(map[string]interface{})["pull_request"].(map[string]interface{})["user"].(map[string]interface{})["login"].(string)
+// Get the events of the user created on any of the given dates. Pages are
+// fetched, newest first, until one reaches past the earliest date.
+func getDailyEvents(ctx context.Context, username, token string, dates ...string) ([]map[string]interface{}, error) {
+	earliest := slices.Min(dates)
 
-		case "PullRequestReviewEvent":
-			action, _ = event["payload"].(map[string]interface{})["action"].(string)
-			merged, _ = event["payload"].(map[string]interface{})["pull_request"].(map[string]interface{})["merged"].(bool)
-			prTitle, _ = event["payload"].(map[string]interface{})["pull_request"].(map[string]interface{})["title"].(string)
-			author, _ = event["payload"].(map[string]interface{})["review"].(map[string]interface{})["user"].(map[string]interface{})["login"].(string)
+	var dailyEvents []map[string]interface{}
+	for page := 1; page <= maxEventPages; page++ {
+		var events []map[string]interface{}
+		if err := githubGet(ctx, fmt.Sprintf(eventsAPI+"?per_page=100&page=%d", username, page), token, &events); err != nil {
+			return nil, err
 		}
 
-		// Check if the title has been seen before
-		if !seenTitles[prTitle] {
-			seenTitles[prTitle] = true
-
-			switch {
-			//myside
-			case author == os.Getenv("GITHUB_USERNAME") && action == "opened" && merged:
-				status = "Done"
-			case author == os.Getenv("GITHUB_USERNAME") && action == "opened":
-				status = "In Review"
-			case author == os.Getenv("GITHUB_USERNAME") && action == "closed" && merged:
-				status = "Done"
-			case author == os.Getenv("GITHUB_USERNAME") && merged:
-				status = "Done"
-
-			//other side
-			case author != os.Getenv("GITHUB_USERNAME") && action == "closed" && merged:
-				status = "Reviewed and merged"
-			case author != os.Getenv("GITHUB_USERNAME") && action == "closed":
-				status = "Reviewed"
+		older := false
+		for _, event := range events {
+			createdAt, ok := event["created_at"].(string)
+			if !ok {
+				continue
 			}
 
-			// Append to the report only if status and prTitle are not empty
-			if status != "" && prTitle != "" {
-				report += fmt.Sprintf("%s | %s\n", status, prTitle)
+			if startTime, err := time.Parse(time.RFC3339, createdAt); err == nil {
+				day := startTime.Format(dateFormat)
+				older = older || day < earliest
+				if slices.Contains(dates, day) {
+					dailyEvents = append(dailyEvents, event)
+				}
 			}
 		}
-	}
+		slog.Debug("Fetched events page", "user", username, "page", page, "events", len(events), "kept", len(dailyEvents))
 
-	report += "Next:\nContinue with assigned task and R&D\n"
+		if len(events) < 100 || older {
+			break
+		}
+	}
 
-	return report
+	return dailyEvents, nil
 }
 
-
 func parseJSON(data []byte, v interface{}) error {
 	return json.Unmarshal(data, v)
 }
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+)
+
+// reportDocument is a day's report as JSON, written by "export" and read by
+// "merge", and given to hooks. It carries the raw events so entries can be
+// derived again; Entries is informative only.
+type reportDocument struct {
+	Date    string                   `json:"date"`
+	Report  string                   `json:"report,omitempty"`
+	Entries []documentEntry          `json:"entries,omitempty"`
+	Events  []map[string]interface{} `json:"events"`
+	Manual  []documentEntry          `json:"manual,omitempty"`
+}
+
+// An entry in a report document
+type documentEntry struct {
+	Status string `json:"status"`
+	Title  string `json:"title"`
+	Repo   string `json:"repo,omitempty"`
+	Number int    `json:"number,omitempty"`
+}
+
+// document returns the report as a report document, with the entries
+// that are shown
+func (r *generatedReport) document() reportDocument {
+	doc := reportDocument{Date: r.date, Report: r.render(nil), Events: r.events}
+	if doc.Events == nil {
+		doc.Events = []map[string]interface{}{}
+	}
+	for _, entry := range filterEntries(r.entries, nil) {
+		doc.Entries = append(doc.Entries, documentEntry{Status: entry.status, Title: entry.title, Repo: entry.repo, Number: entry.number})
+	}
+	return doc
+}
+
+// provenance identifies where an event came from, so the same event
+// captured on two machines is only counted once
+func provenance(event map[string]interface{}) string {
+	if id, ok := event["id"].(string); ok {
+		return "github:" + id
+	}
+	if event["type"] == localCommitEvent {
+		if sha, ok := event["payload"].(map[string]interface{})["sha"].(string); ok {
+			return "commit:" + sha
+		}
+	}
+	data, _ := json.Marshal(event)
+	return string(data)
+}
+
+// mergeDocuments unions the events and manual entries of documents of the
+// same day, keeping the first of each
+func mergeDocuments(docs ...reportDocument) (reportDocument, error) {
+	merged := reportDocument{Date: docs[0].Date, Events: []map[string]interface{}{}}
+	seenEvents := make(map[string]bool)
+	seenManual := make(map[documentEntry]bool)
+
+	for _, doc := range docs {
+		if doc.Date != merged.Date {
+			return merged, fmt.Errorf("cannot merge reports of %s and %s", merged.Date, doc.Date)
+		}
+		for _, event := range doc.Events {
+			if p := provenance(event); !seenEvents[p] {
+				seenEvents[p] = true
+				merged.Events = append(merged.Events, event)
+			}
+		}
+		for _, entry := range doc.Manual {
+			if !seenManual[entry] {
+				seenManual[entry] = true
+				merged.Manual = append(merged.Manual, entry)
+			}
+		}
+	}
+	return merged, nil
+}
+
+func readDocument(path string) (reportDocument, error) {
+	var doc reportDocument
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return doc, err
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return doc, fmt.Errorf("%s: %w", path, err)
+	}
+	if _, err := time.Parse(dateFormat, doc.Date); err != nil {
+		return doc, fmt.Errorf("%s: invalid date %q", path, doc.Date)
+	}
+	return doc, nil
+}
+
+func printDocument(doc reportDocument) error {
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// exportReport implements "export [--date YYYY-MM-DD]": print a stored day
+// as a report document
+func exportReport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	date := fs.String("date", time.Now().Format(dateFormat), "day of the report to export")
+	fs.Parse(args)
+
+	h, err := openHistory(historyPath())
+	if err != nil {
+		return err
+	}
+	defer h.Close()
+
+	r, err := h.get(*date)
+	if err != nil {
+		return err
+	}
+	manual, err := h.manual(*date)
+	if err != nil {
+		return err
+	}
+
+	doc := reportDocument{Date: r.date, Report: r.report, Events: r.events}
+	for _, entry := range manual {
+		doc.Manual = append(doc.Manual, documentEntry{Status: entry.status, Title: entry.title})
+	}
+	return printDocument(doc)
+}
+
+// mergeReports implements "merge [--save] a.json b.json ...": print the
+// union of report documents of the same day, and with --save store it as
+// that day's report in the history, merged with what is already stored
+func mergeReports(args []string) error {
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	save := fs.Bool("save", false, "store the merged report in the history")
+	fs.Parse(args)
+	if fs.NArg() < 2 {
+		return errors.New("usage: merge [--save] a.json b.json ...")
+	}
+
+	var docs []reportDocument
+	for _, path := range fs.Args() {
+		doc, err := readDocument(path)
+		if err != nil {
+			return err
+		}
+		docs = append(docs, doc)
+	}
+	date := docs[0].Date
+
+	h, err := openHistory(historyPath())
+	if err != nil {
+		return err
+	}
+	defer h.Close()
+
+	// The stored day takes part in the merge when saving
+	var stored []documentEntry
+	if *save {
+		if r, err := h.get(date); err == nil {
+			manual, err := h.manual(date)
+			if err != nil {
+				return err
+			}
+			for _, entry := range manual {
+				stored = append(stored, documentEntry{Status: entry.status, Title: entry.title})
+			}
+			docs = append([]reportDocument{{Date: date, Events: r.events, Manual: stored}}, docs...)
+		}
+	}
+
+	merged, err := mergeDocuments(docs...)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(configPath())
+	if err != nil {
+		return err
+	}
+	hidden, err := h.hiddenEntries(date)
+	if err != nil {
+		return err
+	}
+	tags, err := h.privacyTags(date)
+	if err != nil {
+		return err
+	}
+	hours, err := h.hourOverrides(date)
+	if err != nil {
+		return err
+	}
+	var manual []reportEntry
+	for _, entry := range merged.Manual {
+		manual = append(manual, reportEntry{status: entry.Status, title: entry.Title})
+	}
+	day, _ := time.ParseInLocation(dateFormat, date, time.Local)
+	rep := renderReport(cfg, reportOptions{}, day, merged.Events, manual, nil, nil)
+	rep.hide(hidden)
+	rep.tagPrivacy(tags)
+	rep.setHours(hours)
+	merged.Report = rep.render(nil)
+
+	if *save {
+		for _, entry := range merged.Manual {
+			if slices.Contains(stored, entry) {
+				continue
+			}
+			if err := h.addManual(date, reportEntry{status: entry.Status, title: entry.Title}); err != nil {
+				return err
+			}
+		}
+		if err := h.save(date, merged.Events, merged.Report); err != nil {
+			return err
+		}
+	}
+	return printDocument(merged)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Outcomes of a scheduled run, as the "result" label of report_runs_total
+const (
+	runSucceeded = "success"
+	runFailed    = "failure"
+	runSkipped   = "skipped"
+)
+
+// serveMetrics counts what serve mode does, for /metrics, /healthz and
+// /status
+type serveMetrics struct {
+	mu sync.Mutex
+	// Runs by schedule and result
+	runs map[[2]string]int
+	// Failed deliveries by schedule
+	deliveryFailures map[string]int
+	// Failed GitHub API requests
+	apiErrors int
+	// Last successful run and outcome of the last run, by schedule
+	lastSuccess map[string]time.Time
+	lastResult  map[string]string
+	// Health of each source of the report, and the last rate limit of each
+	// API by host
+	sources    map[string]*sourceHealth
+	rateLimits map[string]rateLimit
+}
+
+var metrics = &serveMetrics{
+	runs:             make(map[[2]string]int),
+	deliveryFailures: make(map[string]int),
+	lastSuccess:      make(map[string]time.Time),
+	lastResult:       make(map[string]string),
+	sources:          make(map[string]*sourceHealth),
+	rateLimits:       make(map[string]rateLimit),
+}
+
+// run counts a scheduled run with its result
+func (m *serveMetrics) run(schedule, result string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runs[[2]string{schedule, result}]++
+	m.lastResult[schedule] = result
+	if result == runSucceeded {
+		m.lastSuccess[schedule] = time.Now()
+	}
+}
+
+// deliveryFailed counts a delivery of the schedule that failed
+func (m *serveMetrics) deliveryFailed(schedule string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deliveryFailures[schedule]++
+}
+
+// apiError counts a GitHub API request that failed
+func (m *serveMetrics) apiError() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.apiErrors++
+}
+
+// handleMetrics serves the counters in the Prometheus text format
+func (m *serveMetrics) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+	b.WriteString("# HELP report_runs_total Scheduled report runs by result.\n# TYPE report_runs_total counter\n")
+	runs := make([][2]string, 0, len(m.runs))
+	for key := range m.runs {
+		runs = append(runs, key)
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i][0] < runs[j][0] || (runs[i][0] == runs[j][0] && runs[i][1] < runs[j][1])
+	})
+	for _, key := range runs {
+		fmt.Fprintf(&b, "report_runs_total{schedule=%q,result=%q} %d\n", key[0], key[1], m.runs[key])
+	}
+
+	b.WriteString("# HELP report_delivery_failures_total Failed report deliveries.\n# TYPE report_delivery_failures_total counter\n")
+	for _, schedule := range sortedKeys(m.deliveryFailures) {
+		fmt.Fprintf(&b, "report_delivery_failures_total{schedule=%q} %d\n", schedule, m.deliveryFailures[schedule])
+	}
+
+	b.WriteString("# HELP report_github_api_errors_total Failed GitHub API requests.\n# TYPE report_github_api_errors_total counter\n")
+	fmt.Fprintf(&b, "report_github_api_errors_total %d\n", m.apiErrors)
+
+	b.WriteString("# HELP report_source_failures_total Failed fetches of a source of the report.\n# TYPE report_source_failures_total counter\n")
+	for _, source := range sortedKeys(m.sources) {
+		fmt.Fprintf(&b, "report_source_failures_total{source=%q} %d\n", source, m.sources[source].failures)
+	}
+
+	b.WriteString("# HELP report_rate_limit_remaining Requests left in the rate limit of an API.\n# TYPE report_rate_limit_remaining gauge\n")
+	for _, api := range sortedKeys(m.rateLimits) {
+		fmt.Fprintf(&b, "report_rate_limit_remaining{api=%q} %d\n", api, m.rateLimits[api].remaining)
+	}
+
+	b.WriteString("# HELP report_last_success_timestamp_seconds Time of the last successful run.\n# TYPE report_last_success_timestamp_seconds gauge\n")
+	for _, schedule := range sortedKeys(m.lastSuccess) {
+		fmt.Fprintf(&b, "report_last_success_timestamp_seconds{schedule=%q} %d\n", schedule, m.lastSuccess[schedule].Unix())
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write([]byte(b.String()))
+}
+
+// handleHealthz answers 200 while the last run of every schedule succeeded,
+// and 503 naming the schedules whose last run failed otherwise
+func (m *serveMetrics) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	var failing []string
+	for _, schedule := range sortedKeys(m.lastResult) {
+		if m.lastResult[schedule] == runFailed {
+			failing = append(failing, schedule)
+		}
+	}
+	m.mu.Unlock()
+
+	if len(failing) > 0 {
+		http.Error(w, "last run failed: "+strings.Join(failing, ", "), http.StatusServiceUnavailable)
+		return
+	}
+	w.Write([]byte("ok\n"))
+}
+
+// serveMetricsOn serves /metrics, /healthz and /status without
+// authentication, for Prometheus, uptime checks and a look at which sources
+// have been failing
+func serveMetricsOn(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", metrics.handleMetrics)
+	mux.HandleFunc("GET /healthz", metrics.handleHealthz)
+	mux.HandleFunc("GET /status", metrics.handleStatus)
+
+	slog.Info("Serving metrics", "addr", addr)
+	fatal("metrics server stopped", "err", http.ListenAndServe(addr, mux))
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+// GitHub account the report is about
+type githubConfig struct {
+	// Login whose events are reported, replacing GITHUB_USERNAME
+	Username string `json:"username,omitempty"`
+	// API token, replacing GITHUB_TOKEN. Prefer "auth login", which keeps it
+	// in the OS keychain instead.
+	Token string `json:"token,omitempty"`
+}
+
+// An environment variable replaced by a config setting
+type legacyEnv struct {
+	name    string
+	setting string
+	field   func(cfg *config) *string
+}
+
+var legacyEnvs = []legacyEnv{
+	{"GITHUB_USERNAME", "github.username", func(cfg *config) *string { return &cfg.GitHub.Username }},
+	{"GITHUB_TOKEN", "github.token", func(cfg *config) *string { return &cfg.GitHub.Token }},
+	{"REPORT_FILE", "report_file", func(cfg *config) *string { return &cfg.ReportFile }},
+}
+
+// Deprecation warnings are logged once per run, not on every config reload
+var warnLegacyOnce sync.Once
+
+// applyLegacyEnv fills the settings left out of the config from the
+// environment variables they replace, warning that those are deprecated
+func (cfg *config) applyLegacyEnv() {
+	var used, ignored []legacyEnv
+	for _, env := range legacyEnvs {
+		value, field := os.Getenv(env.name), env.field(cfg)
+		switch {
+		case value == "" || value == *field:
+		case *field == "":
+			*field = value
+			used = append(used, env)
+		default:
+			ignored = append(ignored, env)
+		}
+	}
+
+	warnLegacyOnce.Do(func() {
+		for _, env := range used {
+			slog.Warn("Deprecated environment variable", "var", env.name, "setting", env.setting,
+				"hint", "run \"config migrate\" to write it to "+configPath())
+		}
+		for _, env := range ignored {
+			slog.Warn("Environment variable ignored, the config sets it", "var", env.name, "setting", env.setting)
+		}
+	})
+}
+
+// configCommand implements "config migrate [--with-token]", writing the
+// config equivalent to the config file and the environment variables it
+// replaces, and "config defaults" and "config show", see configsource.go
+func configCommand(args []string) error {
+	if len(args) > 0 && args[0] == "defaults" {
+		return configDefaults(args[1:])
+	}
+	if len(args) > 0 && args[0] == "show" {
+		return configShow(args[1:])
+	}
+	if len(args) == 0 || args[0] != "migrate" {
+		return errors.New("usage: config migrate [--with-token] | config defaults [--json] | config show [--resolved] [--json]")
+	}
+	fs := flag.NewFlagSet("config migrate", flag.ExitOnError)
+	withToken := fs.Bool("with-token", false, "also write GITHUB_TOKEN to the config file, in plain text")
+	fs.Parse(args[1:])
+
+	// Only the file and the environment variables it replaces are written:
+	// profiles, flags and defaults stay out of it
+	cfg := &config{}
+	data, err := os.ReadFile(configPath())
+	exists := !errors.Is(err, os.ErrNotExist)
+	if err != nil && exists {
+		return err
+	}
+	if exists {
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return fmt.Errorf("%s: %w", configPath(), err)
+		}
+	}
+	tokenFromEnv := cfg.GitHub.Token == ""
+	cfg.applyLegacyEnv()
+	if !exists {
+		if err := cfg.addDefaultSchedule(); err != nil {
+			return err
+		}
+	}
+	if tokenFromEnv && !*withToken {
+		cfg.GitHub.Token = ""
+	}
+
+	data, err = json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(configPath(), append(data, '\n'), 0600); err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote %s. GITHUB_USERNAME and REPORT_FILE can be removed from the environment.\n", configPath())
+	if os.Getenv("GITHUB_TOKEN") != "" && tokenFromEnv && !*withToken {
+		fmt.Println("Move GITHUB_TOKEN to the OS keychain with \"auth login\", or rerun with --with-token.")
+	}
+	return nil
+}
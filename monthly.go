@@ -0,0 +1,200 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Titles listed under 主な作業内容 for each project before "ほか N件"
+const monthlyTopTitles = 3
+
+// Project row of entries without a repository, such as entries added by hand
+const otherProject = "その他"
+
+// monthlyReportConfig is the client and author of the 月報
+type monthlyReportConfig struct {
+	// Addressee, e.g. "株式会社サンプル", written with 御中
+	Client string `json:"client,omitempty"`
+	// Name signing the report, the GitHub user by default
+	Author string `json:"author,omitempty"`
+}
+
+// projectMonth is the row of a project: the days it was worked on, its
+// man-days and its titles, the done ones first
+type projectMonth struct {
+	name          string
+	days          int
+	manDays       float64
+	titles, other []string
+}
+
+// monthlyProjects aggregates the stored reports of the month by project.
+// Each reported day counts as one man-day, split evenly between the
+// projects worked on that day. Hidden and private entries are left out.
+func monthlyProjects(h *historyStore, cfg *config, month time.Time) ([]projectMonth, int, error) {
+	records, err := h.list()
+	if err != nil {
+		return nil, 0, err
+	}
+	prefix := month.Format("2006-01")
+
+	byName := make(map[string]*projectMonth)
+	seen := make(map[string]bool)
+	var workdays int
+	for _, r := range slices.Backward(records) {
+		if !strings.HasPrefix(r.date, prefix) {
+			continue
+		}
+		stored, err := h.get(r.date)
+		if err != nil {
+			return nil, 0, err
+		}
+		day, _ := time.ParseInLocation(dateFormat, r.date, time.Local)
+		rep, err := h.renderStored(cfg, reportOptions{mode: modeFinal}, day, stored.events)
+		if err != nil {
+			return nil, 0, err
+		}
+		rep, _ = rep.restrictedTo(privacyTeam)
+
+		var projects []string
+		for _, entry := range rep.entries {
+			if entry.hidden {
+				continue
+			}
+			name := entry.repo
+			if name == "" {
+				name = otherProject
+			}
+			p, ok := byName[name]
+			if !ok {
+				p = &projectMonth{name: name}
+				byName[name] = p
+			}
+			if !slices.Contains(projects, name) {
+				projects = append(projects, name)
+				p.days++
+			}
+			if key := name + "\x00" + entry.title; !seen[key] {
+				seen[key] = true
+				if entry.status == "Done" {
+					p.titles = append(p.titles, entry.title)
+				} else {
+					p.other = append(p.other, entry.title)
+				}
+			}
+		}
+		if len(projects) == 0 {
+			continue
+		}
+		workdays++
+		for _, name := range projects {
+			byName[name].manDays += 1 / float64(len(projects))
+		}
+	}
+
+	var rows []projectMonth
+	for _, p := range byName {
+		p.titles = append(p.titles, p.other...)
+		rows = append(rows, *p)
+	}
+	// Most worked on first, その他 last
+	sort.Slice(rows, func(i, j int) bool {
+		if (rows[i].name == otherProject) != (rows[j].name == otherProject) {
+			return rows[j].name == otherProject
+		}
+		if rows[i].manDays != rows[j].manDays {
+			return rows[i].manDays > rows[j].manDays
+		}
+		return rows[i].name < rows[j].name
+	})
+	return rows, workdays, nil
+}
+
+// formatMonthlyReport renders the 月報: the greeting, the table of projects
+// with their days, man-days and main work, their totals and the closing
+func formatMonthlyReport(c monthlyReportConfig, month time.Time, rows []projectMonth, workdays int) string {
+	var b strings.Builder
+	if c.Client != "" {
+		fmt.Fprintf(&b, "%s 御中\n\n", c.Client)
+	}
+	fmt.Fprintf(&b, "%d年%d月 月報\n\n", month.Year(), month.Month())
+	b.WriteString("いつも大変お世話になっております。\n")
+	fmt.Fprintf(&b, "%d月の作業内容について、下記の通りご報告いたします。\n\n", month.Month())
+
+	b.WriteString("■ 作業実績\n")
+	b.WriteString("| プロジェクト | 作業日数 | 工数(人日) | 主な作業内容 |\n")
+	b.WriteString("|---|---|---|---|\n")
+	var total float64
+	for _, p := range rows {
+		work := strings.Join(p.titles[:min(len(p.titles), monthlyTopTitles)], "、")
+		if more := len(p.titles) - monthlyTopTitles; more > 0 {
+			work += fmt.Sprintf(" ほか%d件", more)
+		}
+		fmt.Fprintf(&b, "| %s | %d日 | %.1f | %s |\n", p.name, p.days, p.manDays, work)
+		total += p.manDays
+	}
+	fmt.Fprintf(&b, "| 合計 | %d日 | %.1f | |\n\n", workdays, total)
+
+	b.WriteString("■ 稼働日数\n")
+	fmt.Fprintf(&b, "%d日\n\n", workdays)
+	b.WriteString("ご不明な点がございましたら、お気軽にお問い合わせください。\n")
+	b.WriteString("引き続きどうぞよろしくお願いいたします。\n\n")
+	b.WriteString("以上\n")
+	if c.Author != "" {
+		fmt.Fprintf(&b, "\n%s\n", c.Author)
+	}
+	return b.String()
+}
+
+// monthlyReport implements "monthly-report [--month YYYY-MM] [--client NAME]
+// [--out FILE]", printing the month's 月報 built from the stored reports, or
+// writing it to the file
+func monthlyReport(args []string) error {
+	fs := flag.NewFlagSet("monthly-report", flag.ExitOnError)
+	monthFlag := fs.String("month", time.Now().Format("2006-01"), "month of the report")
+	client := fs.String("client", "", "addressee, monthly_report.client of the config by default")
+	out := fs.String("out", "", "file the report is written to instead of stdout")
+	fs.Parse(args)
+
+	month, err := time.ParseInLocation("2006-01", *monthFlag, time.Local)
+	if err != nil {
+		return fmt.Errorf("invalid month %q, expected YYYY-MM", *monthFlag)
+	}
+	cfg, err := loadConfig(configPath())
+	if err != nil {
+		return err
+	}
+	c := cfg.MonthlyReport
+	if *client != "" {
+		c.Client = *client
+	}
+	if c.Author == "" {
+		c.Author = cfg.GitHub.Username
+	}
+
+	h, err := openHistory(historyPath())
+	if err != nil {
+		return err
+	}
+	defer h.Close()
+	rows, workdays, err := monthlyProjects(h, cfg, month)
+	if err != nil {
+		return err
+	}
+	if workdays == 0 {
+		return errors.New("no report stored for " + month.Format("2006-01"))
+	}
+
+	report := formatMonthlyReport(c, month, rows, workdays)
+	if *out == "" {
+		fmt.Print(report)
+		return nil
+	}
+	return os.WriteFile(*out, []byte(report), 0644)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Default Next line when there is nothing more specific to plan
+const defaultNext = "Continue with assigned task and R&D"
+
+// Number of Next items planned when next_limit isn't set
+const defaultNextLimit = 5
+
+// Age in days from which Next items say how long they've been waiting,
+// when next_age_days isn't set
+const defaultNextAgeDays = 7
+
+// A candidate Next item, and since when it's been waiting on me
+type nextCandidate struct {
+	line  reportLine
+	issue searchIssue
+	// Issue timeline event starting the wait, e.g. "assigned", or "" when
+	// it's been waiting since the issue was opened
+	event string
+	since time.Time
+}
+
+// waitingSince returns when the latest event of the issue's timeline for
+// me happened, e.g. when it was assigned to me, the issue's creation if
+// there is none
+func waitingSince(ctx context.Context, issue searchIssue, event, username, token string) (time.Time, error) {
+	since, _ := time.Parse(time.RFC3339, issue.CreatedAt)
+	if event == "" {
+		return since, nil
+	}
+
+	var events []issueEvent
+	u := fmt.Sprintf("%s/issues/%d/events?per_page=100", issue.RepositoryURL, issue.Number)
+	if err := githubGet(ctx, u, token, &events); err != nil {
+		return since, err
+	}
+	for _, e := range events {
+		if e.Event == event && (e.Assignee.Login == username || e.RequestedReviewer.Login == username) {
+			if t, err := time.Parse(time.RFC3339, e.CreatedAt); err == nil && t.After(since) {
+				since = t
+			}
+		}
+	}
+	return since, nil
+}
+
+// ageNote says how long an item has been waiting, e.g. "assigned 12 days ago"
+func ageNote(event string, days int) string {
+	switch event {
+	case "assigned":
+		return fmt.Sprintf("assigned %d days ago", days)
+	case "review_requested":
+		return fmt.Sprintf("review requested %d days ago", days)
+	}
+	return fmt.Sprintf("opened %d days ago", days)
+}
+
+// getNextItems derives tomorrow's plan from open pull requests and issues:
+// reviews requested from me, my PRs with requested changes or failing
+// checks, and issues assigned to me. The items that have waited the
+// longest come first, and those waiting for ageDays or more say since
+// when. At most limit items are returned.
+func getNextItems(ctx context.Context, now time.Time, username, token string, limit, ageDays int) ([]reportLine, error) {
+	queries := []struct {
+		query, format, event string
+	}{
+		{"type:pr is:open review-requested:%s", "Review %s", "review_requested"},
+		{"type:pr is:open author:%s review:changes_requested", "Address requested changes on %s", ""},
+		{"type:pr is:open author:%s status:failure", "Fix failing checks on %s", ""},
+		{"type:issue is:open assignee:%s", "Work on %s", "assigned"},
+	}
+
+	var candidates []nextCandidate
+	for _, q := range queries {
+		prs, err := searchIssues(ctx, fmt.Sprintf(q.query, username), token)
+		if err != nil {
+			return nil, err
+		}
+		for _, pr := range prs {
+			line := reportLine{text: fmt.Sprintf(q.format, pr.Title), repo: pr.repoName()}
+			candidates = append(candidates, nextCandidate{line: line, issue: pr, event: q.event})
+		}
+	}
+
+	err := runPool(ctx, fetchConcurrency, len(candidates), func(ctx context.Context, i int) (err error) {
+		c := &candidates[i]
+		c.since, err = waitingSince(ctx, c.issue, c.event, username, token)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Oldest first, in the order of the queries on the same time
+	slices.SortStableFunc(candidates, func(a, b nextCandidate) int {
+		return a.since.Compare(b.since)
+	})
+
+	var items []reportLine
+	for _, c := range candidates[:min(limit, len(candidates))] {
+		if days := int(now.Sub(c.since).Hours() / 24); !c.since.IsZero() && days >= ageDays {
+			c.line.text += fmt.Sprintf(" (%s)", ageNote(c.event, days))
+		}
+		items = append(items, c.line)
+	}
+	return items, nil
+}
+
+// nextLimit returns how many items getNextItems plans, the next_items of
+// the config taking their place first
+func (cfg *config) nextLimit() int {
+	limit := cfg.NextLimit
+	if limit == 0 {
+		limit = defaultNextLimit
+	}
+	return max(limit-len(cfg.NextItems), 0)
+}
+
+// nextAgeDays returns the age in days from which Next items say how long
+// they've been waiting
+func (cfg *config) nextAgeDays() int {
+	if cfg.NextAgeDays == 0 {
+		return defaultNextAgeDays
+	}
+	return cfg.NextAgeDays
+}
+
+// manualNext returns the next_items of the config as Next lines
+func (cfg *config) manualNext() []reportLine {
+	var lines []reportLine
+	for _, text := range cfg.NextItems {
+		lines = append(lines, reportLine{text: text})
+	}
+	return lines
+}
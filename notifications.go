@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// A notification thread as returned by the notifications API
+type notificationThread struct {
+	ID         string `json:"id"`
+	Unread     bool   `json:"unread"`
+	LastReadAt string `json:"last_read_at"`
+}
+
+// notificationsTriage counts the notification threads updated during the
+// day that I read, those left unread, and the ones I read and unsubscribed
+// from. It needs a token with the notifications scope.
+func notificationsTriage(ctx context.Context, date, token string) (read, unread, unsubscribed int, err error) {
+	for page := 1; ; page++ {
+		var threads []notificationThread
+		u := fmt.Sprintf("%s/notifications?all=true&since=%sT00:00:00Z&per_page=50&page=%d", githubAPI, date, page)
+		if err := githubGet(ctx, u, token, &threads); err != nil {
+			return 0, 0, 0, err
+		}
+
+		for _, t := range threads {
+			if t.Unread {
+				unread++
+				continue
+			}
+			if !onDate(t.LastReadAt, date) {
+				continue
+			}
+			read++
+
+			// Unsubscribing ignores the thread; threads I was never
+			// subscribed to on their own answer 404
+			var subscription struct {
+				Ignored bool `json:"ignored"`
+			}
+			err := githubGet(ctx, githubAPI+"/notifications/threads/"+t.ID+"/subscription", token, &subscription)
+			if err != nil && !errors.Is(err, errNotFound) {
+				return 0, 0, 0, err
+			}
+			if subscription.Ignored {
+				unsubscribed++
+			}
+		}
+		if len(threads) < 50 {
+			return read, unread, unsubscribed, nil
+		}
+	}
+}
+
+// getNotificationsTriage is the line of the Notifications section, e.g.
+// "Triaged 14 threads, unsubscribed from 3, 5 left unread"
+func getNotificationsTriage(ctx context.Context, date, token string) ([]reportLine, error) {
+	if err := needsNotifications(token); err != nil {
+		slog.Warn("Notifications left out of the report", "err", err)
+		return nil, nil
+	}
+	read, unread, unsubscribed, err := notificationsTriage(ctx, date, token)
+	if err != nil {
+		return nil, err
+	}
+	if read == 0 && unread == 0 {
+		return nil, nil
+	}
+
+	parts := []string{fmt.Sprintf("Triaged %d threads", read)}
+	if unsubscribed > 0 {
+		parts = append(parts, fmt.Sprintf("unsubscribed from %d", unsubscribed))
+	}
+	parts = append(parts, fmt.Sprintf("%d left unread", unread))
+	return []reportLine{{text: strings.Join(parts, ", ")}}, nil
+}
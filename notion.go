@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+)
+
+const (
+	notionAPI     = "https://api.notion.com/v1"
+	notionVersion = "2022-06-28"
+	// Notion accepts at most 100 blocks per request
+	notionMaxBlocks = 100
+)
+
+func notionHeaders(t target) map[string]string {
+	token := t.Token
+	if token == "" {
+		token = os.Getenv("NOTION_TOKEN")
+	}
+	return map[string]string{
+		"Authorization":  "Bearer " + token,
+		"Notion-Version": notionVersion,
+	}
+}
+
+func notionText(content string) []map[string]interface{} {
+	return []map[string]interface{}{{"type": "text", "text": map[string]string{"content": content}}}
+}
+
+// notionBlocks turns the report lines into blocks: "Section:" lines become
+// headings and the other lines bullet points
+func notionBlocks(report string) []map[string]interface{} {
+	var blocks []map[string]interface{}
+	for _, line := range strings.Split(report, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		kind := "bulleted_list_item"
+		if strings.HasSuffix(line, ":") {
+			kind = "heading_3"
+			line = strings.TrimSuffix(line, ":")
+		}
+		blocks = append(blocks, map[string]interface{}{
+			"object": "block",
+			"type":   kind,
+			kind:     map[string]interface{}{"rich_text": notionText(line)},
+		})
+	}
+	return blocks
+}
+
+// postToNotion creates a page for the report in the target's database, with
+// the date in the date property and the report lines as the page content. A
+// report replacing the day's earlier one archives the page created for it.
+func postToNotion(ctx context.Context, t target, rep *generatedReport, report string) error {
+	delivery := "notion:" + t.DatabaseID
+	if rep.replaces {
+		previous, err := deliveredRef(rep.date, delivery, "page")
+		if err != nil {
+			return err
+		}
+		if previous != "" {
+			if err := sendJSON(ctx, "PATCH", notionAPI+"/pages/"+previous, notionHeaders(t), map[string]interface{}{"archived": true}, nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	titleProperty, dateProperty := t.TitleProperty, t.DateProperty
+	if titleProperty == "" {
+		titleProperty = "Name"
+	}
+	if dateProperty == "" {
+		dateProperty = "Date"
+	}
+
+	// The header line is the page title
+	title, _, _ := strings.Cut(report, "\n")
+	blocks := notionBlocks(report)
+	first := blocks[:min(len(blocks), notionMaxBlocks)]
+
+	var page struct {
+		ID string `json:"id"`
+	}
+	err := sendJSON(ctx, "POST", notionAPI+"/pages", notionHeaders(t), map[string]interface{}{
+		"parent": map[string]string{"database_id": t.DatabaseID},
+		"properties": map[string]interface{}{
+			titleProperty: map[string]interface{}{"title": notionText(revisionTitle(rep, strings.TrimSuffix(title, ":")))},
+			dateProperty:  map[string]interface{}{"date": map[string]string{"start": rep.date}},
+		},
+		"children": first,
+	}, &page)
+	if err != nil {
+		return err
+	}
+	if err := recordDelivery(rep.date, delivery, "page", page.ID); err != nil {
+		return err
+	}
+
+	// Append the remaining blocks in batches
+	for rest := blocks[len(first):]; len(rest) > 0; {
+		batch := rest[:min(len(rest), notionMaxBlocks)]
+		rest = rest[len(batch):]
+		if err := sendJSON(ctx, "PATCH", notionAPI+"/blocks/"+page.ID+"/children", notionHeaders(t), map[string]interface{}{"children": batch}, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
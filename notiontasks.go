@@ -0,0 +1,175 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// Kinds of the status property of Notion tasks
+const (
+	notionStatus   = "status"
+	notionSelect   = "select"
+	notionCheckbox = "checkbox"
+)
+
+// notionTasksConfig reads my tasks from a Notion database into Next, and
+// checks off the tasks of the pull requests merged today
+type notionTasksConfig struct {
+	DatabaseID string `json:"database_id"`
+	// Integration token, NOTION_TOKEN when not set
+	Token string `json:"token,omitempty"`
+	// Names of the task properties
+	Properties notionTaskProperties `json:"properties,omitempty"`
+	// Values of the status property of the tasks being worked on,
+	// "In progress" by default, and of the tasks done, "Done" by default.
+	// Checkboxes are checked when done.
+	Doing string `json:"doing,omitempty"`
+	Done  string `json:"done,omitempty"`
+}
+
+// notionTaskProperties maps the task properties to those of the database
+type notionTaskProperties struct {
+	// Title, "Name" by default
+	Title string `json:"title,omitempty"`
+	// Status, "Status" by default, of the given kind: "status" (default),
+	// "select" or "checkbox"
+	Status     string `json:"status,omitempty"`
+	StatusType string `json:"status_type,omitempty"`
+	// Due date, "Due" by default
+	Due string `json:"due,omitempty"`
+	// URL property with the link of the task's pull request, e.g.
+	// "https://github.com/octocat/hello-world/pull/12"; tasks are only
+	// checked off when set
+	PullRequest string `json:"pull_request,omitempty"`
+}
+
+func (c *notionTasksConfig) validate() error {
+	if c.DatabaseID == "" {
+		return errors.New("notion_tasks: database_id is required")
+	}
+	switch c.statusType() {
+	case notionStatus, notionSelect, notionCheckbox:
+	default:
+		return fmt.Errorf("notion_tasks: properties: status_type must be %s, %s or %s", notionStatus, notionSelect, notionCheckbox)
+	}
+	return nil
+}
+
+func (c *notionTasksConfig) statusType() string {
+	return cmp.Or(c.Properties.StatusType, notionStatus)
+}
+
+// statusFilter is the filter of the tasks whose status is or isn't the value
+func (c *notionTasksConfig) statusFilter(value string, is bool) map[string]interface{} {
+	status := cmp.Or(c.Properties.Status, "Status")
+	if c.statusType() == notionCheckbox {
+		return map[string]interface{}{"property": status, "checkbox": map[string]bool{"equals": is}}
+	}
+	op := "does_not_equal"
+	if is {
+		op = "equals"
+	}
+	return map[string]interface{}{"property": status, c.statusType(): map[string]string{op: value}}
+}
+
+// A task page as returned by database queries
+type notionTask struct {
+	ID         string `json:"id"`
+	Properties map[string]struct {
+		Title []struct {
+			PlainText string `json:"plain_text"`
+		} `json:"title"`
+		Date *struct {
+			Start string `json:"start"`
+		} `json:"date"`
+	} `json:"properties"`
+}
+
+func (c *notionTasksConfig) query(ctx context.Context, filter map[string]interface{}) ([]notionTask, error) {
+	var result struct {
+		Results []notionTask `json:"results"`
+	}
+	err := sendJSON(ctx, "POST", notionAPI+"/databases/"+c.DatabaseID+"/query", notionHeaders(target{Token: c.Token}), map[string]interface{}{"filter": filter}, &result)
+	return result.Results, err
+}
+
+func (c *notionTasksConfig) title(task notionTask) string {
+	var title string
+	for _, text := range task.Properties[cmp.Or(c.Properties.Title, "Name")].Title {
+		title += text.PlainText
+	}
+	return title
+}
+
+// getNotionTasks returns the Next items of the tasks not done yet that are
+// due by the day, "Finish <task>", or being worked on, "Continue <task>"
+func getNotionTasks(ctx context.Context, c *notionTasksConfig, day string) ([]reportLine, error) {
+	if offline {
+		return nil, errOffline
+	}
+	done := cmp.Or(c.Done, "Done")
+	due := map[string]interface{}{"property": cmp.Or(c.Properties.Due, "Due"), "date": map[string]string{"on_or_before": day}}
+	open := []interface{}{due}
+	if c.statusType() != notionCheckbox {
+		open = append(open, c.statusFilter(cmp.Or(c.Doing, "In progress"), true))
+	}
+	tasks, err := c.query(ctx, map[string]interface{}{
+		"and": []interface{}{map[string]interface{}{"or": open}, c.statusFilter(done, false)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []reportLine
+	for _, task := range tasks {
+		format := "Continue %s"
+		// Dates may have a time, e.g. "2024-05-10T15:00:00.000+09:00"
+		if date := task.Properties[cmp.Or(c.Properties.Due, "Due")].Date; date != nil && date.Start[:min(len(date.Start), len(day))] <= day {
+			format = "Finish %s"
+		}
+		if title := c.title(task); title != "" {
+			lines = append(lines, reportLine{text: fmt.Sprintf(format, title)})
+		}
+	}
+	return lines, nil
+}
+
+// completeNotionTasks checks off the tasks whose pull request is one of mine
+// merged in the report
+func completeNotionTasks(ctx context.Context, c *notionTasksConfig, rep *generatedReport) error {
+	if c.Properties.PullRequest == "" {
+		return nil
+	}
+	done := cmp.Or(c.Done, "Done")
+	status := cmp.Or(c.Properties.Status, "Status")
+	var value interface{} = map[string]string{"name": done}
+	if c.statusType() == notionCheckbox {
+		value = true
+	}
+
+	for _, entry := range rep.entries {
+		if entry.status != "Done" || entry.eventType != "PullRequestEvent" || entry.url() == "" {
+			continue
+		}
+		tasks, err := c.query(ctx, map[string]interface{}{
+			"and": []interface{}{
+				map[string]interface{}{"property": c.Properties.PullRequest, "url": map[string]string{"equals": entry.url()}},
+				c.statusFilter(done, false),
+			},
+		})
+		if err != nil {
+			return err
+		}
+		for _, task := range tasks {
+			properties := map[string]interface{}{status: map[string]interface{}{c.statusType(): value}}
+			if err := sendJSON(ctx, "PATCH", notionAPI+"/pages/"+task.ID, notionHeaders(target{Token: c.Token}), map[string]interface{}{"properties": properties}, nil); err != nil {
+				return err
+			}
+			slog.Info("Checked off Notion task", "task", c.title(task), "pr", entry.key())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// When set, GitHub API requests are answered from the cache only and other
+// network sources are skipped. See --offline.
+var offline bool
+
+// Returned offline by requests whose response isn't cached
+var errOffline = errors.New("not available offline")
+
+// Oldest cached response used by the requests of a context
+type cacheAge struct {
+	mu     sync.Mutex
+	oldest time.Time
+}
+
+type cacheAgeKey struct{}
+
+func withCacheAge(ctx context.Context) (context.Context, *cacheAge) {
+	age := &cacheAge{}
+	return context.WithValue(ctx, cacheAgeKey{}, age), age
+}
+
+// noteCacheAge records that a response cached at fetched was used
+func noteCacheAge(ctx context.Context, fetched time.Time) {
+	noteSourceUsed(ctx)
+	age, ok := ctx.Value(cacheAgeKey{}).(*cacheAge)
+	if !ok {
+		return
+	}
+	age.mu.Lock()
+	defer age.mu.Unlock()
+	if age.oldest.IsZero() || fetched.Before(age.oldest) {
+		age.oldest = fetched
+	}
+}
+
+// offlineNotes collects which parts of an offline report are stale or
+// missing, for its Offline section
+type offlineNotes struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (n *offlineNotes) add(format string, args ...interface{}) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.lines = append(n.lines, fmt.Sprintf(format, args...))
+}
+
+// source wraps a source of the report so that offline, a source that isn't
+// cached is left out instead of failing the report, and a cached one is
+// noted with the time it was fetched. Its health is kept for /status.
+func (n *offlineNotes) source(name string, fetch func(ctx context.Context) error) func(ctx context.Context) error {
+	fetch = metrics.tracked(name, fetch)
+	if !offline {
+		return fetch
+	}
+	return func(ctx context.Context) error {
+		ctx, age := withCacheAge(ctx)
+		err := fetch(ctx)
+		switch {
+		case errors.Is(err, errOffline):
+			n.add("%s: not available offline", name)
+			return nil
+		case err == nil && !age.oldest.IsZero():
+			n.add("%s: as of %s", name, age.oldest.Local().Format("Jan 2 15:04"))
+		}
+		return err
+	}
+}
+
+// section lists the notes, empty when online
+func (n *offlineNotes) section() reportSection {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	sort.Strings(n.lines)
+	section := reportSection{title: "Offline, possibly stale"}
+	for _, line := range n.lines {
+		section.lines = append(section.lines, reportLine{text: line})
+	}
+	return section
+}
+
+// storedEvents returns the events stored for the day by an earlier run,
+// with the time they were stored
+func storedEvents(date string) ([]map[string]interface{}, time.Time, error) {
+	h, err := openHistory(historyPath())
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer h.Close()
+
+	record, err := h.get(date)
+	if err != nil {
+		return nil, time.Time{}, errOffline
+	}
+	return record.events, record.createdAt, nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// pdfConfig lays out PDF and HTML reports
+type pdfConfig struct {
+	// Company name printed at the top of every page
+	Company string `json:"company,omitempty"`
+	// PNG or JPEG logo printed next to the company name
+	Logo string `json:"logo,omitempty"`
+	// Add a line for the signature and date under the report
+	Signature bool `json:"signature,omitempty"`
+	// Theme of HTML reports, built in "plain" (default), "dark" or
+	// "branded", or one added under themes/ of the assets, and a CSS file
+	// added after it. PDFs take the accent color of the theme.
+	Theme string `json:"theme,omitempty"`
+	CSS   string `json:"css,omitempty"`
+}
+
+// Widths in mm of the status, title and repository columns of the task
+// table, filling an A4 page between 15 mm margins
+var pdfColumnWidths = [3]float64{35, 105, 40}
+
+const pdfLineHeight = 6
+
+// renderPDF lays the report out as an A4 PDF: the company header, the date,
+// a table of the meetings and entries, then the sections and Next
+func (r *generatedReport) renderPDF(c pdfConfig, orgs []string) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	// The core fonts only cover Western European characters
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	// The theme's accent color, if any, for the headings and table head
+	headingColor := [3]int{0, 0, 0}
+	headFill, headText := [3]int{230, 230, 230}, [3]int{0, 0, 0}
+	if accent, ok := c.accent(); ok {
+		headingColor, headFill, headText = accent, accent, [3]int{255, 255, 255}
+	}
+
+	pdf.SetHeaderFunc(func() {
+		if c.Logo != "" {
+			pdf.ImageOptions(c.Logo, 165, 10, 30, 0, false, fpdf.ImageOptions{ReadDpi: true}, 0, "")
+		}
+		pdf.SetFont("Helvetica", "B", 14)
+		pdf.CellFormat(0, 10, tr(c.Company), "", 1, "L", false, 0, "")
+		pdf.Ln(4)
+	})
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.CellFormat(0, 5, fmt.Sprintf("Page %d", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.SetTextColor(headingColor[0], headingColor[1], headingColor[2])
+	pdf.CellFormat(0, 8, tr("Daily report, "+r.header), "", 1, "L", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+	pdf.Ln(2)
+
+	// Task table
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.SetFillColor(headFill[0], headFill[1], headFill[2])
+	pdf.SetTextColor(headText[0], headText[1], headText[2])
+	for i, heading := range []string{"Status", "Task", "Repository"} {
+		pdf.CellFormat(pdfColumnWidths[i], pdfLineHeight+1, heading, "1", 0, "L", true, 0, "")
+	}
+	pdf.SetTextColor(0, 0, 0)
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 10)
+	incidents, entries := splitIncidents(filterEntries(r.entries, orgs))
+	var rows [][3]string
+	for _, entry := range incidents {
+		rows = append(rows, [3]string{entry.status, fmt.Sprintf("[%s] %s", entry.severity, entry.title), entry.repo})
+	}
+	for _, entry := range append(append([]reportEntry{}, r.meetings...), entries...) {
+		rows = append(rows, [3]string{entry.status, entry.title, entry.repo})
+	}
+	for _, row := range rows {
+		pdfRow(pdf, tr, row)
+	}
+
+	// Sections and Next as lists
+	next := filterLines(r.next, orgs)
+	if len(next) == 0 {
+		next = []reportLine{{text: defaultNext}}
+	}
+	for _, section := range append(filterSections(r.sections, orgs), reportSection{title: "Next", lines: next}) {
+		if len(section.lines) == 0 {
+			continue
+		}
+		pdf.Ln(4)
+		pdf.SetFont("Helvetica", "B", 11)
+		pdf.SetTextColor(headingColor[0], headingColor[1], headingColor[2])
+		pdf.CellFormat(0, 7, tr(section.title), "", 1, "L", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+		pdf.SetFont("Helvetica", "", 10)
+		for _, line := range section.lines {
+			pdf.MultiCell(0, pdfLineHeight, tr("- "+line.text), "", "L", false)
+		}
+	}
+
+	if c.Signature {
+		pdf.Ln(16)
+		pdf.CellFormat(90, pdfLineHeight, "Signature: ______________________", "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, pdfLineHeight, "Date: ______________", "", 1, "L", false, 0, "")
+	}
+
+	var b bytes.Buffer
+	if err := pdf.Output(&b); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// pdfRow adds a table row, as high as its longest cell once wrapped
+func pdfRow(pdf *fpdf.Fpdf, tr func(string) string, cells [3]string) {
+	var lines [3][]string
+	height := float64(pdfLineHeight)
+	for i, cell := range cells {
+		lines[i] = pdf.SplitText(cell, pdfColumnWidths[i]-2)
+		height = max(height, float64(len(lines[i])*pdfLineHeight))
+	}
+
+	// Start the row on a new page when it wouldn't fit
+	_, pageHeight := pdf.GetPageSize()
+	_, _, _, bottom := pdf.GetMargins()
+	if pdf.GetY()+height > pageHeight-bottom {
+		pdf.AddPage()
+	}
+
+	x, y := pdf.GetXY()
+	for i := range cells {
+		pdf.Rect(x, y, pdfColumnWidths[i], height, "D")
+		for j, line := range lines[i] {
+			pdf.SetXY(x+1, y+float64(j*pdfLineHeight))
+			pdf.CellFormat(pdfColumnWidths[i]-2, pdfLineHeight, tr(line), "", 0, "L", false, 0, "")
+		}
+		x += pdfColumnWidths[i]
+	}
+	pdf.SetXY(15, y+height)
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+)
+
+// Clipboard tools tried by copyToClipboard, in order
+var clipboardCommands = [][]string{{"pbcopy"}}
+
+// openURL opens the URL in the default browser
+func openURL(url string) error {
+	return exec.Command("open", url).Start()
+}
+
+// shellCommand runs a command line in the shell
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
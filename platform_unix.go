@@ -0,0 +1,21 @@
+//go:build !darwin && !windows
+
+package main
+
+import (
+	"context"
+	"os/exec"
+)
+
+// Clipboard tools tried by copyToClipboard, in order: Wayland, then X11
+var clipboardCommands = [][]string{{"wl-copy"}, {"xclip", "-selection", "clipboard"}, {"xsel", "--clipboard", "--input"}}
+
+// openURL opens the URL in the default browser
+func openURL(url string) error {
+	return exec.Command("xdg-open", url).Start()
+}
+
+// shellCommand runs a command line in the shell
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
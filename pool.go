@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// Sources of a report fetched at the same time
+const fetchConcurrency = 4
+
+// runPool runs task(ctx, i) for each i in [0, n), at most limit at a time.
+// The first error cancels the context passed to the other tasks, stops
+// the tasks not started yet and is returned. Tasks write their results to
+// their own index, so the results don't depend on the order tasks finish in.
+func runPool(ctx context.Context, limit, n int, task func(ctx context.Context, i int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var once sync.Once
+	var firstErr error
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, limit)
+
+	for i := 0; i < n; i++ {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			if err := task(ctx, i); err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if firstErr == nil {
+		return ctx.Err()
+	}
+	return firstErr
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Privacy levels of entries, from the least to the most private
+const (
+	privacyPublic  = "public"
+	privacyTeam    = "team"
+	privacyPrivate = "private"
+)
+
+var privacyLevels = []string{privacyPublic, privacyTeam, privacyPrivate}
+
+// Position of the level in privacyLevels; entries without one are team
+func privacyRank(level string) int {
+	if i := slices.Index(privacyLevels, level); i >= 0 {
+		return i
+	}
+	return 1
+}
+
+func validPrivacy(level string) error {
+	if !slices.Contains(privacyLevels, level) {
+		return fmt.Errorf("unknown privacy %q, expected public, team or private", level)
+	}
+	return nil
+}
+
+// privacyRule sets the privacy of the entries matching all of its patterns
+type privacyRule struct {
+	// Repository pattern, e.g. "octocat/*"
+	Repo string `json:"repo,omitempty"`
+	// Part of the title, ignoring case, e.g. "salary"
+	Title string `json:"title,omitempty"`
+	Level string `json:"level"`
+}
+
+func (r privacyRule) matches(entry reportEntry) bool {
+	if r.Repo != "" {
+		if ok, _ := path.Match(r.Repo, entry.repo); !ok {
+			return false
+		}
+	}
+	return r.Title == "" || strings.Contains(strings.ToLower(entry.title), strings.ToLower(r.Title))
+}
+
+// classifyPrivacy sets the privacy of each entry from the first rule that
+// matches it, the default privacy otherwise
+func classifyPrivacy(cfg *config, entries []reportEntry) {
+	for i := range entries {
+		entries[i].privacy = cfg.defaultPrivacy()
+		for _, rule := range cfg.PrivacyRules {
+			if rule.matches(entries[i]) {
+				entries[i].privacy = rule.Level
+				break
+			}
+		}
+	}
+}
+
+func (cfg *config) defaultPrivacy() string {
+	if cfg.DefaultPrivacy != "" {
+		return cfg.DefaultPrivacy
+	}
+	return privacyTeam
+}
+
+// privacyTags returns the privacy set by hand for the entries of the day,
+// by key, the last one set for each
+func (h *historyStore) privacyTags(date string) (map[string]string, error) {
+	changes, err := h.entryChanges(date)
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[string]string)
+	for _, c := range changes {
+		if slices.Contains(privacyLevels, c.action) {
+			tags[c.entry] = c.action
+		}
+	}
+	return tags, nil
+}
+
+// Load the privacy set by hand for the entries of the day's report
+func loadPrivacyTags(date string) (map[string]string, error) {
+	h, err := openHistory(historyPath())
+	if err != nil {
+		return nil, err
+	}
+	defer h.Close()
+
+	return h.privacyTags(date)
+}
+
+// tagPrivacy overrides the privacy of the entries with the given keys
+func (r *generatedReport) tagPrivacy(tags map[string]string) {
+	for i := range r.entries {
+		if level, ok := tags[r.entries[i].key()]; ok {
+			r.entries[i].privacy = level
+		}
+	}
+}
+
+// The most private level a target receives: private for files and stdout,
+// which stay on this machine, team for the others
+func (t target) privacyLevel() string {
+	if t.Privacy != "" {
+		return t.Privacy
+	}
+	if t.Type == "file" || t.Type == "stdout" {
+		return privacyPrivate
+	}
+	return privacyTeam
+}
+
+// restrictedTo returns the report as seen by a target receiving entries up
+// to the privacy level, with the more private entries hidden, and whether
+// any was
+func (r *generatedReport) restrictedTo(level string) (*generatedReport, bool) {
+	restricted := *r
+	restricted.entries = slices.Clone(r.entries)
+	withheld := false
+	for i, entry := range restricted.entries {
+		if !entry.hidden && privacyRank(entry.privacy) > privacyRank(level) {
+			restricted.entries[i].hidden = true
+			withheld = true
+		}
+	}
+	return &restricted, withheld
+}
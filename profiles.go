@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Profile the config is loaded with, see --profile
+var activeProfile string
+
+// profile is a named set of settings replacing those of the config, e.g. one
+// per client, chosen with --profile. Settings left out keep the config's.
+type profile struct {
+	// GitHub account the profile's report is about
+	GitHub githubConfig `json:"github,omitempty"`
+	// Repositories the profile reports on, see repos
+	Repos []string `json:"repos,omitempty"`
+	// Layout, file and targets of the profile's report. Targets are added
+	// to those of the config, replacing the ones of the same name.
+	ReportTemplate string            `json:"report_template,omitempty"`
+	ReportFile     string            `json:"report_file,omitempty"`
+	Deliver        []string          `json:"deliver,omitempty"`
+	Targets        map[string]target `json:"targets,omitempty"`
+	Schedules      []schedule        `json:"schedules,omitempty"`
+}
+
+// applyProfile replaces the settings of the config with those the profile
+// sets
+func (cfg *config) applyProfile(name string) error {
+	p, ok := cfg.Profiles[name]
+	if !ok && len(cfg.Profiles) == 0 {
+		return fmt.Errorf("unknown profile %q, the config has no profiles", name)
+	}
+	if !ok {
+		return fmt.Errorf("unknown profile %q, expected one of %s", name, strings.Join(sortedKeys(cfg.Profiles), ", "))
+	}
+	if p.GitHub.Username != "" {
+		cfg.GitHub = p.GitHub
+	}
+	if p.Repos != nil {
+		cfg.Repos = p.Repos
+	}
+	if p.ReportTemplate != "" {
+		cfg.ReportTemplate = p.ReportTemplate
+	}
+	if p.ReportFile != "" {
+		cfg.ReportFile = p.ReportFile
+	}
+	if p.Deliver != nil {
+		cfg.Deliver = p.Deliver
+	}
+	if p.Targets != nil {
+		targets := maps.Clone(cfg.Targets)
+		if targets == nil {
+			targets = make(map[string]target)
+		}
+		maps.Copy(targets, p.Targets)
+		cfg.Targets = targets
+	}
+	if p.Schedules != nil {
+		cfg.Schedules = p.Schedules
+	}
+	return nil
+}
+
+// profilePath returns the file of the active profile next to the given one,
+// e.g. "history-clientA.db" for "history.db", so that profiles keep their
+// reports apart
+func profilePath(file string) string {
+	if activeProfile == "" {
+		return file
+	}
+	ext := filepath.Ext(file)
+	return strings.TrimSuffix(file, ext) + "-" + activeProfile + ext
+}
+
+// reportsOn reports whether the repository is one of the repos of the
+// config, patterns such as "acme/*" matching whole organizations. Without
+// repos, and for entries without a repository, it is true.
+func (cfg *config) reportsOn(repo string) bool {
+	if len(cfg.Repos) == 0 || repo == "" {
+		return true
+	}
+	return slices.ContainsFunc(cfg.Repos, func(pattern string) bool {
+		ok, _ := path.Match(pattern, repo)
+		return ok
+	})
+}
+
+// validRepos checks the repos patterns
+func validRepos(repos []string) error {
+	for _, pattern := range repos {
+		if _, err := path.Match(pattern, ""); err != nil || !strings.Contains(pattern, "/") {
+			return fmt.Errorf("invalid repository %q, expected owner/name, e.g. \"acme/web\" or \"acme/*\"", pattern)
+		}
+	}
+	return nil
+}
+
+// linesOnRepos returns the lines about the repos of the config, and those
+// about no repository
+func (cfg *config) linesOnRepos(lines []reportLine) []reportLine {
+	if len(cfg.Repos) == 0 {
+		return lines
+	}
+	var kept []reportLine
+	for _, line := range lines {
+		if cfg.reportsOn(line.repo) {
+			kept = append(kept, line)
+		}
+	}
+	return kept
+}
+
+// onRepos returns the sections with only the lines about the repos of the
+// config
+func (cfg *config) onRepos(sections []reportSection) []reportSection {
+	if len(cfg.Repos) == 0 {
+		return sections
+	}
+	kept := make([]reportSection, len(sections))
+	for i, section := range sections {
+		kept[i] = reportSection{title: section.title, lines: cfg.linesOnRepos(section.lines)}
+	}
+	return kept
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// errNothingMissed is returned by reconcileReport when the stored report
+// already had every event of its day
+var errNothingMissed = errors.New("nothing missed")
+
+// lastReportDay returns the latest day before today a report is stored for
+func lastReportDay(h *historyStore, today string) (string, error) {
+	records, err := h.list()
+	if err != nil {
+		return "", err
+	}
+	for _, r := range records {
+		if r.date < today {
+			return r.date, nil
+		}
+	}
+	return "", errors.New("no earlier report stored")
+}
+
+// missedEntries returns the entries of after that before didn't have, or
+// had with a weaker status, e.g. a pull request merged after the report
+func missedEntries(before, after []reportEntry) []reportEntry {
+	statuses := make(map[string]string)
+	for _, entry := range before {
+		statuses[entry.key()] = entry.status
+	}
+	var missed []reportEntry
+	for _, entry := range filterEntries(after, nil) {
+		if status, ok := statuses[entry.key()]; !ok || statusRank[entry.status] > statusRank[status] {
+			missed = append(missed, entry)
+		}
+	}
+	return missed
+}
+
+// reconcileReport fetches the events of a stored day again, once the events
+// API has caught up, along with the ones received by webhook since. When
+// some were missed, the day's report is stored again as its next revision
+// with them, and an addendum listing the entries they added or changed is
+// returned, e.g. "Addendum to the report of May 10, 2024:". With no date,
+// the last day before today is reconciled.
+func reconcileReport(ctx context.Context, cfg *config, opts reportOptions, date string) (*generatedReport, error) {
+	token, err := githubToken(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	h, err := openHistory(historyPath())
+	if err != nil {
+		return nil, err
+	}
+	defer h.Close()
+
+	if date == "" {
+		if date, err = lastReportDay(h, time.Now().Format(dateFormat)); err != nil {
+			return nil, err
+		}
+	}
+	day, err := time.ParseInLocation(dateFormat, date, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", date)
+	}
+	stored, err := h.get(date)
+	if err != nil {
+		return nil, err
+	}
+
+	username := cfg.GitHub.Username
+	fetched, err := getDailyEvents(ctx, username, token, date)
+	if err != nil {
+		return nil, err
+	}
+	captured, err := storedWebhookEvents(date, username)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	for _, event := range stored.events {
+		seen[provenance(event)] = true
+	}
+	events := stored.events
+	for _, event := range append(fetched, captured...) {
+		if !seen[provenance(event)] {
+			seen[provenance(event)] = true
+			events = append(events, event)
+		}
+	}
+	if len(events) == len(stored.events) {
+		return nil, errNothingMissed
+	}
+
+	before, err := h.renderStored(cfg, opts, day, stored.events)
+	if err != nil {
+		return nil, err
+	}
+	rep, err := h.renderStored(cfg, opts, day, events)
+	if err != nil {
+		return nil, err
+	}
+	missed := missedEntries(before.entries, rep.entries)
+	if len(missed) == 0 {
+		return nil, errNothingMissed
+	}
+	slog.Info("Reconciled report", "date", date, "events", len(events)-len(stored.events), "entries", len(missed))
+
+	revision, err := h.storedRevision(date)
+	if err != nil {
+		return nil, err
+	}
+	rep.revision = revision + 1
+	if err := recordHistory(rep, rep.render(nil)); err != nil {
+		return nil, err
+	}
+
+	messages := cfg.messages()
+	header := messages.textf("Addendum to the report of %s", cfg.formatHeaderDate(day))
+	return &generatedReport{
+		date:       date,
+		username:   username,
+		header:     header,
+		events:     events,
+		entries:    missed,
+		messages:   messages,
+		reconciled: rep,
+		format: func(_ *generatedReport, entries []reportEntry, orgs []string) string {
+			report := fmt.Sprintf("%s:\n", header)
+			for _, entry := range entries {
+				report += fmt.Sprintf("%s%s | %s\n", entry.bullet, entry.statusText(messages), entry.title)
+			}
+			return report
+		},
+	}, nil
+}
+
+// reconcile implements "reconcile [--date YYYY-MM-DD] [--post]", printing
+// the addendum of the day and, with --post, delivering it to the targets of
+// deliver, file and git targets getting the reconciled report
+func reconcile(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	date := fs.String("date", "", "day to reconcile, the last reported day before today by default")
+	post := fs.Bool("post", false, "deliver the addendum to the targets of deliver")
+	fs.Parse(args)
+
+	cfg, err := loadConfig(configPath())
+	if err != nil {
+		return err
+	}
+	rep, err := reconcileReport(ctx, cfg, reportOptions{mode: modeFinal}, *date)
+	if errors.Is(err, errNothingMissed) {
+		fmt.Println("Nothing missed")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	addendum := rep.render(nil)
+	fmt.Print(addendum)
+	if !*post {
+		return nil
+	}
+	if len(cfg.Deliver) == 0 {
+		return errors.New("--post needs deliver targets in the config")
+	}
+	return deliverToTargets(ctx, cfg.Hooks, rep, addendum, cfg.resolveTargets(cfg.Deliver))
+}
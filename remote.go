@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// runRemote runs a command against the API of a report server instead of
+// locally: no command generates today's report, "history list", "history
+// show <date>" and "add" work like their local versions
+func runRemote(ctx context.Context, server string, args []string) error {
+	server = strings.TrimSuffix(server, "/")
+	headers := map[string]string{"Authorization": "Bearer " + os.Getenv("SERVER_TOKEN")}
+
+	switch {
+	case len(args) == 0:
+		var rep apiReport
+		if err := sendJSON(ctx, "POST", server+"/api/reports", headers, nil, &rep); err != nil {
+			return err
+		}
+		fmt.Println(rep.Report)
+		return nil
+
+	case len(args) == 2 && args[0] == "history" && args[1] == "list":
+		var reports []apiReport
+		if err := sendJSON(ctx, "GET", server+"/api/reports", headers, nil, &reports); err != nil {
+			return err
+		}
+		for _, rep := range reports {
+			createdAt, _ := time.Parse(time.RFC3339, rep.CreatedAt)
+			fmt.Printf("%s  generated %s\n", rep.Date, createdAt.Local().Format(time.RFC1123))
+		}
+		return nil
+
+	case len(args) == 3 && args[0] == "history" && args[1] == "show":
+		var rep apiReport
+		if err := sendJSON(ctx, "GET", server+"/api/reports/"+args[2], headers, nil, &rep); err != nil {
+			return err
+		}
+		fmt.Println(rep.Report)
+		return nil
+
+	case len(args) > 0 && args[0] == "add":
+		fs := flag.NewFlagSet("add", flag.ExitOnError)
+		date := fs.String("date", time.Now().Format(dateFormat), "day of the report the entry is added to")
+		fs.Parse(args[1:])
+
+		entry, err := parseManualEntry(strings.Join(fs.Args(), " "))
+		if err != nil {
+			return err
+		}
+		return sendJSON(ctx, "POST", server+"/api/entries", headers, apiEntry{Date: *date, Status: entry.status, Title: entry.title}, nil)
+
+	default:
+		return errors.New("usage with --server: [history list | history show <YYYY-MM-DD> | add [--date YYYY-MM-DD] \"Status | Title\"]")
+	}
+}
@@ -0,0 +1,488 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A single line of the report
+type reportEntry struct {
+	status string
+	title  string
+	// Repository of the pull request or commit, e.g. "octocat/hello-world"
+	repo string
+	// Pull request number, 0 for local commits
+	number int
+	// Head branch of the pull request
+	branch string
+	// Branch or tag of branch and tag events and releases
+	ref string
+	// Files changed by the pull request or commit, when known
+	files []string
+	// Subject lines of the pull request's commits of the day, see pr_commits
+	commits []string
+	// Severity badge of incident fixes, e.g. "SEV1"
+	severity string
+	// Labels of the pull request, and the category they put it in, see
+	// label_categories
+	labels   []string
+	category string
+	// Bullet and status emoji of the output the entry is written to, see
+	// styles
+	bullet string
+	emoji  string
+	// Times of the first and last event of the entry, the hours set by
+	// hand and those tracked, see hours and time_tracking
+	started, ended time.Time
+	manualHours    *float64
+	trackedHours   float64
+	// Left out of the rendered report
+	hidden bool
+	// "public", "team" or "private", see classifyPrivacy
+	privacy string
+	// Type of the event the entry comes from, "" for manual entries, and
+	// the commit of local commits
+	eventType string
+	sha       string
+	// IDs of the events the entry was built from, see --annotate
+	events []string
+}
+
+// key identifies the entry across runs: "owner/repo#12" for pull requests,
+// the title otherwise
+func (e reportEntry) key() string {
+	if e.number > 0 {
+		return fmt.Sprintf("%s#%d", e.repo, e.number)
+	}
+	return e.title
+}
+
+// A line of a section or of Next, with the repository it is about if any
+type reportLine struct {
+	text string
+	repo string
+}
+
+// Default lines in every report, replaced by the day's meetings when
+// Google Calendar is configured
+var defaultEntries = []reportEntry{
+	{status: "Done", title: "Attended frail-check meeting"},
+	{status: "Done", title: "Attended frail-check followup meeting"},
+}
+
+// A titled block of lines rendered after the report entries
+type reportSection struct {
+	title string
+	lines []reportLine
+}
+
+// Organization owning a repository, e.g. "octocat" for "octocat/hello-world"
+func orgOf(repo string) string {
+	org, _, _ := strings.Cut(repo, "/")
+	return org
+}
+
+// Whether an item about repo may be shown to a view restricted to the
+// organizations. Unrestricted views see everything.
+func visible(repo string, orgs []string) bool {
+	return len(orgs) == 0 || (strings.Contains(repo, "/") && slices.Contains(orgs, orgOf(repo)))
+}
+
+func filterEntries(entries []reportEntry, orgs []string) []reportEntry {
+	var filtered []reportEntry
+	for _, entry := range entries {
+		if !entry.hidden && visible(entry.repo, orgs) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+func filterLines(lines []reportLine, orgs []string) []reportLine {
+	if len(orgs) == 0 {
+		return lines
+	}
+	var filtered []reportLine
+	for _, line := range lines {
+		if visible(line.repo, orgs) {
+			filtered = append(filtered, line)
+		}
+	}
+	return filtered
+}
+
+func filterSections(sections []reportSection, orgs []string) []reportSection {
+	filtered := make([]reportSection, 0, len(sections))
+	for _, section := range sections {
+		filtered = append(filtered, reportSection{title: section.title, lines: filterLines(section.lines, orgs)})
+	}
+	return filtered
+}
+
+// classifyEvent works out the report entry for an event of username. The
+// status is empty when the event isn't worth reporting.
+func classifyEvent(event map[string]interface{}, username string) reportEntry {
+	entry, _ := classifyEventFacts(event, username)
+	return entry
+}
+
+// classifyEventFacts works out the entry of an event and the facts its
+// status is decided on by the status rules
+func classifyEventFacts(event map[string]interface{}, username string) (reportEntry, eventFacts) {
+	var entry reportEntry
+
+	eventType, ok := event["type"].(string)
+	if !ok {
+		return entry, eventFacts{}
+	}
+	repo, _ := event["repo"].(map[string]interface{})
+	entry.repo, _ = repo["name"].(string)
+	entry.eventType = eventType
+
+	var action, author, reviewState string
+	merged, draft := false, false
+
+	switch eventType {
+	case "PullRequestEvent":
+		action, _ = event["payload"].(map[string]interface{})["action"].(string)
+		merged, _ = event["payload"].(map[string]interface{})["pull_request"].(map[string]interface{})["merged"].(bool)
+		draft, _ = event["payload"].(map[string]interface{})["pull_request"].(map[string]interface{})["draft"].(bool)
+		entry.title, _ = event["payload"].(map[string]interface{})["pull_request"].(map[string]interface{})["title"].(string)
+		author, _ = event["payload"].(map[string]interface{})["pull_request"].(map[string]interface{})["user"].(map[string]interface{})["login"].(string)
+		number, _ := event["payload"].(map[string]interface{})["pull_request"].(map[string]interface{})["number"].(float64)
+		entry.number = int(number)
+		head, _ := event["payload"].(map[string]interface{})["pull_request"].(map[string]interface{})["head"].(map[string]interface{})
+		entry.branch, _ = head["ref"].(string)
+		entry.files = eventFiles(event["payload"].(map[string]interface{}))
+		entry.commits = eventCommits(event["payload"].(map[string]interface{}))
+		entry.labels = prLabels(event)
+		entry.severity = severityOf(entry.title, entry.labels)
+
+	case "PullRequestReviewEvent":
+		action, _ = event["payload"].(map[string]interface{})["action"].(string)
+		merged, _ = event["payload"].(map[string]interface{})["pull_request"].(map[string]interface{})["merged"].(bool)
+		entry.title, _ = event["payload"].(map[string]interface{})["pull_request"].(map[string]interface{})["title"].(string)
+		author, _ = event["payload"].(map[string]interface{})["review"].(map[string]interface{})["user"].(map[string]interface{})["login"].(string)
+		reviewState, _ = event["payload"].(map[string]interface{})["review"].(map[string]interface{})["state"].(string)
+		number, _ := event["payload"].(map[string]interface{})["pull_request"].(map[string]interface{})["number"].(float64)
+		entry.number = int(number)
+		head, _ := event["payload"].(map[string]interface{})["pull_request"].(map[string]interface{})["head"].(map[string]interface{})
+		entry.branch, _ = head["ref"].(string)
+		entry.files = eventFiles(event["payload"].(map[string]interface{}))
+		entry.commits = eventCommits(event["payload"].(map[string]interface{}))
+		entry.labels = prLabels(event)
+		entry.severity = severityOf(entry.title, entry.labels)
+
+	case "CreateEvent", "DeleteEvent", "ReleaseEvent":
+		return classifyRefEvent(event, entry, username), eventFacts{}
+
+	case localCommitEvent:
+		action = "committed"
+		entry.files = eventFiles(event["payload"].(map[string]interface{}))
+		entry.title, _ = event["payload"].(map[string]interface{})["message"].(string)
+		entry.sha, _ = event["payload"].(map[string]interface{})["sha"].(string)
+		author = username
+	}
+
+	facts := eventFacts{mine: author == username, action: action, review: strings.ToLower(reviewState), merged: merged, draft: draft}
+	if rule, ok := matchStatusRule(facts); ok {
+		entry.status = rule.status
+	}
+	return entry, facts
+}
+
+// classifyRefEvent works out the entry of a branch or tag created or
+// deleted, or a release published, e.g. "Done | Released v1.4.0 of service-x"
+func classifyRefEvent(event map[string]interface{}, entry reportEntry, username string) reportEntry {
+	actor, _ := event["actor"].(map[string]interface{})
+	payload, _ := event["payload"].(map[string]interface{})
+	if actor["login"] != username || payload == nil {
+		return entry
+	}
+	name := path.Base(entry.repo)
+	refType, _ := payload["ref_type"].(string)
+	entry.ref, _ = payload["ref"].(string)
+
+	switch {
+	case entry.eventType == "ReleaseEvent":
+		release, _ := payload["release"].(map[string]interface{})
+		entry.ref, _ = release["tag_name"].(string)
+		if payload["action"] == "published" && entry.ref != "" {
+			entry.status, entry.title = "Done", fmt.Sprintf("Released %s of %s", entry.ref, name)
+		}
+	case entry.eventType == "CreateEvent" && refType == "repository":
+		entry.status, entry.title = "Done", fmt.Sprintf("Created repository %s", name)
+	case entry.ref == "":
+	case entry.eventType == "CreateEvent" && refType == "tag":
+		entry.status, entry.title = "Done", fmt.Sprintf("Tagged %s of %s", entry.ref, name)
+	case entry.eventType == "CreateEvent" && refType == "branch":
+		entry.status, entry.title = "WIP", fmt.Sprintf("Created branch %s in %s", entry.ref, name)
+	case entry.eventType == "DeleteEvent" && refType == "tag":
+		entry.status, entry.title = "Done", fmt.Sprintf("Deleted tag %s of %s", entry.ref, name)
+	case entry.eventType == "DeleteEvent" && refType == "branch":
+		entry.status, entry.title = "Done", fmt.Sprintf("Deleted branch %s in %s", entry.ref, name)
+	}
+	return entry
+}
+
+// Names of the labels of the event's pull request
+func prLabels(event map[string]interface{}) []string {
+	pr, _ := event["payload"].(map[string]interface{})["pull_request"].(map[string]interface{})
+	labels, _ := pr["labels"].([]interface{})
+
+	var names []string
+	for _, label := range labels {
+		l, _ := label.(map[string]interface{})
+		if name, ok := l["name"].(string); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// How strong each status is when the same pull request shows up several
+// times, e.g. opened on Monday and merged on Wednesday is reported as Done
+var statusRank = map[string]int{
+	"Reviewed":             1,
+	"Reviewed (commented)": 1,
+	"Reviewed (approved)":  1,
+	"Requested changes":    1,
+	"Committed":            2,
+	"WIP":                  3,
+	"In Review":            4,
+	"Reviewed and merged":  5,
+	"Done":                 6,
+}
+
+// buildEntries classifies the events of username into report entries,
+// reporting each pull request once, by repository and number, with the
+// strongest status it reached. Other entries are deduplicated by repository
+// and title.
+func buildEntries(events []map[string]interface{}, username string) []reportEntry {
+	var entries []reportEntry
+	index := make(map[string]int)
+
+	for _, event := range events {
+		entry := classifyEvent(event, username)
+
+		// Keep the entry only if status and title are not empty
+		if entry.status == "" || entry.title == "" {
+			slog.Debug("Skipped event", "id", event["id"], "type", event["type"], "repo", entry.repo,
+				"title", entry.title, "reason", "no reportable status or title")
+			continue
+		}
+
+		key := entry.key()
+		if entry.number == 0 {
+			key = entry.repo + "\x00" + entry.title
+		}
+
+		if id, ok := event["id"]; ok {
+			entry.events = []string{fmt.Sprint(id)}
+		}
+		entry.spanEvent(event)
+
+		if i, seen := index[key]; seen {
+			entries[i].events = append(entries[i].events, entry.events...)
+			entries[i].spanEvent(event)
+			if statusRank[entry.status] > statusRank[entries[i].status] {
+				entries[i].status = entry.status
+			}
+			slog.Debug("Merged event", "id", event["id"], "type", event["type"], "entry", key, "status", entries[i].status)
+			continue
+		}
+		slog.Debug("Kept event", "id", event["id"], "type", event["type"], "entry", key, "status", entry.status)
+		index[key] = len(entries)
+		entries = append(entries, entry)
+	}
+
+	// Commits of a pull request already in the report are not repeated,
+	// nor the branches of its pull requests or the tags of its releases
+	prTitles, refs := make(map[string]bool), make(map[string]bool)
+	for _, entry := range entries {
+		if entry.number != 0 {
+			prTitles[entry.repo+"\x00"+entry.title] = true
+			refs[entry.repo+"\x00"+entry.branch] = true
+		}
+		if entry.eventType == "ReleaseEvent" {
+			refs[entry.repo+"\x00"+entry.ref] = true
+		}
+	}
+	deduped := entries[:0]
+	for _, entry := range entries {
+		switch {
+		case entry.number == 0 && prTitles[entry.repo+"\x00"+entry.title]:
+			slog.Debug("Skipped commit", "repo", entry.repo, "title", entry.title, "reason", "pull request with the same title reported")
+		case (entry.eventType == "CreateEvent" || entry.eventType == "DeleteEvent") && entry.ref != "" && refs[entry.repo+"\x00"+entry.ref]:
+			slog.Debug("Skipped ref", "repo", entry.repo, "ref", entry.ref, "reason", "pull request or release of the ref reported")
+		default:
+			deduped = append(deduped, entry)
+		}
+	}
+	return deduped
+}
+
+// Groups of statuses the entries can be listed under, by status
+var statusGroups = map[string]string{
+	"Done":                 "Done",
+	"In Review":            "In Review",
+	"WIP":                  "WIP",
+	"Committed":            "Committed",
+	"Reviewed":             "Reviewed",
+	"Reviewed (commented)": "Reviewed",
+	"Reviewed (approved)":  "Reviewed",
+	"Requested changes":    "Reviewed",
+	"Reviewed and merged":  "Reviewed",
+}
+
+// Order of the status groups when status_order doesn't set it
+var defaultStatusOrder = []string{"Done", "In Review", "WIP", "Committed", "Reviewed"}
+
+// How the report entries are grouped under headings
+type entryGrouping struct {
+	byOrg bool
+	// Status groups in order, nil when not grouping by status
+	byStatus []string
+	// Label categories in order, nil when not grouping by category
+	byCategory []string
+}
+
+// A block of entries, under a heading unless the title is empty
+type entryBlock struct {
+	title   string
+	entries []reportEntry
+}
+
+// buildBlocks lays out the entries of the report. Incident fixes come first,
+// most severe first, then the meetings and the other entries: with byStatus
+// one block per status group in its order, the meetings among Done, other
+// groups after those in the default order; with byOrg one block per
+// organization; otherwise in the order of the events.
+func buildBlocks(meetings, entries []reportEntry, grouping entryGrouping) []entryBlock {
+	incidents, entries := splitIncidents(entries)
+	blocks := []entryBlock{{entries: incidents}}
+
+	switch {
+	case grouping.byStatus != nil:
+		order := slices.Clone(grouping.byStatus)
+		for _, group := range defaultStatusOrder {
+			if !slices.Contains(order, group) {
+				order = append(order, group)
+			}
+		}
+		byGroup := map[string][]reportEntry{"Done": meetings}
+		for _, entry := range entries {
+			// Statuses of manual entries outside the groups are groups of their own
+			group, ok := statusGroups[entry.status]
+			if !ok {
+				group = entry.status
+			}
+			if !slices.Contains(order, group) {
+				order = append(order, group)
+			}
+			byGroup[group] = append(byGroup[group], entry)
+		}
+		for _, group := range order {
+			if len(byGroup[group]) > 0 {
+				blocks = append(blocks, entryBlock{title: group, entries: byGroup[group]})
+			}
+		}
+
+	case grouping.byCategory != nil:
+		blocks = append(blocks, entryBlock{entries: meetings})
+		byCategory := make(map[string][]reportEntry)
+		for _, entry := range entries {
+			category := entry.category
+			if category == "" {
+				category = otherCategory
+			}
+			byCategory[category] = append(byCategory[category], entry)
+		}
+		for _, category := range append(slices.Clone(grouping.byCategory), otherCategory) {
+			if len(byCategory[category]) > 0 {
+				blocks = append(blocks, entryBlock{title: category, entries: byCategory[category]})
+			}
+		}
+
+	case grouping.byOrg:
+		blocks = append(blocks, entryBlock{entries: meetings})
+		byOrg := make(map[string][]reportEntry)
+		var orgs []string
+		for _, entry := range entries {
+			org := orgOf(entry.repo)
+			if _, ok := byOrg[org]; !ok {
+				orgs = append(orgs, org)
+			}
+			byOrg[org] = append(byOrg[org], entry)
+		}
+		sort.Strings(orgs)
+		for _, org := range orgs {
+			blocks = append(blocks, entryBlock{title: org, entries: byOrg[org]})
+		}
+
+	default:
+		blocks = append(blocks, entryBlock{entries: append(slices.Clone(meetings), entries...)})
+	}
+	return blocks
+}
+
+// formatEntries renders the report: the blocks of entries, incident fixes
+// with their severity, the commits of the day of pull requests as
+// sub-bullets, then the sections, and Next lists tomorrow's plan,
+// falling back to the default line when empty. Statuses and headings are
+// translated with the catalog.
+func formatEntries(header string, meetings, entries []reportEntry, sections []reportSection, next []reportLine, grouping entryGrouping, c catalog) string {
+	report := fmt.Sprintf("%s:\n", header)
+
+	for _, block := range buildBlocks(meetings, entries, grouping) {
+		if block.title != "" {
+			report += fmt.Sprintf("\n%s:\n", c.text(block.title))
+		}
+		for _, entry := range block.entries {
+			report += entry.bullet
+			if entry.severity != "" {
+				report += fmt.Sprintf("[%s] ", entry.severity)
+			}
+			report += fmt.Sprintf("%s | %s\n", entry.statusText(c), entry.title)
+			for _, commit := range entry.commits {
+				report += fmt.Sprintf("  - %s\n", commit)
+			}
+		}
+	}
+
+	if grouping.byOrg || grouping.byStatus != nil || grouping.byCategory != nil {
+		report += "\n"
+	}
+	return report + formatTail(sections, next, c)
+}
+
+// formatTail renders the extra sections and the Next plan
+func formatTail(sections []reportSection, next []reportLine, c catalog) string {
+	var report string
+
+	// Empty sections are left out
+	for _, section := range sections {
+		if len(section.lines) == 0 {
+			continue
+		}
+		report += c.text(section.title) + ":\n"
+		for _, line := range section.lines {
+			report += line.text + "\n"
+		}
+	}
+
+	if len(next) == 0 {
+		next = []reportLine{{text: c.text(defaultNext)}}
+	}
+	report += c.text("Next") + ":\n"
+	for _, item := range next {
+		report += item.text + "\n"
+	}
+
+	return report
+}
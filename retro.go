@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"text/template"
+)
+
+// Accomplishments picked for the retrospective
+const retroAccomplishments = 3
+
+// retrospective scaffolds the weekly retro from the week's entries
+type retrospective struct {
+	tmpl            *template.Template
+	accomplishments []reportEntry
+	blockers        []reportLine
+	carryOvers      []reportEntry
+}
+
+// retroScore ranks accomplishments: incident fixes by severity first, then
+// my merged PRs over the ones I reviewed, then the larger changes
+func retroScore(entry reportEntry) int {
+	score := statusRank[entry.status] * 100
+	if entry.severity != "" {
+		score += (11 - severityRank(entry.severity)) * 1000
+	}
+	return score + min(len(entry.files), 99)
+}
+
+// rankAccomplishments returns the entries that made it to Done or were
+// merged, best scored first
+func rankAccomplishments(entries []reportEntry) []reportEntry {
+	var done []reportEntry
+	for _, entry := range entries {
+		if entry.status == "Done" || entry.status == "Reviewed and merged" {
+			done = append(done, entry)
+		}
+	}
+	sort.SliceStable(done, func(i, j int) bool {
+		return retroScore(done[i]) > retroScore(done[j])
+	})
+	return done
+}
+
+// Entries of mine still open at the end of the week
+func carryOvers(entries []reportEntry) []reportEntry {
+	var open []reportEntry
+	for _, entry := range entries {
+		switch entry.status {
+		case "WIP", "In Review", "Committed":
+			open = append(open, entry)
+		}
+	}
+	return open
+}
+
+// getBlockers lists my open pull requests waiting on requested changes or
+// failing checks
+func getBlockers(ctx context.Context, username, token string) ([]reportLine, error) {
+	queries := []struct {
+		query, format string
+	}{
+		{"type:pr is:open author:%s review:changes_requested", "%s (changes requested)"},
+		{"type:pr is:open author:%s status:failure", "%s (failing checks)"},
+	}
+
+	var blockers []reportLine
+	for _, q := range queries {
+		prs, err := searchIssues(ctx, fmt.Sprintf(q.query, username), token)
+		if err != nil {
+			return nil, err
+		}
+		for _, pr := range prs {
+			blockers = append(blockers, reportLine{text: fmt.Sprintf(q.format, pr.Title), repo: pr.repoName()})
+		}
+	}
+	return blockers, nil
+}
+
+// newRetrospective scaffolds the retro of the week's entries, rendered with
+// the template at retro_template or templates/retro.tmpl of the assets
+func newRetrospective(ctx context.Context, cfg *config, entries []reportEntry) (*retrospective, error) {
+	data, err := readAsset("templates/retro.tmpl")
+	if cfg.RetroTemplate != "" {
+		data, err = os.ReadFile(cfg.RetroTemplate)
+	}
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New("retro").Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("retro_template: %w", err)
+	}
+
+	token, err := githubToken(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	blockers, err := getBlockers(ctx, cfg.GitHub.Username, token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &retrospective{
+		tmpl:            tmpl,
+		accomplishments: rankAccomplishments(entries),
+		blockers:        blockers,
+		carryOvers:      carryOvers(entries),
+	}, nil
+}
+
+// render fills the template with the parts of the retro visible to the
+// organizations, picking the top accomplishments among those
+func (r *retrospective) render(orgs []string) string {
+	titles := func(entries []reportEntry) []string {
+		var lines []string
+		for _, entry := range filterEntries(entries, orgs) {
+			line := fmt.Sprintf("%s | %s", entry.status, entry.title)
+			if entry.severity != "" {
+				line = fmt.Sprintf("[%s] %s", entry.severity, line)
+			}
+			lines = append(lines, line)
+		}
+		return lines
+	}
+	top := filterEntries(r.accomplishments, orgs)
+	top = top[:min(len(top), retroAccomplishments)]
+	var blockers []string
+	for _, line := range filterLines(r.blockers, orgs) {
+		blockers = append(blockers, line.text)
+	}
+
+	var out bytes.Buffer
+	err := r.tmpl.Execute(&out, struct {
+		Accomplishments, Blockers, CarryOvers []string
+	}{titles(top), blockers, titles(r.carryOvers)})
+	if err != nil {
+		slog.Warn("Rendering the retrospective failed", "err", err)
+		return ""
+	}
+	return out.String()
+}
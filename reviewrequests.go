@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// getReviewRequests lists the reviews requested from me on the given date,
+// e.g. "Review requested on Add login page (octocat/app#12) by alice", and
+// the pull requests still waiting for my review, oldest request first
+func getReviewRequests(ctx context.Context, now time.Time, date, username, token string) (received, pending []reportLine, err error) {
+	open, err := searchIssues(ctx, fmt.Sprintf("type:pr is:open review-requested:%s", username), token)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Requests I already answered today are no longer requests
+	reviewed, err := searchIssues(ctx, fmt.Sprintf("type:pr reviewed-by:%s updated:>=%s", username, date), token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	prs := append(append([]searchIssue{}, open...), reviewed...)
+	requesters := make([]string, len(prs))
+	since := make([]time.Time, len(prs))
+	err = runPool(ctx, fetchConcurrency, len(prs), func(ctx context.Context, i int) error {
+		var events []issueEvent
+		u := fmt.Sprintf("%s/issues/%d/events?per_page=100", prs[i].RepositoryURL, prs[i].Number)
+		if err := githubGet(ctx, u, token, &events); err != nil {
+			return err
+		}
+		since[i], _ = time.Parse(time.RFC3339, prs[i].CreatedAt)
+		for _, e := range events {
+			if e.Event != "review_requested" || e.RequestedReviewer.Login != username {
+				continue
+			}
+			if t, err := time.Parse(time.RFC3339, e.CreatedAt); err == nil && t.After(since[i]) {
+				since[i] = t
+			}
+			if onDate(e.CreatedAt, date) {
+				requesters[i] = e.Actor.Login
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	seen := make(map[string]bool)
+	for i, pr := range prs {
+		name := fmt.Sprintf("%s (%s#%d)", pr.Title, pr.repoName(), pr.Number)
+		if requesters[i] != "" && !seen[pr.HTMLURL] {
+			received = append(received, reportLine{text: fmt.Sprintf("Review requested on %s by %s", name, requesters[i]), repo: pr.repoName()})
+		}
+		seen[pr.HTMLURL] = true
+	}
+
+	// Oldest requests first
+	waiting := make([]int, len(open))
+	for i := range waiting {
+		waiting[i] = i
+	}
+	slices.SortStableFunc(waiting, func(a, b int) int { return since[a].Compare(since[b]) })
+	for _, i := range waiting {
+		pr := open[i]
+		age := "today"
+		if days := int(now.Sub(since[i]).Hours() / 24); days > 0 {
+			age = fmt.Sprintf("%d days ago", days)
+		}
+		pending = append(pending, reportLine{
+			text: fmt.Sprintf("%s (%s#%d), requested %s", pr.Title, pr.repoName(), pr.Number, age),
+			repo: pr.repoName(),
+		})
+	}
+	return received, pending, nil
+}
+
+// reviewRequestsGiven lists the reviews username requested in the events,
+// e.g. "Requested review from bob on Add login page (octocat/app#12)"
+func reviewRequestsGiven(events []map[string]interface{}, username string) []reportLine {
+	var lines []reportLine
+	seen := make(map[string]bool)
+	for _, event := range events {
+		actor, _ := event["actor"].(map[string]interface{})
+		payload, _ := event["payload"].(map[string]interface{})
+		if event["type"] != "PullRequestEvent" || actor["login"] != username || payload["action"] != "review_requested" {
+			continue
+		}
+		pr, _ := payload["pull_request"].(map[string]interface{})
+		reviewer, _ := payload["requested_reviewer"].(map[string]interface{})
+		login, _ := reviewer["login"].(string)
+		repo, _ := event["repo"].(map[string]interface{})
+		name, _ := repo["name"].(string)
+		title, _ := pr["title"].(string)
+		number, _ := pr["number"].(float64)
+
+		line := fmt.Sprintf("Requested review from %s on %s (%s#%d)", login, title, name, int(number))
+		if login != "" && !seen[line] {
+			seen[line] = true
+			lines = append(lines, reportLine{text: line, repo: name})
+		}
+	}
+	return lines
+}
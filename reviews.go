@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+)
+
+// reviewBalance counts the reviews username asked for on their pull requests
+// and the reviews they gave on others', from the events of the period. Each
+// reviewer counts once per pull request, as does each pull request reviewed.
+func reviewBalance(events []map[string]interface{}, username string) (requested, given int) {
+	asked := make(map[string]bool)
+	reviewed := make(map[string]bool)
+	for _, event := range events {
+		payload, _ := event["payload"].(map[string]interface{})
+		pr, _ := payload["pull_request"].(map[string]interface{})
+		user, _ := pr["user"].(map[string]interface{})
+		author, _ := user["login"].(string)
+		repo, _ := event["repo"].(map[string]interface{})
+		number, _ := pr["number"].(float64)
+		key := fmt.Sprintf("%v#%d", repo["name"], int(number))
+
+		switch event["type"] {
+		case "PullRequestEvent":
+			if author != username {
+				continue
+			}
+			reviewers, _ := pr["requested_reviewers"].([]interface{})
+			if reviewer, ok := payload["requested_reviewer"].(map[string]interface{}); ok {
+				reviewers = append(reviewers, reviewer)
+			}
+			for _, r := range reviewers {
+				reviewer, _ := r.(map[string]interface{})
+				login, _ := reviewer["login"].(string)
+				if login != "" && login != username {
+					asked[key+" "+login] = true
+				}
+			}
+
+		case "PullRequestReviewEvent":
+			review, _ := payload["review"].(map[string]interface{})
+			user, _ := review["user"].(map[string]interface{})
+			if user["login"] == username && author != username {
+				reviewed[key] = true
+			}
+		}
+	}
+	return len(asked), len(reviewed)
+}
+
+// reviewBalanceSection is the review balance line, e.g.
+// "Requested 5 reviews, gave 3 (-2)"
+func reviewBalanceSection(events []map[string]interface{}, username string) reportSection {
+	requested, given := reviewBalance(events, username)
+	return reportSection{title: "Review balance", lines: []reportLine{{
+		text: fmt.Sprintf("Requested %d reviews, gave %d (%+d)", requested, given, given-requested),
+	}}}
+}
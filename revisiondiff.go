@@ -0,0 +1,240 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	htmltemplate "html/template"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Severity badge in front of incident lines, e.g. "[SEV1] "
+var badgePattern = regexp.MustCompile(`^\[[^\]]+\] `)
+
+// reportLines returns the entries of a rendered report, status by title:
+// the "Status | Title" lines, without their severity badge
+func reportLines(report string) (titles []string, statuses map[string]string) {
+	statuses = make(map[string]string)
+	for _, line := range strings.Split(report, "\n") {
+		status, title, ok := strings.Cut(badgePattern.ReplaceAllString(line, ""), " | ")
+		if !ok || strings.HasPrefix(line, " ") {
+			continue
+		}
+		if _, seen := statuses[title]; !seen {
+			titles = append(titles, title)
+		}
+		statuses[title] = status
+	}
+	return titles, statuses
+}
+
+// A change of an entry between two revisions of a report
+type entryDiff struct {
+	// "added", "removed" or "changed"
+	Kind      string
+	Title     string
+	Status    string
+	OldStatus string
+}
+
+// diffReports compares the entries of two revisions of a report: the ones
+// added, removed, and whose status changed, in the order of the reports
+func diffReports(before, after string) []entryDiff {
+	oldTitles, oldStatuses := reportLines(before)
+	newTitles, newStatuses := reportLines(after)
+
+	var diffs []entryDiff
+	for _, title := range newTitles {
+		old, ok := oldStatuses[title]
+		switch {
+		case !ok:
+			diffs = append(diffs, entryDiff{Kind: "added", Title: title, Status: newStatuses[title]})
+		case old != newStatuses[title]:
+			diffs = append(diffs, entryDiff{Kind: "changed", Title: title, Status: newStatuses[title], OldStatus: old})
+		}
+	}
+	for _, title := range oldTitles {
+		if _, ok := newStatuses[title]; !ok {
+			diffs = append(diffs, entryDiff{Kind: "removed", Title: title, Status: oldStatuses[title]})
+		}
+	}
+	return diffs
+}
+
+// diffEntries compares the entries of two days by pull request, or title for
+// the others: the ones new on the later day, those whose status changed, and
+// those gone from it. Hidden entries are left out.
+func diffEntries(before, after []reportEntry) []entryDiff {
+	old := make(map[string]reportEntry)
+	for _, entry := range before {
+		if !entry.hidden {
+			old[entry.key()] = entry
+		}
+	}
+	seen := make(map[string]bool)
+	var diffs []entryDiff
+	for _, entry := range after {
+		if entry.hidden || seen[entry.key()] {
+			continue
+		}
+		seen[entry.key()] = true
+		previous, ok := old[entry.key()]
+		switch {
+		case !ok:
+			diffs = append(diffs, entryDiff{Kind: "added", Title: entry.title, Status: entry.status})
+		case previous.status != entry.status:
+			diffs = append(diffs, entryDiff{Kind: "changed", Title: entry.title, Status: entry.status, OldStatus: previous.status})
+		}
+	}
+	for _, entry := range before {
+		if !entry.hidden && !seen[entry.key()] {
+			seen[entry.key()] = true
+			diffs = append(diffs, entryDiff{Kind: "removed", Title: entry.title, Status: entry.status})
+		}
+	}
+	return diffs
+}
+
+// formatDayDiff writes the changes from the previous day, grouped as new,
+// changed status and gone
+func formatDayDiff(previous string, diffs []entryDiff) string {
+	if len(diffs) == 0 {
+		return fmt.Sprintf("Same entries as on %s\n", previous)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Compared with %s:\n", previous)
+	for _, group := range []struct{ kind, title string }{
+		{"added", "New"},
+		{"changed", "Changed status"},
+		{"removed", "Gone"},
+	} {
+		var lines []string
+		for _, d := range diffs {
+			switch {
+			case d.Kind != group.kind:
+			case d.Kind == "changed":
+				lines = append(lines, fmt.Sprintf("  %s → %s | %s", d.OldStatus, d.Status, d.Title))
+			default:
+				lines = append(lines, fmt.Sprintf("  %s | %s", d.Status, d.Title))
+			}
+		}
+		if len(lines) > 0 {
+			fmt.Fprintf(&b, "%s:\n%s\n", group.title, strings.Join(lines, "\n"))
+		}
+	}
+	return b.String()
+}
+
+// previousDayDiff compares the report with the one stored for the last day
+// before it, rendered again with the current config
+func previousDayDiff(cfg *config, rep *generatedReport) (string, error) {
+	h, err := openHistory(historyPath())
+	if err != nil {
+		return "", err
+	}
+	defer h.Close()
+
+	records, err := h.list()
+	if err != nil {
+		return "", err
+	}
+	i := slices.IndexFunc(records, func(r historyRecord) bool { return r.date < rep.date })
+	if i < 0 {
+		return fmt.Sprintf("No report stored before %s to compare with\n", rep.date), nil
+	}
+	stored, err := h.get(records[i].date)
+	if err != nil {
+		return "", err
+	}
+	day, _ := time.ParseInLocation(dateFormat, stored.date, time.Local)
+	previous, err := h.renderStored(cfg, reportOptions{mode: modeFinal}, day, stored.events)
+	if err != nil {
+		return "", err
+	}
+	return formatDayDiff(stored.date, diffEntries(previous.entries, rep.entries)), nil
+}
+
+// A revision of a day's report as shown by the history web page, with its
+// changes from the previous one
+type revisionView struct {
+	Revision  int
+	CreatedAt string
+	Report    string
+	Changes   []entryDiff
+}
+
+// dayRevisions returns every revision of the day's report, the stored one
+// last, each with its changes from the previous one
+func (h *historyStore) dayRevisions(date string) ([]revisionView, error) {
+	current, err := h.get(date)
+	if err != nil {
+		return nil, err
+	}
+	archived, err := h.revisions(date)
+	if err != nil {
+		return nil, err
+	}
+	revision, err := h.storedRevision(date)
+	if err != nil {
+		return nil, err
+	}
+	archived = append(archived, reportRevision{revision: revision, report: current.report, createdAt: current.createdAt})
+
+	views := make([]revisionView, 0, len(archived))
+	for i, r := range archived {
+		view := revisionView{Revision: r.revision, CreatedAt: r.createdAt.Local().Format(time.RFC1123), Report: r.report}
+		if i > 0 {
+			view.Changes = diffReports(archived[i-1].report, r.report)
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+// historyWeb implements "history web [--addr localhost:8091]", a local page
+// listing the stored days, and for each day its revisions with what changed
+// from one to the next
+func historyWeb(h *historyStore, args []string) error {
+	fs := flag.NewFlagSet("history web", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:8091", "address to serve the history on")
+	fs.Parse(args)
+
+	pageHTML, err := readAsset("web/history.html")
+	if err != nil {
+		return err
+	}
+	page, err := htmltemplate.New("history").Parse(string(pageHTML))
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		records, err := h.list()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		var dates []string
+		for _, record := range records {
+			dates = append(dates, record.date)
+		}
+		page.Execute(w, map[string]interface{}{"Dates": dates})
+	})
+	mux.HandleFunc("GET /{date}", func(w http.ResponseWriter, r *http.Request) {
+		date := r.PathValue("date")
+		revisions, err := h.dayRevisions(date)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		page.Execute(w, map[string]interface{}{"Date": date, "Revisions": revisions})
+	})
+
+	slog.Info("Serving the history", "url", "http://"+*addr)
+	return http.ListenAndServe(*addr, mux)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Separator line starting each day's report in appended files
+var separatorPattern = regexp.MustCompile(`^--- \d{4}-\d{2}-\d{2}(, revision \d+)? ---$`)
+
+// separator returns the line starting the report in appended files,
+// "--- 2024-05-10 ---", or "--- 2024-05-10, revision 2 ---" for revisions
+// following the day's earlier one
+func separator(rep *generatedReport) string {
+	if rep.revision > 1 && !rep.replaces {
+		return fmt.Sprintf("--- %s, revision %d ---", rep.date, rep.revision)
+	}
+	return fmt.Sprintf("--- %s ---", rep.date)
+}
+
+// appendReport adds the report to the end of the file after its separator
+// line. A report already in the file under the same separator, from an
+// earlier run of the day, is replaced where it is instead.
+func appendReport(path string, rep *generatedReport, report string) error {
+	existing, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	block := separator(rep) + "\n" + strings.TrimRight(report, "\n") + "\n"
+
+	lines := strings.SplitAfter(string(existing), "\n")
+	start, end := -1, len(lines)
+	for i, line := range lines {
+		line = strings.TrimRight(line, "\n")
+		if start < 0 && line == separator(rep) {
+			start = i
+		} else if start >= 0 && separatorPattern.MatchString(line) {
+			end = i
+			break
+		}
+	}
+
+	var content string
+	switch {
+	case start >= 0:
+		// Keep the blank line before the next day's separator
+		rest := strings.Join(lines[end:], "")
+		if rest != "" {
+			block += "\n"
+		}
+		content = strings.Join(lines[:start], "") + block + rest
+	case len(existing) == 0:
+		content = block
+	default:
+		content = strings.TrimRight(string(existing), "\n") + "\n\n" + block
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
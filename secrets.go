@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// memberKey derives the key encrypting one member's tokens from the server
+// secret and their login, so each member's tokens use a different key and
+// the database alone is not enough to read any of them
+func memberKey(secret, login string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("daily-reporting member token key\x00" + login))
+	return mac.Sum(nil)
+}
+
+func memberCipher(login string) (cipher.AEAD, error) {
+	secret := os.Getenv("SERVER_SECRET")
+	if secret == "" {
+		return nil, errors.New("SERVER_SECRET is required to store or read member tokens")
+	}
+	block, err := aes.NewCipher(memberKey(secret, login))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// saveToken encrypts and stores a member's token for a source such as "github"
+func (h *historyStore) saveToken(login, source, token string) error {
+	aead, err := memberCipher(login)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	// The login and source are authenticated so a row can't be moved to another member
+	ciphertext := aead.Seal(nil, nonce, []byte(token), []byte(login+"\x00"+source))
+
+	_, err = h.db.Exec(`INSERT INTO member_tokens (login, source, nonce, ciphertext) VALUES (?, ?, ?, ?)
+		ON CONFLICT (login, source) DO UPDATE SET nonce = excluded.nonce, ciphertext = excluded.ciphertext`,
+		login, source, nonce, ciphertext)
+	return err
+}
+
+// token decrypts a member's stored token, returning "" when none is stored
+func (h *historyStore) token(login, source string) (string, error) {
+	var nonce, ciphertext []byte
+	err := h.db.QueryRow(`SELECT nonce, ciphertext FROM member_tokens WHERE login = ? AND source = ?`, login, source).
+		Scan(&nonce, &ciphertext)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	aead, err := memberCipher(login)
+	if err != nil {
+		return "", err
+	}
+	plain, err := aead.Open(nil, nonce, ciphertext, []byte(login+"\x00"+source))
+	if err != nil {
+		return "", fmt.Errorf("cannot decrypt the %s token of %s: wrong SERVER_SECRET?", source, login)
+	}
+	return string(plain), nil
+}
+
+// githubToken returns the configured token, an installation token when
+// running as a GitHub App, the token stored in the OS keychain with "auth
+// login", or else the encrypted token stored for the user with "token set github"
+func githubToken(ctx context.Context, cfg *config) (string, error) {
+	if cfg.GitHub.Token != "" {
+		return cfg.GitHub.Token, nil
+	}
+	if githubAppConfigured() {
+		return githubAppToken(ctx)
+	}
+	if token := keyringToken(); token != "" {
+		return token, nil
+	}
+	if os.Getenv("SERVER_SECRET") == "" {
+		return "", nil
+	}
+
+	h, err := openHistory(historyPath())
+	if err != nil {
+		return "", err
+	}
+	defer h.Close()
+
+	return h.token(cfg.GitHub.Username, "github")
+}
+
+// Sources whose stored tokens are read, see githubToken
+var tokenSources = []string{"github"}
+
+// tokenCommand implements "token set <source>", reading the token from stdin
+// so it doesn't end up in the shell history
+func tokenCommand(args []string) error {
+	if len(args) != 2 || args[0] != "set" {
+		return errors.New("usage: token set <source>  (the token is read from stdin)")
+	}
+	if !slices.Contains(tokenSources, args[1]) {
+		return fmt.Errorf("unknown token source %q, expected one of %s", args[1], strings.Join(tokenSources, ", "))
+	}
+
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return err
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return errors.New("no token given on stdin")
+	}
+
+	cfg, err := loadConfig(configPath())
+	if err != nil {
+		return err
+	}
+	h, err := openHistory(historyPath())
+	if err != nil {
+		return err
+	}
+	defer h.Close()
+
+	return h.saveToken(cfg.GitHub.Username, args[1], token)
+}
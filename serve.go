@@ -0,0 +1,300 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Defaults for serve mode: generate at 17:00 and only deliver between 17:00 and 22:00
+const (
+	defaultReportTime  = "17:00"
+	defaultWindowStart = "17:00"
+	defaultWindowEnd   = "22:00"
+)
+
+// clock is a local time of day such as 17:00
+type clock struct {
+	hour, minute int
+}
+
+func parseClock(s string) (clock, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return clock{}, fmt.Errorf("invalid time of day %q, expected HH:MM", s)
+	}
+	return clock{hour: t.Hour(), minute: t.Minute()}, nil
+}
+
+// on returns the clock time on the same calendar day as t
+func (c clock) on(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), c.hour, c.minute, 0, 0, t.Location())
+}
+
+// next returns the first occurrence of the clock time strictly after t
+func (c clock) next(t time.Time) time.Time {
+	at := c.on(t)
+	if !at.After(t) {
+		at = c.on(t.AddDate(0, 0, 1))
+	}
+	return at
+}
+
+// deliveryWindow is the part of the day in which reports may be sent
+type deliveryWindow struct {
+	start, end clock
+}
+
+// next returns the earliest time at or after t that falls inside the window.
+// Reports generated before the window opens are held until it opens, and
+// reports generated after it closes are held until it opens the next day.
+func (w deliveryWindow) next(t time.Time) time.Time {
+	start, end := w.start.on(t), w.end.on(t)
+	switch {
+	case t.Before(start):
+		return start
+	case !t.After(end):
+		return t
+	default:
+		return w.start.on(t.AddDate(0, 0, 1))
+	}
+}
+
+// Read a time of day from the environment, falling back to def
+func clockFromEnv(key, def string) clock {
+	value := os.Getenv(key)
+	if value == "" {
+		value = def
+	}
+	c, err := parseClock(value)
+	if err != nil {
+		fatal("invalid time of day", "var", key, "err", err)
+	}
+	return c
+}
+
+// How often serve mode checks the config file for changes
+const configPollInterval = 5 * time.Second
+
+// scheduler runs the schedules of the current config, which is swapped
+// whenever the config file changes
+type scheduler struct {
+	window deliveryWindow
+
+	mu  sync.Mutex
+	cfg *config
+	// Signalled when a new config was loaded
+	reloaded chan struct{}
+
+	// Reports are built from the events received by webhook
+	webhook bool
+}
+
+// Address webhooks are received on by default
+const defaultWebhookAddr = ":8081"
+
+// serve runs every schedule from the config file, delivering the reports
+// inside the DELIVERY_WINDOW_START..DELIVERY_WINDOW_END window. Without a
+// config file the report is generated every day at REPORT_TIME. With
+// --webhook the reports are built from GitHub webhook deliveries instead of
+// polling the events API.
+func serve(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	webhook := fs.Bool("webhook", false, "receive GitHub webhooks on WEBHOOK_ADDR and build the reports from them")
+	fs.Parse(args)
+
+	window := deliveryWindow{
+		start: clockFromEnv("DELIVERY_WINDOW_START", defaultWindowStart),
+		end:   clockFromEnv("DELIVERY_WINDOW_END", defaultWindowEnd),
+	}
+	if !window.start.on(time.Now()).Before(window.end.on(time.Now())) {
+		fatal("DELIVERY_WINDOW_START must be before DELIVERY_WINDOW_END")
+	}
+
+	cfg, err := loadServeConfig(configPath())
+	if err != nil {
+		fatal("cannot load the config", "err", err)
+	}
+
+	s := &scheduler{window: window, cfg: cfg, reloaded: make(chan struct{}, 1), webhook: *webhook}
+	go s.watch(configPath())
+
+	// Receive the events by webhook, checking they come from GitHub
+	if s.webhook {
+		secret := os.Getenv("WEBHOOK_SECRET")
+		if secret == "" {
+			fatal("WEBHOOK_SECRET is required to receive webhooks")
+		}
+		addr := os.Getenv("WEBHOOK_ADDR")
+		if addr == "" {
+			addr = defaultWebhookAddr
+		}
+		go s.serveWebhook(addr, secret)
+	}
+
+	// Serve the API for remote clients when an address is configured
+	if addr := os.Getenv("SERVER_ADDR"); addr != "" {
+		token := os.Getenv("SERVER_TOKEN")
+		if token == "" {
+			fatal("SERVER_TOKEN is required to serve the API")
+		}
+		go s.serveAPI(addr, token)
+	}
+
+	// Expose metrics and a health check for monitoring
+	if addr := os.Getenv("METRICS_ADDR"); addr != "" {
+		go serveMetricsOn(addr)
+	}
+
+	s.run()
+}
+
+// Load the config for serve mode, which needs at least one schedule
+func loadServeConfig(path string) (*config, error) {
+	cfg, err := loadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.Schedules) == 0 {
+		return nil, fmt.Errorf("%s: no schedules configured", path)
+	}
+	return cfg, nil
+}
+
+func (s *scheduler) config() *config {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cfg
+}
+
+// watch reloads the config file when it changes. A config that fails to
+// load or validate is reported and the previous one is kept.
+func (s *scheduler) watch(path string) {
+	var lastMod time.Time
+	if info, err := os.Stat(path); err == nil {
+		lastMod = info.ModTime()
+	}
+
+	for range time.Tick(configPollInterval) {
+		info, err := os.Stat(path)
+		if err != nil || info.ModTime().Equal(lastMod) {
+			continue
+		}
+		lastMod = info.ModTime()
+
+		cfg, err := loadServeConfig(path)
+		if err != nil {
+			slog.Warn("Keeping previous config", "path", path, "err", err)
+			continue
+		}
+
+		s.mu.Lock()
+		s.cfg = cfg
+		s.mu.Unlock()
+		slog.Info("Reloaded config", "path", path)
+
+		select {
+		case s.reloaded <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// run starts each schedule of the current config when it is due
+func (s *scheduler) run() {
+	for {
+		cfg := s.config()
+
+		// Find the schedules due next
+		var runAt time.Time
+		var due []schedule
+		for _, sc := range cfg.Schedules {
+			next := sc.spec.next(time.Now())
+			switch {
+			case next.IsZero():
+			case runAt.IsZero() || next.Before(runAt):
+				runAt, due = next, []schedule{sc}
+			case next.Equal(runAt):
+				due = append(due, sc)
+			}
+		}
+		if runAt.IsZero() {
+			slog.Warn("No schedule will ever fire, waiting for a config change")
+			<-s.reloaded
+			continue
+		}
+
+		for _, sc := range due {
+			slog.Info("Next report", "schedule", sc.Name, "mode", sc.Mode, "at", runAt.Format(time.RFC1123))
+		}
+
+		select {
+		case <-time.After(time.Until(runAt)):
+			for _, sc := range due {
+				go s.runSchedule(cfg, sc)
+			}
+		case <-s.reloaded:
+		}
+	}
+}
+
+// runSchedule generates the schedule's report and delivers it, holding it
+// until the delivery window is open
+func (s *scheduler) runSchedule(cfg *config, sc schedule) {
+	ctx, cancel := runContext(context.Background())
+	rep, err := generateReport(ctx, cfg, reportOptions{localRoot: os.Getenv("LOCAL_REPOS"), mode: sc.Mode, webhook: s.webhook, summary: sc.Summary})
+	cancel()
+	if errors.Is(err, errNothingMissed) {
+		slog.Info("Nothing to reconcile", "schedule", sc.Name)
+		metrics.run(sc.Name, runSkipped)
+		return
+	}
+	if err != nil {
+		slog.Error("Report failed", "schedule", sc.Name, "err", err)
+		metrics.run(sc.Name, runFailed)
+		return
+	}
+
+	// Only final reports are kept in the history
+	if sc.Mode == modeFinal {
+		if err := applyDuplicatePolicy(rep, cfg.duplicatePolicy()); err != nil {
+			slog.Error("Report not delivered", "schedule", sc.Name, "err", err)
+			metrics.run(sc.Name, runSkipped)
+			return
+		}
+		if err := recordHistory(rep, rep.render(nil)); err != nil {
+			slog.Error("Saving the report failed", "schedule", sc.Name, "err", err)
+		}
+	}
+
+	// Hold the report until the delivery window is open
+	if sendAt := s.window.next(time.Now()); !sc.IgnoreQuietHours && sendAt.After(time.Now()) {
+		slog.Info("Holding report", "schedule", sc.Name, "until", sendAt.Format(time.RFC1123))
+		time.Sleep(time.Until(sendAt))
+	}
+
+	// Targets may have changed in a reload while the report was held
+	targets := s.config().resolveTargets(sc.Targets)
+	ctx, cancel = runContext(context.Background())
+	defer cancel()
+	if err := deliverToTargets(ctx, s.config().Hooks, rep, rep.render(nil), targets); err != nil {
+		slog.Error("Delivery failed", "schedule", sc.Name, "err", err)
+		metrics.deliveryFailed(sc.Name)
+		metrics.run(sc.Name, runFailed)
+		return
+	}
+	slog.Info("Report delivered", "schedule", sc.Name)
+	metrics.run(sc.Name, runSucceeded)
+
+	// Check off the Notion tasks of what got merged, once delivered
+	if sc.Mode == modeFinal && cfg.NotionTasks != nil {
+		if err := completeNotionTasks(ctx, cfg.NotionTasks, rep); err != nil {
+			slog.Warn("Checking off Notion tasks failed", "schedule", sc.Name, "err", err)
+		}
+	}
+}
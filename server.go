@@ -0,0 +1,146 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// A report as exchanged through the server API
+type apiReport struct {
+	Date      string `json:"date"`
+	Report    string `json:"report,omitempty"`
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+// A manual entry as exchanged through the server API
+type apiEntry struct {
+	Date   string `json:"date"`
+	Status string `json:"status"`
+	Title  string `json:"title"`
+}
+
+// serveAPI serves the report API used by the --server client:
+//
+//	POST /api/reports          generate and store today's report
+//	GET  /api/reports          list the stored reports
+//	GET  /api/reports/{date}   show a stored report
+//	POST /api/entries          add a manual entry
+//
+// Every request needs "Authorization: Bearer $SERVER_TOKEN".
+func (s *scheduler) serveAPI(addr, token string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/reports", s.handleGenerate)
+	mux.HandleFunc("GET /api/reports", handleListReports)
+	mux.HandleFunc("GET /api/reports/{date}", handleShowReport)
+	mux.HandleFunc("POST /api/entries", handleAddEntry)
+
+	slog.Info("Serving the API", "addr", addr)
+	fatal("API server stopped", "err", http.ListenAndServe(addr, requireToken(token, mux)))
+}
+
+func requireToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func (s *scheduler) handleGenerate(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := runContext(r.Context())
+	defer cancel()
+	rep, err := generateReport(ctx, s.config(), reportOptions{localRoot: os.Getenv("LOCAL_REPOS"), mode: modeFinal, webhook: s.webhook})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	if err := applyDuplicatePolicy(rep, s.config().duplicatePolicy()); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	report := rep.render(nil)
+	if err := recordHistory(rep, report); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, apiReport{Date: rep.date, Report: report})
+}
+
+func handleListReports(w http.ResponseWriter, r *http.Request) {
+	h, err := openHistory(historyPath())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer h.Close()
+
+	records, err := h.list()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	reports := []apiReport{}
+	for _, record := range records {
+		reports = append(reports, apiReport{Date: record.date, CreatedAt: record.createdAt.Format(time.RFC3339)})
+	}
+	writeJSON(w, reports)
+}
+
+func handleShowReport(w http.ResponseWriter, r *http.Request) {
+	h, err := openHistory(historyPath())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer h.Close()
+
+	record, err := h.get(r.PathValue("date"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, apiReport{Date: record.date, Report: record.report, CreatedAt: record.createdAt.Format(time.RFC3339)})
+}
+
+func handleAddEntry(w http.ResponseWriter, r *http.Request) {
+	var e apiEntry
+	if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if e.Date == "" {
+		e.Date = time.Now().Format(dateFormat)
+	}
+	if _, err := time.Parse(dateFormat, e.Date); err != nil || e.Status == "" || e.Title == "" {
+		http.Error(w, "date (YYYY-MM-DD), status and title are required", http.StatusBadRequest)
+		return
+	}
+
+	h, err := openHistory(historyPath())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer h.Close()
+
+	if err := h.addManual(e.Date, reportEntry{status: e.Status, title: e.Title}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
@@ -0,0 +1,14 @@
+package main
+
+import (
+	"context"
+)
+
+// postToSlack sends the report to a Slack channel through its incoming
+// webhook URL, tables as a code block so their columns line up
+func postToSlack(ctx context.Context, t target, report string) error {
+	if t.Format == formatCSV || t.Format == formatTSV {
+		report = "```\n" + report + "```"
+	}
+	return sendJSON(ctx, "POST", t.URL, nil, map[string]string{"text": report}, nil)
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	htmltemplate "html/template"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Share of its rate limit left, in percent, under which an API is shown as
+// running low
+const lowRateLimitShare = 10
+
+// sourceHealth is how a source of the report has been doing since serve
+// started, for /status
+type sourceHealth struct {
+	lastSuccess, lastFailure time.Time
+	lastError                string
+	successes, failures      int
+}
+
+// rateLimit is the rate limit an API last answered with
+type rateLimit struct {
+	limit, remaining int
+	reset, seen      time.Time
+}
+
+// Whether the run of a source made a request or used a cached response,
+// telling the sources that fetched apart from those turned off
+type sourceUsed struct{ atomic.Bool }
+
+type sourceUsedKey struct{}
+
+// noteSourceUsed records that the source running in ctx fetched something
+func noteSourceUsed(ctx context.Context) {
+	if used, ok := ctx.Value(sourceUsedKey{}).(*sourceUsed); ok {
+		used.Store(true)
+	}
+}
+
+// tracked wraps a source of the report to count its successes and
+// failures. Sources that fetched nothing, being turned off, aren't counted.
+func (m *serveMetrics) tracked(name string, fetch func(ctx context.Context) error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		used := &sourceUsed{}
+		err := fetch(context.WithValue(ctx, sourceUsedKey{}, used))
+		if err == nil && !used.Load() {
+			return nil
+		}
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		s, ok := m.sources[name]
+		if !ok {
+			s = &sourceHealth{}
+			m.sources[name] = s
+		}
+		if err != nil {
+			s.failures++
+			s.lastFailure, s.lastError = time.Now(), err.Error()
+		} else {
+			s.successes++
+			s.lastSuccess = time.Now()
+		}
+		return err
+	}
+}
+
+// noteRateLimit keeps the rate limit headers of an API's response, as sent
+// by GitHub and others
+func (m *serveMetrics) noteRateLimit(resp *http.Response) {
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	limit, _ := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+	l := rateLimit{limit: limit, remaining: remaining, seen: time.Now()}
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		l.reset = time.Unix(reset, 0)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rateLimits[resp.Request.URL.Host] = l
+}
+
+// trackingTransport notes the requests of each source and the rate limits
+// of the APIs for /status
+type trackingTransport struct {
+	base http.RoundTripper
+}
+
+func (t trackingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	noteSourceUsed(req.Context())
+	resp, err := t.base.RoundTrip(req)
+	if err == nil {
+		metrics.noteRateLimit(resp)
+	}
+	return resp, err
+}
+
+// ago writes how long ago t was, e.g. "5m ago", "never" when zero
+func ago(t time.Time, now time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return now.Sub(t).Round(time.Second).String() + " ago"
+}
+
+// handleStatus serves a page with the health of each source of the report,
+// failing ones first, the rate limit headroom of the APIs and the result of
+// the last run of each schedule
+func (m *serveMetrics) handleStatus(w http.ResponseWriter, r *http.Request) {
+	pageHTML, err := readAsset("web/status.html")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	page, err := htmltemplate.New("status").Parse(string(pageHTML))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	type sourceView struct {
+		Name, LastSuccess, LastFailure, LastError string
+		Successes, Failures                       int
+		Failing                                   bool
+	}
+	type rateLimitView struct {
+		API, Reset, Seen        string
+		Remaining, Limit, Share int
+		Low                     bool
+	}
+	type scheduleView struct {
+		Name, Result, LastSuccess string
+	}
+	now := time.Now()
+
+	m.mu.Lock()
+	var sources []sourceView
+	for _, name := range sortedKeys(m.sources) {
+		s := m.sources[name]
+		sources = append(sources, sourceView{
+			Name:        name,
+			LastSuccess: ago(s.lastSuccess, now),
+			LastFailure: ago(s.lastFailure, now),
+			LastError:   s.lastError,
+			Successes:   s.successes,
+			Failures:    s.failures,
+			Failing:     s.lastFailure.After(s.lastSuccess),
+		})
+	}
+	var limits []rateLimitView
+	for _, api := range sortedKeys(m.rateLimits) {
+		l := m.rateLimits[api]
+		v := rateLimitView{API: api, Remaining: l.remaining, Limit: l.limit, Reset: "unknown", Seen: ago(l.seen, now)}
+		if l.limit > 0 {
+			v.Share = 100 * l.remaining / l.limit
+			v.Low = v.Share < lowRateLimitShare
+		}
+		if !l.reset.IsZero() {
+			v.Reset = l.reset.Local().Format("15:04:05")
+		}
+		limits = append(limits, v)
+	}
+	var schedules []scheduleView
+	for _, name := range sortedKeys(m.lastResult) {
+		schedules = append(schedules, scheduleView{Name: name, Result: m.lastResult[name], LastSuccess: ago(m.lastSuccess[name], now)})
+	}
+	m.mu.Unlock()
+
+	slices.SortStableFunc(sources, func(a, b sourceView) int {
+		switch {
+		case a.Failing == b.Failing:
+			return 0
+		case a.Failing:
+			return -1
+		}
+		return 1
+	})
+	err = page.Execute(w, map[string]interface{}{
+		"Now":       now.Format(time.RFC1123),
+		"Sources":   sources,
+		"Limits":    limits,
+		"Schedules": schedules,
+	})
+	if err != nil {
+		slog.Warn("Rendering the status page failed", "err", err)
+	}
+}
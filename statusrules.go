@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// eventFacts are what the status of a pull request, review or commit event
+// is decided on
+type eventFacts struct {
+	// The pull request, review or commit is mine
+	mine bool
+	// Action of the event, e.g. "opened" or "closed", "committed" for
+	// local commits
+	action string
+	// State of the review in lower case, e.g. "approved", empty for the
+	// other events
+	review string
+	merged bool
+	draft  bool
+}
+
+// A condition of a rule on a yes or no fact
+type condition int
+
+const (
+	anyValue condition = iota
+	yes
+	no
+)
+
+func (c condition) matches(fact bool) bool {
+	return c == anyValue || (c == yes) == fact
+}
+
+// statusRule is a row of the status decision table: the events whose facts
+// meet all its conditions get its status. Empty action and review match
+// any.
+type statusRule struct {
+	name   string
+	mine   condition
+	action string
+	review string
+	merged condition
+	draft  condition
+	status string
+}
+
+func (r statusRule) matches(f eventFacts) bool {
+	return r.mine.matches(f.mine) && (r.action == "" || r.action == f.action) && (r.review == "" || r.review == f.review) &&
+		r.merged.matches(f.merged) && r.draft.matches(f.draft)
+}
+
+// statusRules decide the status of events, the first matching rule winning:
+// my reviews whatever their pull request, then my pull requests and
+// commits, then the pull requests of others I closed. Events no rule
+// matches aren't reported.
+var statusRules = []statusRule{
+	{name: "my-approval", mine: yes, review: "approved", status: "Reviewed (approved)"},
+	{name: "my-change-request", mine: yes, review: "changes_requested", status: "Requested changes"},
+	{name: "my-review-comment", mine: yes, review: "commented", status: "Reviewed (commented)"},
+	{name: "my-merged-pr", mine: yes, merged: yes, status: "Done"},
+	{name: "my-opened-draft", mine: yes, action: "opened", draft: yes, status: "WIP"},
+	{name: "my-opened-pr", mine: yes, action: "opened", status: "In Review"},
+	{name: "my-commit", mine: yes, action: "committed", status: "Committed"},
+	{name: "merged-pr-of-others", mine: no, action: "closed", merged: yes, status: "Reviewed and merged"},
+	{name: "closed-pr-of-others", mine: no, action: "closed", status: "Reviewed"},
+}
+
+// matchStatusRule returns the first rule matching the facts
+func matchStatusRule(f eventFacts) (statusRule, bool) {
+	for _, rule := range statusRules {
+		if rule.matches(f) {
+			return rule, true
+		}
+	}
+	return statusRule{}, false
+}
+
+// rulesCommand implements "rules explain [--user LOGIN] <event.json>",
+// printing the facts of a GitHub event, read from the file or from stdin
+// with "-", and the status rule they match
+func rulesCommand(args []string) error {
+	if len(args) == 0 || args[0] != "explain" {
+		return errors.New("usage: rules explain [--user LOGIN] <event.json>")
+	}
+	fs := flag.NewFlagSet("rules explain", flag.ExitOnError)
+	user := fs.String("user", "", "login the report is about, github.username of the config by default")
+	fs.Parse(args[1:])
+	if fs.NArg() != 1 {
+		return errors.New("usage: rules explain [--user LOGIN] <event.json>")
+	}
+
+	username := *user
+	if username == "" {
+		cfg, err := loadConfig(configPath())
+		if err != nil {
+			return fmt.Errorf("%w, or give --user", err)
+		}
+		username = cfg.GitHub.Username
+	}
+
+	var data []byte
+	var err error
+	if fs.Arg(0) == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(fs.Arg(0))
+	}
+	if err != nil {
+		return err
+	}
+	var event map[string]interface{}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return fmt.Errorf("%s: %w", fs.Arg(0), err)
+	}
+
+	entry, facts := classifyEventFacts(event, username)
+	fmt.Printf("event:  %s %v\n", entry.eventType, event["id"])
+	switch entry.eventType {
+	case "CreateEvent", "DeleteEvent", "ReleaseEvent":
+		status := entry.status
+		if status == "" {
+			status = "none, the event isn't reported"
+		}
+		fmt.Printf("Branches, tags and releases aren't decided by the status rules\nstatus: %s\n", status)
+		return nil
+	}
+	fmt.Printf("facts:  mine=%t action=%q review=%q merged=%t draft=%t\n", facts.mine, facts.action, facts.review, facts.merged, facts.draft)
+	rule, ok := matchStatusRule(facts)
+	if !ok {
+		fmt.Println("rule:   none, the event isn't reported")
+		return nil
+	}
+	fmt.Printf("rule:   %s\nstatus: %s\n", rule.name, rule.status)
+	if entry.title == "" {
+		fmt.Println("The event has no title, so it isn't reported")
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatchStatusRule(t *testing.T) {
+	tests := []struct {
+		name   string
+		facts  eventFacts
+		rule   string
+		status string
+	}{
+		{"my approval", eventFacts{mine: true, action: "created", review: "approved"}, "my-approval", "Reviewed (approved)"},
+		{"my approval of a merged pull request", eventFacts{mine: true, action: "created", review: "approved", merged: true}, "my-approval", "Reviewed (approved)"},
+		{"my change request", eventFacts{mine: true, action: "created", review: "changes_requested"}, "my-change-request", "Requested changes"},
+		{"my review comment", eventFacts{mine: true, action: "created", review: "commented"}, "my-review-comment", "Reviewed (commented)"},
+		{"my pull request merged", eventFacts{mine: true, action: "closed", merged: true}, "my-merged-pr", "Done"},
+		{"my pull request opened merged", eventFacts{mine: true, action: "opened", merged: true}, "my-merged-pr", "Done"},
+		{"my draft opened merged", eventFacts{mine: true, action: "opened", merged: true, draft: true}, "my-merged-pr", "Done"},
+		{"my pull request reopened merged", eventFacts{mine: true, action: "reopened", merged: true}, "my-merged-pr", "Done"},
+		{"my draft opened", eventFacts{mine: true, action: "opened", draft: true}, "my-opened-draft", "WIP"},
+		{"my pull request opened", eventFacts{mine: true, action: "opened"}, "my-opened-pr", "In Review"},
+		{"my local commit", eventFacts{mine: true, action: "committed"}, "my-commit", "Committed"},
+		{"pull request of others merged", eventFacts{action: "closed", merged: true}, "merged-pr-of-others", "Reviewed and merged"},
+		{"pull request of others closed", eventFacts{action: "closed"}, "closed-pr-of-others", "Reviewed"},
+		{"my pull request closed unmerged", eventFacts{mine: true, action: "closed"}, "", ""},
+		{"my pull request reopened", eventFacts{mine: true, action: "reopened"}, "", ""},
+		{"my review dismissed", eventFacts{mine: true, action: "created", review: "dismissed"}, "", ""},
+		{"pull request of others opened", eventFacts{action: "opened"}, "", ""},
+		{"review of others", eventFacts{action: "created", review: "approved"}, "", ""},
+		{"no facts", eventFacts{}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, ok := matchStatusRule(tt.facts)
+			if ok != (tt.rule != "") || rule.name != tt.rule || rule.status != tt.status {
+				t.Errorf("matchStatusRule(%+v) = %q %q, want %q %q", tt.facts, rule.name, rule.status, tt.rule, tt.status)
+			}
+		})
+	}
+}
+
+// Every rule must be the first match of some facts, or an earlier rule
+// shadows it
+func TestStatusRulesReachable(t *testing.T) {
+	actions := []string{"", "opened", "closed", "reopened", "created", "committed"}
+	reviews := []string{"", "approved", "changes_requested", "commented", "dismissed"}
+	bools := []bool{false, true}
+
+	first := make(map[string]int)
+	for _, mine := range bools {
+		for _, action := range actions {
+			for _, review := range reviews {
+				for _, merged := range bools {
+					for _, draft := range bools {
+						facts := eventFacts{mine: mine, action: action, review: review, merged: merged, draft: draft}
+						if rule, ok := matchStatusRule(facts); ok {
+							first[rule.name]++
+						}
+					}
+				}
+			}
+		}
+	}
+	for _, rule := range statusRules {
+		if first[rule.name] == 0 {
+			t.Errorf("rule %s never matches first", rule.name)
+		}
+	}
+}
+
+func TestClassifyEventFixtures(t *testing.T) {
+	tests := []struct {
+		file   string
+		status string
+		title  string
+	}{
+		{"pr_opened.json", "In Review", "Add greeting"},
+		{"pr_opened_draft.json", "WIP", "Try a new layout"},
+		{"pr_merged.json", "Done", "Add greeting"},
+		{"pr_of_others_merged.json", "Reviewed and merged", "Fix typo"},
+		{"pr_of_others_closed.json", "Reviewed", "Rewrite in Rust"},
+		{"review_approved.json", "Reviewed (approved)", "Fix typo"},
+		{"review_changes_requested.json", "Requested changes", "Drop Windows support"},
+		{"review_commented.json", "Reviewed (commented)", "Bump dependencies"},
+		{"pr_closed_unmerged.json", "", "Try another greeting"},
+		{"review_of_others.json", "", "Add greeting"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "events", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var event map[string]interface{}
+			if err := json.Unmarshal(data, &event); err != nil {
+				t.Fatal(err)
+			}
+			entry := classifyEvent(event, "octocat")
+			if entry.status != tt.status || entry.title != tt.title {
+				t.Errorf("classifyEvent(%s) = %q %q, want %q %q", tt.file, entry.status, entry.title, tt.status, tt.title)
+			}
+		})
+	}
+}
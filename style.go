@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Outputs styles can be set for: text reports (files, stdout, git and
+// email bodies), tables, and the targets rendering the report themselves
+var styleOutputs = []string{formatText, formatCSV, formatTSV, "slack", "google_chat", "github", "notion"}
+
+// entryStyle is how the entries of an output are written
+type entryStyle struct {
+	// Written at the start of each entry line, e.g. "• " or "- "
+	Bullet string `json:"bullet,omitempty"`
+	// Written instead of the status, by status, e.g. {"Done": "✅",
+	// "In Review": "👀"}
+	Emoji map[string]string `json:"emoji,omitempty"`
+}
+
+func validStyles(styles map[string]entryStyle) error {
+	for output := range styles {
+		if !slices.Contains(styleOutputs, output) {
+			return fmt.Errorf("unknown output %q, expected one of %s", output, strings.Join(styleOutputs, ", "))
+		}
+	}
+	return nil
+}
+
+// styleOutput returns the output whose style a target uses: its table
+// format, text for the targets writing the text report, or its type
+func (t target) styleOutput() string {
+	switch {
+	case t.Type == "email":
+		return formatText
+	case t.Format == formatCSV || t.Format == formatTSV || t.Format == formatPDF || t.Format == formatHTML:
+		return t.Format
+	case t.Type == "file" || t.Type == "stdout" || t.Type == "git":
+		return formatText
+	}
+	return t.Type
+}
+
+// withStyle returns the target with the style of its output, if any
+func (cfg *config) withStyle(t target) target {
+	if style, ok := cfg.Styles[t.styleOutput()]; ok {
+		t.style = &style
+	}
+	return t
+}
+
+// statusText is the status as written in the report: its emoji if styled,
+// translated otherwise
+func (e reportEntry) statusText(c catalog) string {
+	if e.emoji != "" {
+		return e.emoji
+	}
+	return c.text(e.status)
+}
+
+// styled returns the report with the entries written in the style
+func (r *generatedReport) styled(style entryStyle) *generatedReport {
+	styled := *r
+	styled.meetings, styled.entries = slices.Clone(r.meetings), slices.Clone(r.entries)
+	for _, entries := range [][]reportEntry{styled.meetings, styled.entries} {
+		for i := range entries {
+			entries[i].bullet, entries[i].emoji = style.Bullet, style.Emoji[entries[i].status]
+		}
+	}
+	if styled.reconciled != nil {
+		styled.reconciled = r.reconciled.styled(style)
+	}
+	return &styled
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// summaryConfig sets up the LLM writing the summary of --summary
+type summaryConfig struct {
+	// "openai" or "anthropic"
+	Provider string `json:"provider"`
+	// Model to use, a small one of the provider by default
+	Model string `json:"model,omitempty"`
+	// API key, OPENAI_API_KEY or ANTHROPIC_API_KEY by default
+	APIKey string `json:"api_key,omitempty"`
+	// API base URL, for compatible APIs and proxies
+	URL string `json:"url,omitempty"`
+}
+
+// summarizer turns a prompt into text
+type summarizer interface {
+	summarize(ctx context.Context, prompt string) (string, error)
+}
+
+// Summary providers, by name
+var summaryProviders = map[string]func(c summaryConfig) summarizer{
+	"openai":    func(c summaryConfig) summarizer { return openAISummarizer{c} },
+	"anthropic": func(c summaryConfig) summarizer { return anthropicSummarizer{c} },
+}
+
+// pick returns value, or env from the environment, or def
+func pick(value, env, def string) string {
+	if value != "" {
+		return value
+	}
+	if v := os.Getenv(env); v != "" {
+		return v
+	}
+	return def
+}
+
+type openAISummarizer struct{ summaryConfig }
+
+func (s openAISummarizer) summarize(ctx context.Context, prompt string) (string, error) {
+	body := map[string]interface{}{
+		"model":    pick(s.Model, "", "gpt-4o-mini"),
+		"messages": []map[string]string{{"role": "user", "content": prompt}},
+	}
+	headers := map[string]string{"Authorization": "Bearer " + pick(s.APIKey, "OPENAI_API_KEY", "")}
+	var result struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	u := pick(s.URL, "", "https://api.openai.com/v1") + "/chat/completions"
+	if err := sendJSON(ctx, "POST", u, headers, body, &result); err != nil {
+		return "", err
+	}
+	if len(result.Choices) == 0 {
+		return "", errors.New("openai: no summary returned")
+	}
+	return result.Choices[0].Message.Content, nil
+}
+
+type anthropicSummarizer struct{ summaryConfig }
+
+func (s anthropicSummarizer) summarize(ctx context.Context, prompt string) (string, error) {
+	body := map[string]interface{}{
+		"model":      pick(s.Model, "", "claude-3-5-haiku-latest"),
+		"max_tokens": 300,
+		"messages":   []map[string]string{{"role": "user", "content": prompt}},
+	}
+	headers := map[string]string{
+		"x-api-key":         pick(s.APIKey, "ANTHROPIC_API_KEY", ""),
+		"anthropic-version": "2023-06-01",
+	}
+	var result struct {
+		Content []struct {
+			Text string `json:"text"`
+		} `json:"content"`
+	}
+	u := pick(s.URL, "", "https://api.anthropic.com/v1") + "/messages"
+	if err := sendJSON(ctx, "POST", u, headers, body, &result); err != nil {
+		return "", err
+	}
+	if len(result.Content) == 0 {
+		return "", errors.New("anthropic: no summary returned")
+	}
+	return result.Content[0].Text, nil
+}
+
+// Messages of the commits pushed to the repositories, merges left out
+func commitMessages(events []map[string]interface{}, repos map[string]bool) []string {
+	var messages []string
+	for _, event := range events {
+		repo, _ := event["repo"].(map[string]interface{})
+		name, _ := repo["name"].(string)
+		if event["type"] != "PushEvent" || !repos[name] {
+			continue
+		}
+		payload, _ := event["payload"].(map[string]interface{})
+		commits, _ := payload["commits"].([]interface{})
+		for _, c := range commits {
+			commit, _ := c.(map[string]interface{})
+			message, _ := commit["message"].(string)
+			// The subject line is enough
+			message, _, _ = strings.Cut(message, "\n")
+			if message != "" && !strings.HasPrefix(message, "Merge ") {
+				messages = append(messages, fmt.Sprintf("%s: %s", name, message))
+			}
+		}
+	}
+	return messages
+}
+
+// summaryPrompt asks for the summary of the entries and commit messages
+func summaryPrompt(entries []reportEntry, commits []string, language string) string {
+	var b strings.Builder
+	b.WriteString("Write a summary of my day of work for my daily report: 2 to 3 sentences of plain prose, " +
+		"no list, no heading, no greeting, only what is below.")
+	if language == "ja" {
+		b.WriteString(" Write it in Japanese.")
+	}
+	b.WriteString("\n\nReport entries (status | title | repository):\n")
+	for _, entry := range entries {
+		fmt.Fprintf(&b, "- %s | %s | %s\n", entry.status, entry.title, entry.repo)
+	}
+	if len(commits) > 0 {
+		b.WriteString("\nCommit messages:\n")
+		for _, message := range commits {
+			fmt.Fprintf(&b, "- %s\n", message)
+		}
+	}
+	return b.String()
+}
+
+// summarizeDay has the configured provider summarize the entries of the
+// report, with the commit messages of their repositories. The summary goes
+// to every target, so it is only written from the entries the least private
+// of them receives, team ones at most; hidden entries are left out.
+func summarizeDay(ctx context.Context, cfg *config, rep *generatedReport) (reportSection, error) {
+	section := reportSection{title: "Summary"}
+	if cfg.Summary == nil {
+		return section, errors.New("summary: no provider configured")
+	}
+	if offline {
+		return section, errOffline
+	}
+
+	restricted, _ := rep.restrictedTo(cfg.summaryPrivacy())
+	var entries []reportEntry
+	repos := make(map[string]bool)
+	for _, entry := range filterEntries(restricted.entries, nil) {
+		entries = append(entries, entry)
+		repos[entry.repo] = true
+	}
+	if len(entries) == 0 {
+		return section, nil
+	}
+
+	prompt := summaryPrompt(entries, commitMessages(rep.events, repos), cfg.language())
+	text, err := summaryProviders[cfg.Summary.Provider](*cfg.Summary).summarize(ctx, prompt)
+	if err != nil {
+		return section, fmt.Errorf("summary: %w", err)
+	}
+	section.lines = []reportLine{{text: strings.Join(strings.Fields(text), " ")}}
+	return section, nil
+}
+
+// summaryPrivacy is the privacy level of the least private target of the
+// config, team unless one receives only public entries
+func (cfg *config) summaryPrivacy() string {
+	level := privacyTeam
+	for _, t := range cfg.Targets {
+		if privacyRank(t.privacyLevel()) < privacyRank(level) {
+			level = t.privacyLevel()
+		}
+	}
+	return level
+}
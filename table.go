@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Columns of csv and tsv reports, in their default order
+var tableColumns = []string{"date", "status", "title", "repo", "url", "event_type"}
+
+// Columns of csv and tsv reports only written when asked for
+var extraColumns = []string{"hours"}
+
+// Formats a report can be rendered in besides the default text
+const (
+	formatText = "text"
+	formatCSV  = "csv"
+	formatTSV  = "tsv"
+	formatPDF  = "pdf"
+	formatHTML = "html"
+)
+
+// url links to the entry's pull request or commit, "" for other entries
+func (e reportEntry) url() string {
+	switch {
+	case !strings.Contains(e.repo, "/"):
+		return ""
+	case e.number > 0:
+		return fmt.Sprintf("https://github.com/%s/pull/%d", e.repo, e.number)
+	case e.sha != "":
+		return fmt.Sprintf("https://github.com/%s/commit/%s", e.repo, e.sha)
+	case e.eventType == "ReleaseEvent":
+		return fmt.Sprintf("https://github.com/%s/releases/tag/%s", e.repo, e.ref)
+	}
+	return ""
+}
+
+// column returns the entry's value for a table column
+func (e reportEntry) column(date, name string) string {
+	switch name {
+	case "date":
+		return date
+	case "status":
+		if e.emoji != "" {
+			return e.emoji
+		}
+		return e.status
+	case "title":
+		return e.title
+	case "repo":
+		return e.repo
+	case "url":
+		return e.url()
+	case "hours":
+		return strings.TrimSuffix(formatHours(e.hours()), "h")
+	case "event_type":
+		if e.eventType == "" {
+			return "manual"
+		}
+		return e.eventType
+	}
+	return ""
+}
+
+// renderTable renders the meetings and entries as csv or tsv with a header
+// row, one row per entry with the given columns, all of them by default
+func (r *generatedReport) renderTable(format string, columns []string, orgs []string) (string, error) {
+	if len(columns) == 0 {
+		columns = tableColumns
+	}
+
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	if format == formatTSV {
+		w.Comma = '\t'
+	}
+	w.Write(columns)
+
+	meetings := make([]reportEntry, len(r.meetings))
+	for i, meeting := range r.meetings {
+		meeting.eventType = "meeting"
+		meetings[i] = meeting
+	}
+	for _, entry := range append(meetings, filterEntries(r.entries, orgs)...) {
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			row[i] = entry.column(r.date, column)
+		}
+		w.Write(row)
+	}
+	w.Flush()
+	return b.String(), w.Error()
+}
+
+// validFormat checks a report format and table columns
+func validFormat(format string, columns []string) error {
+	switch format {
+	case "", formatText, formatCSV, formatTSV, formatPDF, formatHTML:
+	default:
+		return fmt.Errorf("unknown format %q, expected text, csv, tsv, pdf or html", format)
+	}
+	for _, column := range columns {
+		if !slices.Contains(tableColumns, column) && !slices.Contains(extraColumns, column) {
+			return fmt.Errorf("unknown column %q, expected one of %s", column, strings.Join(append(slices.Clone(tableColumns), extraColumns...), ", "))
+		}
+	}
+	return nil
+}
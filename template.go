@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	htmltemplate "html/template"
+	"log/slog"
+	"net/http"
+	"os"
+	"text/template"
+	"time"
+)
+
+// What report templates are given: the header, the blocks of entries (see
+// buildBlocks), the sections and the Next lines. Statuses and titles of
+// blocks and sections are translated.
+type reportTemplateData struct {
+	Header   string
+	Blocks   []templateBlock
+	Sections []templateSection
+	Next     []string
+}
+
+type templateBlock struct {
+	Title   string
+	Entries []templateEntry
+}
+
+type templateEntry struct {
+	Status, Title, Repo, Severity, Category, URL string
+	Commits                                      []string
+}
+
+type templateSection struct {
+	Title string
+	Lines []string
+}
+
+// newReportTemplateData lays the report out for a template like
+// formatEntries does, leaving out empty sections
+func newReportTemplateData(header string, meetings, entries []reportEntry, sections []reportSection, next []reportLine, grouping entryGrouping, c catalog) reportTemplateData {
+	data := reportTemplateData{Header: header}
+	for _, block := range buildBlocks(meetings, entries, grouping) {
+		b := templateBlock{Title: c.text(block.title)}
+		for _, entry := range block.entries {
+			b.Entries = append(b.Entries, templateEntry{
+				Status:   entry.statusText(c),
+				Title:    entry.title,
+				Repo:     entry.repo,
+				Severity: entry.severity,
+				Category: entry.category,
+				URL:      entry.url(),
+				Commits:  entry.commits,
+			})
+		}
+		if len(b.Entries) > 0 {
+			data.Blocks = append(data.Blocks, b)
+		}
+	}
+	for _, section := range sections {
+		if len(section.lines) == 0 {
+			continue
+		}
+		s := templateSection{Title: c.text(section.title)}
+		for _, line := range section.lines {
+			s.Lines = append(s.Lines, line.text)
+		}
+		data.Sections = append(data.Sections, s)
+	}
+	if len(next) == 0 {
+		next = []reportLine{{text: c.text(defaultNext)}}
+	}
+	for _, line := range next {
+		data.Next = append(data.Next, line.text)
+	}
+	return data
+}
+
+// loadReportTemplate parses the text/template file of the daily report
+func loadReportTemplate(path string) (*template.Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return template.New("report").Parse(string(data))
+}
+
+func executeTemplate(tmpl *template.Template, data interface{}) (string, error) {
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// sampleReportData is the report rendered by "template dev": a bit of
+// everything a day can have
+func sampleReportData(cfg *config) reportTemplateData {
+	entries := []reportEntry{
+		{status: "Done", title: "Fix checkout outage", repo: "octocat/shop", number: 41, severity: "SEV1"},
+		{status: "Done", title: "Add invoice export", repo: "octocat/shop", number: 38},
+		{status: "In Review", title: "Rework the search index", repo: "octocat/search", number: 7},
+		{status: "WIP", title: "Migrate the orders table", repo: "octocat/shop", number: 44},
+		{status: "Reviewed (approved)", title: "Upgrade Go", repo: "hubot/tools", number: 12},
+		{status: "Done", title: "Helped onboard intern"},
+	}
+	sections := []reportSection{
+		{title: "Feedback received", lines: []reportLine{{text: "Add invoice export | 2 comments, approved by alice"}}},
+		{title: "Newly assigned", lines: []reportLine{{text: "Refunds API"}}},
+	}
+	next := []reportLine{{text: "Review Upgrade Node"}, {text: "Address requested changes on Rework the search index"}}
+	return newReportTemplateData(cfg.formatHeaderDate(time.Now()), defaultEntries, entries, sections, next, cfg.entryGrouping(), cfg.messages())
+}
+
+// templateCommand implements "template dev [--addr localhost:8090] [file]",
+// serving a preview of the sample report rendered with the template file,
+// report_template by default
+func templateCommand(args []string) error {
+	if len(args) == 0 || args[0] != "dev" {
+		return errors.New("usage: template dev [--addr localhost:8090] [file]")
+	}
+	fs := flag.NewFlagSet("template dev", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:8090", "address to serve the preview on")
+	fs.Parse(args[1:])
+
+	cfg, err := loadConfig(configPath())
+	if err != nil {
+		return err
+	}
+	path := cfg.ReportTemplate
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+	if path == "" {
+		return errors.New("no template file given and no report_template in the config")
+	}
+
+	// The page shows the rendered sample, and reloads through the event
+	// stream whenever the template changes
+	pageHTML, err := readAsset("web/template_dev.html")
+	if err != nil {
+		return err
+	}
+	devPage, err := htmltemplate.New("dev").Parse(string(pageHTML))
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		page := struct{ Path, Report, Err string }{Path: path}
+		tmpl, err := loadReportTemplate(path)
+		if err == nil {
+			page.Report, err = executeTemplate(tmpl, sampleReportData(cfg))
+		}
+		if err != nil {
+			page.Err = err.Error()
+		}
+		devPage.Execute(w, page)
+	})
+	mux.HandleFunc("GET /events", func(w http.ResponseWriter, r *http.Request) {
+		watchTemplate(w, r, path)
+	})
+
+	slog.Info("Previewing the template", "file", path, "url", "http://"+*addr)
+	return http.ListenAndServe(*addr, mux)
+}
+
+// watchTemplate streams an event to the preview page each time the file
+// is modified, checking twice a second
+func watchTemplate(w http.ResponseWriter, r *http.Request, path string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	flusher.Flush()
+
+	modTime := func() time.Time {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}
+		}
+		return info.ModTime()
+	}
+	last := modTime()
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if t := modTime(); !t.Equal(last) {
+				last = t
+				fmt.Fprint(w, "data: reload\n\n")
+				flusher.Flush()
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
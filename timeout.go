@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Defaults for HTTP_TIMEOUT, the limit of a single API request, and
+// RUN_TIMEOUT, the limit of generating or delivering a report
+const (
+	defaultHTTPTimeout = 30 * time.Second
+	defaultRunTimeout  = 10 * time.Minute
+)
+
+// Client used for every API call, see setHTTPTimeout. Its transport keeps
+// what serve's /status shows.
+var httpClient = &http.Client{Timeout: defaultHTTPTimeout, Transport: trackingTransport{http.DefaultTransport}}
+
+// Largest API response read, so a runaway one can't exhaust the memory
+const maxResponseBody = 32 << 20
+
+// boundedReader reads a response body, failing once it goes past
+// maxResponseBody rather than cutting it short
+type boundedReader struct {
+	r    io.Reader
+	left int64
+}
+
+func boundedBody(body io.Reader) io.Reader {
+	return &boundedReader{r: body, left: maxResponseBody}
+}
+
+func (b *boundedReader) Read(p []byte) (int, error) {
+	// One byte more than allowed tells a response of the largest size from
+	// a larger one
+	if int64(len(p)) > b.left+1 {
+		p = p[:b.left+1]
+	}
+	n, err := b.r.Read(p)
+	if b.left -= int64(n); b.left < 0 {
+		return n, fmt.Errorf("response larger than %d MB", maxResponseBody>>20)
+	}
+	return n, err
+}
+
+// Read a duration such as "45s" from the environment, falling back to def
+func durationFromEnv(key string, def time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		fatal("invalid duration", "var", key, "value", value)
+	}
+	return d
+}
+
+// setHTTPTimeout applies HTTP_TIMEOUT to the client, before any request
+func setHTTPTimeout() {
+	httpClient.Timeout = durationFromEnv("HTTP_TIMEOUT", defaultHTTPTimeout)
+}
+
+// runContext bounds one run, so a hung connection can't stall it forever
+func runContext(parent context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(parent, durationFromEnv("RUN_TIMEOUT", defaultRunTimeout))
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	togglAPI    = "https://api.track.toggl.com/api/v9"
+	clockifyAPI = "https://api.clockify.me/api/v1"
+)
+
+// Time trackers the day's time entries can be read from
+const (
+	providerToggl    = "toggl"
+	providerClockify = "clockify"
+)
+
+// timeTrackingConfig reads the day's time entries from Toggl Track or
+// Clockify
+type timeTrackingConfig struct {
+	// "toggl" or "clockify"
+	Provider string `json:"provider"`
+	// API token, TOGGL_API_TOKEN or CLOCKIFY_API_KEY when not set
+	Token string `json:"token,omitempty"`
+	// Clockify workspace, the active one of the user by default
+	Workspace string `json:"workspace,omitempty"`
+}
+
+func (c *timeTrackingConfig) validate() error {
+	switch c.Provider {
+	case providerToggl, providerClockify:
+		return nil
+	}
+	return fmt.Errorf("time_tracking: provider must be %s or %s", providerToggl, providerClockify)
+}
+
+func (c *timeTrackingConfig) token() string {
+	if c.Token != "" {
+		return c.Token
+	}
+	if c.Provider == providerClockify {
+		return os.Getenv("CLOCKIFY_API_KEY")
+	}
+	return os.Getenv("TOGGL_API_TOKEN")
+}
+
+// A time entry of the day, running ones up to now
+type timeEntry struct {
+	description string
+	duration    time.Duration
+}
+
+// getTimeEntries returns the time entries started on the day
+func getTimeEntries(ctx context.Context, c *timeTrackingConfig, day time.Time) ([]timeEntry, error) {
+	if offline {
+		return nil, errOffline
+	}
+	token := c.token()
+	if token == "" {
+		return nil, errors.New("time_tracking: token, TOGGL_API_TOKEN or CLOCKIFY_API_KEY is required")
+	}
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	end := start.AddDate(0, 0, 1)
+	if c.Provider == providerClockify {
+		return getClockifyEntries(ctx, c.Workspace, token, start, end)
+	}
+	return getTogglEntries(ctx, token, start, end)
+}
+
+func getTogglEntries(ctx context.Context, token string, start, end time.Time) ([]timeEntry, error) {
+	query := url.Values{
+		"start_date": {start.Format(time.RFC3339)},
+		"end_date":   {end.Format(time.RFC3339)},
+	}
+	auth := base64.StdEncoding.EncodeToString([]byte(token + ":api_token"))
+	var result []struct {
+		Description string `json:"description"`
+		Start       string `json:"start"`
+		// Seconds, negative while running
+		Duration int64 `json:"duration"`
+	}
+	err := sendJSON(ctx, "GET", togglAPI+"/me/time_entries?"+query.Encode(), map[string]string{"Authorization": "Basic " + auth}, nil, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []timeEntry
+	for _, e := range result {
+		duration := time.Duration(e.Duration) * time.Second
+		if e.Duration < 0 {
+			started, err := time.Parse(time.RFC3339, e.Start)
+			if err != nil {
+				continue
+			}
+			duration = time.Since(started)
+		}
+		entries = append(entries, timeEntry{description: e.Description, duration: duration})
+	}
+	return entries, nil
+}
+
+func getClockifyEntries(ctx context.Context, workspace, token string, start, end time.Time) ([]timeEntry, error) {
+	headers := map[string]string{"X-Api-Key": token}
+	var user struct {
+		ID              string `json:"id"`
+		ActiveWorkspace string `json:"activeWorkspace"`
+	}
+	if err := sendJSON(ctx, "GET", clockifyAPI+"/user", headers, nil, &user); err != nil {
+		return nil, err
+	}
+	if workspace == "" {
+		workspace = user.ActiveWorkspace
+	}
+
+	query := url.Values{
+		"start":     {start.UTC().Format(time.RFC3339)},
+		"end":       {end.UTC().Format(time.RFC3339)},
+		"page-size": {"200"},
+	}
+	var result []struct {
+		Description  string `json:"description"`
+		TimeInterval struct {
+			Start string `json:"start"`
+			// Empty while running
+			End string `json:"end"`
+		} `json:"timeInterval"`
+	}
+	u := fmt.Sprintf("%s/workspaces/%s/user/%s/time-entries?%s", clockifyAPI, url.PathEscape(workspace), url.PathEscape(user.ID), query.Encode())
+	if err := sendJSON(ctx, "GET", u, headers, nil, &result); err != nil {
+		return nil, err
+	}
+
+	var entries []timeEntry
+	for _, e := range result {
+		started, err := time.Parse(time.RFC3339, e.TimeInterval.Start)
+		if err != nil {
+			continue
+		}
+		ended := time.Now()
+		if e.TimeInterval.End != "" {
+			if ended, err = time.Parse(time.RFC3339, e.TimeInterval.End); err != nil {
+				continue
+			}
+		}
+		entries = append(entries, timeEntry{description: e.Description, duration: ended.Sub(started)})
+	}
+	return entries, nil
+}
+
+// References to pull requests in time entry descriptions, e.g.
+// "octocat/hello-world#12", "hello-world#12" or "#12"
+var prReference = regexp.MustCompile(`([\w.-]+/)?([\w.-]+)?#(\d+)`)
+
+// matchTimeEntry returns the index of the report entry the time entry is
+// about: the pull request its description refers to, or else the entry whose
+// title it contains or is contained in, ignoring case. It is -1 when none or
+// several entries match.
+func matchTimeEntry(entries []reportEntry, description string) int {
+	match := func(ok func(reportEntry) bool) int {
+		found := -1
+		for i, entry := range entries {
+			if !ok(entry) {
+				continue
+			}
+			if found >= 0 {
+				return -1
+			}
+			found = i
+		}
+		return found
+	}
+
+	for _, ref := range prReference.FindAllStringSubmatch(description, -1) {
+		owner, name := strings.TrimSuffix(ref[1], "/"), ref[2]
+		number, _ := strconv.Atoi(ref[3])
+		i := match(func(e reportEntry) bool {
+			repoOwner, repoName, _ := strings.Cut(e.repo, "/")
+			return e.number == number && (name == "" || name == repoName) && (owner == "" || owner == repoOwner)
+		})
+		if i >= 0 {
+			return i
+		}
+	}
+
+	text := strings.ToLower(strings.TrimSpace(description))
+	if text == "" {
+		return -1
+	}
+	return match(func(e reportEntry) bool {
+		title := strings.ToLower(e.title)
+		return title != "" && (strings.Contains(text, title) || strings.Contains(title, text))
+	})
+}
+
+// trackTime adds the time entries to the hours of the entries they match,
+// and lists the others under "Time tracked" with the day's total
+func (r *generatedReport) trackTime(timeEntries []timeEntry) {
+	var total time.Duration
+	var descriptions []string
+	unmatched := make(map[string]time.Duration)
+	for _, t := range timeEntries {
+		total += t.duration
+		if i := matchTimeEntry(r.entries, t.description); i >= 0 {
+			r.entries[i].trackedHours += t.duration.Hours()
+			continue
+		}
+		description := strings.TrimSpace(t.description)
+		if description == "" {
+			description = r.messages.text("(no description)")
+		}
+		if _, ok := unmatched[description]; !ok {
+			descriptions = append(descriptions, description)
+		}
+		unmatched[description] += t.duration
+	}
+	if len(timeEntries) == 0 {
+		return
+	}
+
+	section := reportSection{title: "Time tracked"}
+	for _, description := range descriptions {
+		text := fmt.Sprintf("%s (%s)", description, formatHours(roundHours(unmatched[description].Hours())))
+		section.lines = append(section.lines, reportLine{text: text})
+	}
+	section.lines = append(section.lines, reportLine{text: r.messages.textf("Total: %s", formatHours(roundHours(total.Hours())))})
+	r.sections = append(r.sections, section)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Matches a TODO or FIXME comment and its text, e.g. "// TODO(me): retry"
+var todoPattern = regexp.MustCompile(`\b(TODO|FIXME)\b(?:\([^)]*\))?:?\s*(.*)`)
+
+// addedTodos returns the TODO and FIXME comments added by a unified diff
+func addedTodos(patch string) []string {
+	var todos []string
+	for _, line := range strings.Split(patch, "\n") {
+		if !strings.HasPrefix(line, "+") || strings.HasPrefix(line, "+++") {
+			continue
+		}
+		m := todoPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		text := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(m[2]), "*/"))
+		if text == "" {
+			text = m[1]
+		}
+		todos = append(todos, fmt.Sprintf("%s: %s", m[1], text))
+	}
+	return todos
+}
+
+// getTodoItems scans the diffs of the day's merged pull requests for newly
+// added TODO and FIXME comments, as candidate Next items, e.g.
+// "Follow up on TODO: retry on timeout (api/client.go)"
+func getTodoItems(ctx context.Context, entries []reportEntry, token string) ([]reportLine, error) {
+	var merged []reportEntry
+	for _, entry := range entries {
+		if entry.number != 0 && entry.status == "Done" {
+			merged = append(merged, entry)
+		}
+	}
+
+	items := make([][]reportLine, len(merged))
+	err := runPool(ctx, fetchConcurrency, len(merged), func(ctx context.Context, i int) error {
+		var files []prFile
+		url := fmt.Sprintf("%s/repos/%s/pulls/%d/files?per_page=100", githubAPI, merged[i].repo, merged[i].number)
+		if err := githubGet(ctx, url, token, &files); err != nil {
+			return err
+		}
+		for _, f := range files {
+			for _, todo := range addedTodos(f.Patch) {
+				items[i] = append(items[i], reportLine{text: fmt.Sprintf("Follow up on %s (%s)", todo, f.Filename), repo: merged[i].repo})
+			}
+		}
+		return nil
+	})
+
+	var lines []reportLine
+	for _, l := range items {
+		lines = append(lines, l...)
+	}
+	return lines, err
+}
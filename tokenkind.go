@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tokenKind is a kind of GitHub token, told apart by its prefix
+type tokenKind struct {
+	name string
+	// Authorization scheme: "token" for classic tokens, "Bearer" for the
+	// others, which don't work with "token" everywhere
+	scheme string
+	// Whether the token signs in as a user, so /user and notifications
+	// work: installation tokens act as an app instead, and fine-grained
+	// tokens can't read notifications
+	user, notifications bool
+}
+
+var (
+	classicToken      = tokenKind{name: "classic personal access token", scheme: "token", user: true, notifications: true}
+	fineGrainedToken  = tokenKind{name: "fine-grained personal access token", scheme: "Bearer", user: true}
+	oauthToken        = tokenKind{name: "OAuth token", scheme: "Bearer", user: true, notifications: true}
+	appUserToken      = tokenKind{name: "GitHub App user token", scheme: "Bearer", user: true}
+	installationToken = tokenKind{name: "GitHub App installation token", scheme: "Bearer"}
+)
+
+// kindOfToken returns the kind of a GitHub token from its prefix, e.g.
+// "github_pat_" for fine-grained tokens. Tokens from before prefixes, 40
+// hex digits, are classic.
+func kindOfToken(token string) tokenKind {
+	switch {
+	case strings.HasPrefix(token, "github_pat_"):
+		return fineGrainedToken
+	case strings.HasPrefix(token, "gho_"):
+		return oauthToken
+	case strings.HasPrefix(token, "ghu_"):
+		return appUserToken
+	case strings.HasPrefix(token, "ghs_"), strings.HasPrefix(token, "v1."):
+		return installationToken
+	}
+	return classicToken
+}
+
+// githubAuthorization returns the Authorization header of requests made
+// with the token, empty without one
+func githubAuthorization(token string) string {
+	if token == "" {
+		return ""
+	}
+	return kindOfToken(token).scheme + " " + token
+}
+
+// needsNotifications returns an error when the token can't read
+// notifications
+func needsNotifications(token string) error {
+	if kind := kindOfToken(token); !kind.notifications {
+		return fmt.Errorf("a %s can't read notifications, use a classic personal access token or auth login", kind.name)
+	}
+	return nil
+}
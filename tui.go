@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Statuses an entry can be switched between in the dashboard
+var tuiStatuses = []string{"Done", "In Review", "WIP", "Committed", "Reviewed", "Reviewed (approved)", "Requested changes", "Reviewed (commented)", "Reviewed and merged"}
+
+// tuiModel lets me review today's entries before the report is sent
+type tuiModel struct {
+	rep    *generatedReport
+	cursor int
+	// Set when I chose to send the report
+	send bool
+}
+
+func (m tuiModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch key.String() {
+	case "ctrl+c", "q", "esc":
+		return m, tea.Quit
+	case "enter":
+		m.send = true
+		return m, tea.Quit
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.rep.entries)-1 {
+			m.cursor++
+		}
+	case " ", "x":
+		if len(m.rep.entries) > 0 {
+			m.rep.entries[m.cursor].hidden = !m.rep.entries[m.cursor].hidden
+		}
+	case "right", "l", "s":
+		m.cycleStatus(1)
+	case "left", "h", "S":
+		m.cycleStatus(-1)
+	}
+	return m, nil
+}
+
+// cycleStatus moves the selected entry to the next or previous status
+func (m tuiModel) cycleStatus(step int) {
+	if len(m.rep.entries) == 0 {
+		return
+	}
+	entry := &m.rep.entries[m.cursor]
+
+	i := 0
+	for j, status := range tuiStatuses {
+		if status == entry.status {
+			i = j
+		}
+	}
+	entry.status = tuiStatuses[(i+step+len(tuiStatuses))%len(tuiStatuses)]
+}
+
+func (m tuiModel) View() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Report for %s\n\n", m.rep.date)
+
+	if len(m.rep.entries) == 0 {
+		b.WriteString("  No entries today\n")
+	}
+	for i, entry := range m.rep.entries {
+		cursor := " "
+		if i == m.cursor {
+			cursor = ">"
+		}
+		check := "x"
+		if entry.hidden {
+			check = " "
+		}
+		fmt.Fprintf(&b, "%s [%s] %-20s %s", cursor, check, entry.status, entry.title)
+		if entry.repo != "" {
+			fmt.Fprintf(&b, "  (%s)", entry.repo)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n↑/↓ move  space toggle  ←/→ status  enter send  q quit\n")
+	return b.String()
+}
+
+// runTUI shows the generated report in the dashboard and returns whether
+// it should be sent, with my changes applied to rep's entries
+func runTUI(rep *generatedReport) (bool, error) {
+	final, err := tea.NewProgram(tuiModel{rep: rep}).Run()
+	if err != nil {
+		return false, err
+	}
+	return final.(tuiModel).send, nil
+}
+
+// dashboard implements the "tui" command: fetch today's report, edit its
+// entries, then save it and deliver it to the targets of the final schedules
+func dashboard(opts reportOptions) error {
+	cfg, err := loadConfig(configPath())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := runContext(context.Background())
+	rep, err := generateReport(ctx, cfg, opts)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	send, err := runTUI(rep)
+	if err != nil || !send {
+		return err
+	}
+
+	if err := applyDuplicatePolicy(rep, cfg.duplicatePolicy()); err != nil {
+		return err
+	}
+	if err := recordHistory(rep, rep.render(nil)); err != nil {
+		return err
+	}
+	ctx, cancel = runContext(context.Background())
+	defer cancel()
+	return deliverToTargets(ctx, cfg.Hooks, rep, rep.render(nil), cfg.finalTargets())
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// pushedSince returns the commits reachable from the clone's remote-tracking
+// branches, as of its last fetch, that were committed since the given time.
+// Older history isn't walked.
+func pushedSince(repo *git.Repository, since time.Time) (map[plumbing.Hash]bool, error) {
+	refs, err := repo.References()
+	if err != nil {
+		return nil, err
+	}
+
+	var queue []plumbing.Hash
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Name().IsRemote() && ref.Type() == plumbing.HashReference {
+			queue = append(queue, ref.Hash())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pushed := make(map[plumbing.Hash]bool)
+	for len(queue) > 0 {
+		hash := queue[0]
+		queue = queue[1:]
+		if pushed[hash] {
+			continue
+		}
+		c, err := object.GetCommit(repo.Storer, hash)
+		if err != nil {
+			return nil, err
+		}
+		pushed[hash] = true
+		if c.Committer.When.Before(since) {
+			continue
+		}
+		queue = append(queue, c.ParentHashes...)
+	}
+	return pushed, nil
+}
+
+// checkUnpushed warns about my commits of the day in the local clones that
+// no remote branch contains, since GitHub can't have reported them
+func checkUnpushed(ctx context.Context, root, date, email string) ([]string, error) {
+	paths, err := findClones(root)
+	if err != nil {
+		return nil, err
+	}
+	day, err := time.ParseInLocation(dateFormat, date, time.Local)
+	if err != nil {
+		return nil, err
+	}
+
+	warnings := make([]string, len(paths))
+	err = runPool(ctx, fetchConcurrency, len(paths), func(ctx context.Context, i int) error {
+		commits, err := getRepoCommits(paths[i], date, email)
+		if err != nil || len(commits) == 0 {
+			return err
+		}
+		repo, err := git.PlainOpen(paths[i])
+		if err != nil {
+			return err
+		}
+		pushed, err := pushedSince(repo, day)
+		if err != nil {
+			return err
+		}
+
+		unpushed := 0
+		for _, c := range commits {
+			sha, _ := c["payload"].(map[string]interface{})["sha"].(string)
+			if !pushed[plumbing.NewHash(sha)] {
+				unpushed++
+			}
+		}
+		if unpushed == 0 {
+			return nil
+		}
+
+		repoName, _ := commits[0]["repo"].(map[string]interface{})
+		name, _ := repoName["name"].(string)
+		noun := "commits"
+		if unpushed == 1 {
+			noun = "commit"
+		}
+		warnings[i] = fmt.Sprintf("%d local %s in %s not pushed — report may be incomplete", unpushed, noun, name)
+		return nil
+	})
+
+	var found []string
+	for _, w := range warnings {
+		if w != "" {
+			found = append(found, w)
+		}
+	}
+	return found, err
+}
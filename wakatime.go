@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const wakaTimeAPI = "https://wakatime.com/api/v1"
+
+// Languages and projects named on the coding activity line by default
+const defaultWakaTimeTop = 3
+
+// wakaTimeConfig adds the day's coding activity from WakaTime
+type wakaTimeConfig struct {
+	// API key, WAKATIME_API_KEY when not set
+	APIKey string `json:"api_key,omitempty"`
+	// API of a WakaTime compatible server such as Wakapi, e.g.
+	// "https://wakapi.example.com/api/compat/wakatime/v1"
+	APIURL string `json:"api_url,omitempty"`
+	// Languages and projects named, 3 by default
+	Top int `json:"top,omitempty"`
+}
+
+// Time spent on a language or project as summarized by WakaTime
+type wakaTimeItem struct {
+	Name         string  `json:"name"`
+	TotalSeconds float64 `json:"total_seconds"`
+}
+
+// formatDuration writes a duration in hours and minutes, e.g. "6h 13m"
+func formatDuration(seconds float64) string {
+	d := time.Duration(seconds) * time.Second
+	hours, minutes := int(d.Hours()), int(d.Minutes())%60
+	switch {
+	case hours == 0:
+		return fmt.Sprintf("%dm", minutes)
+	case minutes == 0:
+		return fmt.Sprintf("%dh", hours)
+	}
+	return fmt.Sprintf("%dh %dm", hours, minutes)
+}
+
+// topItems writes the first n items with their time, e.g. "Go 4h 2m, SQL 25m"
+func topItems(items []wakaTimeItem, n int) string {
+	var named []string
+	for _, item := range items[:min(len(items), n)] {
+		named = append(named, item.Name+" "+formatDuration(item.TotalSeconds))
+	}
+	return strings.Join(named, ", ")
+}
+
+// getCodingActivity returns the line of the day's coding time with the top
+// languages and projects, none without coding time
+func getCodingActivity(ctx context.Context, c *wakaTimeConfig, day string, messages catalog) ([]reportLine, error) {
+	if offline {
+		return nil, errOffline
+	}
+	key := c.APIKey
+	if key == "" {
+		key = os.Getenv("WAKATIME_API_KEY")
+	}
+	if key == "" {
+		return nil, errors.New("wakatime: api_key or WAKATIME_API_KEY is required")
+	}
+	api := c.APIURL
+	if api == "" {
+		api = wakaTimeAPI
+	}
+	top := c.Top
+	if top == 0 {
+		top = defaultWakaTimeTop
+	}
+
+	var result struct {
+		Data []struct {
+			GrandTotal struct {
+				TotalSeconds float64 `json:"total_seconds"`
+			} `json:"grand_total"`
+			// Most time first
+			Languages []wakaTimeItem `json:"languages"`
+			Projects  []wakaTimeItem `json:"projects"`
+		} `json:"data"`
+	}
+	query := url.Values{"start": {day}, "end": {day}}
+	auth := base64.StdEncoding.EncodeToString([]byte(key))
+	u := strings.TrimSuffix(api, "/") + "/users/current/summaries?" + query.Encode()
+	if err := sendJSON(ctx, "GET", u, map[string]string{"Authorization": "Basic " + auth}, nil, &result); err != nil {
+		return nil, err
+	}
+	if len(result.Data) == 0 || result.Data[0].GrandTotal.TotalSeconds == 0 {
+		return nil, nil
+	}
+
+	summary := result.Data[0]
+	line := messages.textf("%s of coding", formatDuration(summary.GrandTotal.TotalSeconds))
+	if languages := topItems(summary.Languages, top); languages != "" {
+		line += "; " + messages.textf("languages: %s", languages)
+	}
+	if projects := topItems(summary.Projects, top); projects != "" {
+		line += "; " + messages.textf("projects: %s", projects)
+	}
+	return []reportLine{{text: line}}, nil
+}
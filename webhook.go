@@ -0,0 +1,257 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Largest webhook delivery accepted, GitHub caps payloads at 25 MB
+const maxWebhookPayload = 25 << 20
+
+// validSignature checks the X-Hub-Signature-256 header of a delivery
+// against the webhook secret
+func validSignature(secret string, body []byte, signature string) bool {
+	given, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil || !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(given, mac.Sum(nil))
+}
+
+// webhookEvents turns a webhook delivery into events shaped like the ones
+// of the events API, keeping only what the logins kept did: their pull
+// request and review activity, the branches, tags and releases they created
+// or deleted, and the commits they authored in a push
+func webhookEvents(kind, delivery string, payload map[string]interface{}, keep func(login string) bool, received time.Time) []map[string]interface{} {
+	sender, _ := payload["sender"].(map[string]interface{})
+	login, _ := sender["login"].(string)
+	repository, _ := payload["repository"].(map[string]interface{})
+	repo := map[string]interface{}{"name": repository["full_name"]}
+
+	switch kind {
+	case "pull_request", "pull_request_review":
+		if !keep(login) {
+			return nil
+		}
+		eventType := "PullRequestEvent"
+		if kind == "pull_request_review" {
+			eventType = "PullRequestReviewEvent"
+		}
+		return []map[string]interface{}{{
+			"id":         delivery,
+			"type":       eventType,
+			"created_at": received.Format(time.RFC3339),
+			"actor":      map[string]interface{}{"login": login},
+			"repo":       repo,
+			"payload":    payload,
+		}}
+
+	case "create", "delete", "release":
+		if !keep(login) {
+			return nil
+		}
+		eventType := map[string]string{"create": "CreateEvent", "delete": "DeleteEvent", "release": "ReleaseEvent"}[kind]
+		return []map[string]interface{}{{
+			"id":         delivery,
+			"type":       eventType,
+			"created_at": received.Format(time.RFC3339),
+			"actor":      map[string]interface{}{"login": login},
+			"repo":       repo,
+			"payload":    payload,
+		}}
+
+	case "push":
+		commits, _ := payload["commits"].([]interface{})
+		var events []map[string]interface{}
+		for _, c := range commits {
+			commit, _ := c.(map[string]interface{})
+			author, _ := commit["author"].(map[string]interface{})
+			authorLogin, _ := author["username"].(string)
+			if authorLogin == "" || !keep(authorLogin) {
+				continue
+			}
+			message, _ := commit["message"].(string)
+			message, _, _ = strings.Cut(strings.TrimSpace(message), "\n")
+			var files []interface{}
+			for _, change := range []string{"added", "modified", "removed"} {
+				changed, _ := commit[change].([]interface{})
+				files = append(files, changed...)
+			}
+
+			createdAt := received.Format(time.RFC3339)
+			if timestamp, ok := commit["timestamp"].(string); ok {
+				createdAt = timestamp
+			}
+			events = append(events, map[string]interface{}{
+				"id":         fmt.Sprintf("%s/%v", delivery, commit["id"]),
+				"type":       localCommitEvent,
+				"created_at": createdAt,
+				"actor":      map[string]interface{}{"login": authorLogin},
+				"repo":       repo,
+				"payload": map[string]interface{}{
+					"sha":     commit["id"],
+					"message": message,
+					"files":   files,
+				},
+			})
+		}
+		return events
+	}
+	return nil
+}
+
+// saveWebhookEvent stores an event received by webhook, once however many
+// times GitHub redelivers it
+func (h *historyStore) saveWebhookEvent(event map[string]interface{}) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	createdAt, _ := time.Parse(time.RFC3339, event["created_at"].(string))
+	_, err = h.db.Exec(`INSERT INTO webhook_events (id, date, event, received_at) VALUES (?, ?, ?, ?) ON CONFLICT (id) DO NOTHING`,
+		event["id"], createdAt.In(time.Local).Format(dateFormat), string(data), time.Now().Format(time.RFC3339))
+	return err
+}
+
+// storedWebhookEvents returns the events of login received by webhook for
+// the day, in the order they were received
+func storedWebhookEvents(date, login string) ([]map[string]interface{}, error) {
+	h, err := openHistory(historyPath())
+	if err != nil {
+		return nil, err
+	}
+	defer h.Close()
+
+	rows, err := h.db.Query(`SELECT event FROM webhook_events WHERE date = ? AND json_extract(event, '$.actor.login') = ? ORDER BY rowid`, date, login)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []map[string]interface{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var event map[string]interface{}
+		if err := parseJSON([]byte(data), &event); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// watchedLogin reports whether the events of login are stored from
+// webhooks: the configured user's and the team's, for the digest
+func (cfg *config) watchedLogin(login string) bool {
+	return login != "" && (login == cfg.GitHub.Username || slices.Contains(cfg.Team, login))
+}
+
+// handleWebhook receives GitHub webhook deliveries of push, pull_request and
+// pull_request_review events and stores what the configured user and the
+// team did. Deliveries to a GitHub App also keep track of the repositories
+// it's installed on.
+func (s *scheduler) handleWebhook(secret string) http.HandlerFunc {
+	return webhookHandler(secret, s.config)
+}
+
+// webhookHandler stores the webhook deliveries signed with secret, as of
+// the config returned by cfg when they arrive
+func webhookHandler(secret string, cfg func() *config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookPayload))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if !validSignature(secret, body, r.Header.Get("X-Hub-Signature-256")) {
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+
+		kind, delivery := r.Header.Get("X-GitHub-Event"), r.Header.Get("X-GitHub-Delivery")
+		var payload map[string]interface{}
+		if err := parseJSON(body, &payload); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		h, err := openHistory(historyPath())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer h.Close()
+		if installation, err := h.saveSubscriptions(kind, payload); err != nil || installation {
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			slog.Info("GitHub App installation changed", "event", kind, "action", payload["action"])
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+
+		events := webhookEvents(kind, delivery, payload, cfg().watchedLogin, time.Now())
+		slog.Debug("Webhook delivery", "event", kind, "delivery", delivery, "kept", len(events))
+		if len(events) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		for _, event := range events {
+			if err := h.saveWebhookEvent(event); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// serveWebhook receives GitHub webhook deliveries on POST /webhook
+func (s *scheduler) serveWebhook(addr, secret string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /webhook", s.handleWebhook(secret))
+
+	slog.Info("Receiving GitHub webhooks", "addr", addr)
+	fatal("webhook server stopped", "err", http.ListenAndServe(addr, mux))
+}
+
+// receive implements "receive [--addr :8081]", only receiving GitHub
+// webhooks and storing their events as they arrive, without the schedules
+// of serve. Reports generated later add them to the events API's.
+func receive(args []string) error {
+	fs := flag.NewFlagSet("receive", flag.ExitOnError)
+	addr := fs.String("addr", pick("", "WEBHOOK_ADDR", defaultWebhookAddr), "address GitHub webhooks are received on")
+	fs.Parse(args)
+
+	secret := os.Getenv("WEBHOOK_SECRET")
+	if secret == "" {
+		return errors.New("WEBHOOK_SECRET is required to receive webhooks")
+	}
+	cfg, err := loadConfig(configPath())
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /webhook", webhookHandler(secret, func() *config { return cfg }))
+	slog.Info("Receiving GitHub webhooks", "addr", *addr)
+	return http.ListenAndServe(*addr, mux)
+}
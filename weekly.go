@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// The Monday starting the week of t
+func mondayOf(t time.Time) time.Time {
+	return t.AddDate(0, 0, -(int(t.Weekday())+6)%7)
+}
+
+// Monday to Friday of the week of t, up to t
+func weekDays(t time.Time) []string {
+	var days []string
+	monday := mondayOf(t)
+	for d := monday; d.Before(monday.AddDate(0, 0, 5)) && !d.After(t); d = d.AddDate(0, 0, 1) {
+		days = append(days, d.Format(dateFormat))
+	}
+	return days
+}
+
+// generateWeeklyReport aggregates this week's entries, Monday to Friday,
+// grouped by repository
+func generateWeeklyReport(ctx context.Context, cfg *config, opts reportOptions) (*generatedReport, error) {
+	now := time.Now()
+	events, err := getWeekEvents(ctx, cfg, weekDays(now), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := cfg.messages()
+	header := messages.textf("Week of %s", cfg.formatHeaderDate(mondayOf(now)))
+	entries := buildEntries(cfg.withoutIgnored(events), cfg.GitHub.Username)
+	tagComponents(cfg.Components, entries)
+	classifyPrivacy(cfg, entries)
+	categorizeEntries(cfg, entries)
+	var sections []reportSection
+	if cfg.ReviewBalance {
+		sections = append(sections, reviewBalanceSection(events, cfg.GitHub.Username))
+	}
+	var retro *retrospective
+	if cfg.Retro && now.Weekday() == time.Friday {
+		if retro, err = newRetrospective(ctx, cfg, entries); err != nil {
+			return nil, err
+		}
+	}
+	return &generatedReport{
+		date:     now.Format(dateFormat),
+		username: cfg.GitHub.Username,
+		header:   header,
+		events:   events,
+		entries:  entries,
+		sections: sections,
+		messages: messages,
+		format: func(_ *generatedReport, entries []reportEntry, orgs []string) string {
+			report := formatWeekly(header, entries, filterSections(sections, orgs), messages)
+			if retro != nil {
+				report += "\n" + retro.render(orgs)
+			}
+			return report
+		},
+	}, nil
+}
+
+// getWeekEvents returns the events of the days, taken from the history where
+// stored and fetched from the API and local clones otherwise
+func getWeekEvents(ctx context.Context, cfg *config, days []string, opts reportOptions) ([]map[string]interface{}, error) {
+	h, err := openHistory(historyPath())
+	if err != nil {
+		return nil, err
+	}
+	defer h.Close()
+
+	var events []map[string]interface{}
+	var missing []string
+	for _, day := range days {
+		record, err := h.get(day)
+		if err != nil {
+			missing = append(missing, day)
+			continue
+		}
+		events = append(events, record.events...)
+	}
+	if len(missing) == 0 {
+		return events, nil
+	}
+
+	token, err := githubToken(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	apiEvents, err := getDailyEvents(ctx, cfg.GitHub.Username, token, missing...)
+	if err != nil {
+		return nil, err
+	}
+	events = append(events, apiEvents...)
+
+	if opts.localRoot != "" {
+		for _, day := range missing {
+			localEvents, err := getLocalEvents(ctx, opts.localRoot, day, localAuthorEmail())
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, localEvents...)
+		}
+	}
+	return events, nil
+}
+
+// formatWeekly renders one block of entries per repository, then the sections
+func formatWeekly(header string, entries []reportEntry, sections []reportSection, c catalog) string {
+	byRepo := make(map[string][]reportEntry)
+	for _, entry := range entries {
+		byRepo[entry.repo] = append(byRepo[entry.repo], entry)
+	}
+
+	repos := make([]string, 0, len(byRepo))
+	for repo := range byRepo {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+
+	report := fmt.Sprintf("%s:\n", header)
+	for _, repo := range repos {
+		report += fmt.Sprintf("\n%s:\n", repo)
+		for _, entry := range byRepo[repo] {
+			report += fmt.Sprintf("%s%s | %s\n", entry.bullet, entry.statusText(c), entry.title)
+		}
+	}
+
+	return report + "\n" + formatTail(sections, nil, c)
+}